@@ -0,0 +1,128 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"blog/models"
+	"blog/oauthserver"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix API 密钥前缀，用于区分 API 密钥和其他令牌
+const APIKeyPrefix = "bk_"
+
+// apiKeyTouchInterval 最近使用时间的更新间隔，避免每次请求都写数据库
+const apiKeyTouchInterval = time.Minute
+
+var (
+	// ErrAPIKeyInvalid API 密钥不存在、已过期或已失效
+	ErrAPIKeyInvalid = errors.New("API 密钥无效或已过期")
+	// ErrAPIKeyScope 申请了不存在的权限
+	ErrAPIKeyScope = errors.New("不支持的权限")
+)
+
+// IsAPIKey 是否为 API 密钥
+func IsAPIKey(key string) bool {
+	return strings.HasPrefix(key, APIKeyPrefix)
+}
+
+// CreateAPIKey 创建 API 密钥，返回记录和完整密钥（只在此时可见）
+//
+// 权限与第三方应用相同；用户重置密码或申请注销后密钥失效。
+func CreateAPIKey(db *gorm.DB, user *models.User, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	var scopes []string
+	for _, scope := range req.Scopes {
+		if _, ok := oauthserver.LookupScope(scope); !ok {
+			return nil, "", fmt.Errorf("%w: %s", ErrAPIKeyScope, scope)
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	secret, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		UserID:       user.ID,
+		Name:         req.Name,
+		Prefix:       APIKeyPrefix + hex.EncodeToString(id),
+		SecretHash:   hashToken(secret),
+		Scopes:       strings.Join(scopes, " "),
+		TokenVersion: user.TokenVersion,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := db.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, key.Prefix + "_" + secret, nil
+}
+
+// AuthenticateAPIKey 校验 API 密钥，返回密钥记录和对应用户，并更新最近使用时间
+func AuthenticateAPIKey(db *gorm.DB, raw string) (*models.APIKey, *models.User, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(raw, APIKeyPrefix), "_")
+	if !ok || !IsAPIKey(raw) {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	prefix = APIKeyPrefix + prefix
+
+	var key models.APIKey
+	if err := db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAPIKeyInvalid
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(now) {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+
+	var user models.User
+	if err := db.Select("id", "username", "status", "token_version").First(&user, key.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAPIKeyInvalid
+		}
+		return nil, nil, err
+	}
+	if user.Status != 1 || user.TokenVersion != key.TokenVersion {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := db.Model(&models.APIKey{}).Where("id = ?", key.ID).Update("last_used_at", now).Error; err != nil {
+			logrus.WithError(err).WithField("api_key", key.Prefix).Warn("更新 API 密钥使用时间失败")
+		}
+		key.LastUsedAt = &now
+	}
+	return &key, &user, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package account
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blog/config"
+	"blog/events"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 注销后评论和文章归属的占位账号
+const (
+	ghostUsername = "deleted-user"
+	ghostEmail    = "deleted-user@users.invalid"
+	ghostNickname = "已注销用户"
+)
+
+// ScheduleDeletion 申请注销账号，撤销全部令牌，冷静期结束后删除
+//
+// 冷静期为 0 时立即删除。
+func ScheduleDeletion(db *gorm.DB, user *models.User) (*time.Time, error) {
+	at := time.Now().Add(config.Account.DeletionGrace)
+	if err := db.Model(user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": at,
+		"token_version":         gorm.Expr("token_version + 1"),
+	}).Error; err != nil {
+		return nil, err
+	}
+	user.DeletionScheduledAt = &at
+
+	if config.Account.DeletionGrace <= 0 {
+		return &at, Purge(db, user.ID)
+	}
+	return &at, nil
+}
+
+// CancelDeletion 撤销注销申请
+func CancelDeletion(db *gorm.DB, user *models.User) error {
+	if err := db.Model(user).Update("deletion_scheduled_at", nil).Error; err != nil {
+		return err
+	}
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+// PurgeDue 删除冷静期已结束的账号
+//
+// 单个账号删除失败时记录日志并继续处理其余账号，返回成功删除的数量和汇总的错误。
+func PurgeDue(db *gorm.DB) (int, error) {
+	var ids []uint
+	if err := db.Model(&models.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, id := range ids {
+		if err := Purge(db, id); err != nil {
+			logrus.WithError(err).WithField("user_id", id).Error("删除注销账号失败")
+			errs = append(errs, fmt.Errorf("删除用户 %d 失败: %w", id, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// Purge 删除账号
+//
+// 评论转移给"已注销用户"并清除 IP 和 User-Agent；文章按配置转移或删除；
+// 登录记录、后台任务、邮件令牌、API 密钥、第三方应用授权和导出文件一并删除，最后删除用户记录本身。
+func Purge(db *gorm.DB, userID uint) error {
+	var (
+		affected  []uint
+		commented []uint // 用户发表过评论的文章，评论转移后这些文章的评论列表需要刷新
+		deleted   bool
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		ghost, err := ghostUser(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).
+			Distinct().Pluck("post_id", &commented).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"user_id": ghost, "ip_address": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &affected).Error; err != nil {
+			return err
+		}
+		if config.Account.PostPolicy == config.PostPolicyDelete {
+			deleted = true
+			if err := deletePosts(tx, affected); err != nil {
+				return err
+			}
+		} else if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).
+			Update("user_id", ghost).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.LoginLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Job{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.EmailToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.WebAuthnCredential{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.WebAuthnSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.OAuthState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.OAuthToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.OAuthConsent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := deleteOAuthClients(tx, userID); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	removeExports(userID)
+
+	topic := events.PostUpdated
+	if deleted {
+		topic = events.PostDeleted
+	}
+	for _, id := range affected {
+		events.Publish(topic, &models.Post{ID: id})
+	}
+	for _, id := range commented {
+		events.Publish(events.CommentUpdated, &models.Comment{PostID: id})
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id": userID,
+		"posts":   len(affected),
+		"policy":  config.Account.PostPolicy,
+	}).Info("账号已注销")
+	return nil
+}
+
+// deletePosts 删除文章及其评论、标签关联和历史 slug
+func deletePosts(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id IN ?", ids).
+		Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("post_id IN ?", ids).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM ? WHERE post_id IN ?", clause.Table{Name: "post_tags"}, ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id IN ?", ids).Delete(&models.PostSlugHistory{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{}).Error
+}
+
+// deleteOAuthClients 删除用户注册的第三方应用，其他用户对这些应用的授权和已签发的令牌一并删除
+func deleteOAuthClients(tx *gorm.DB, userID uint) error {
+	var clientIDs []string
+	if err := tx.Model(&models.OAuthClient{}).Where("user_id = ?", userID).Pluck("client_id", &clientIDs).Error; err != nil {
+		return err
+	}
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("client_id IN ?", clientIDs).Delete(&models.OAuthToken{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("client_id IN ?", clientIDs).Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("client_id IN ?", clientIDs).Delete(&models.OAuthConsent{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.OAuthClient{}).Error
+}
+
+// ghostUser 获取或创建"已注销用户"占位账号，该账号处于禁用状态，无法登录
+func ghostUser(tx *gorm.DB) (uint, error) {
+	var ghost models.User
+	err := tx.Unscoped().Where("email = ?", ghostEmail).First(&ghost).Error
+	if err == nil {
+		return ghost.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	username := ghostUsername
+	var count int64
+	if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		username += "-" + randomHex(4)
+	}
+
+	ghost = models.User{
+		Username: username,
+		Password: randomHex(32),
+		Email:    ghostEmail,
+		Nickname: ghostNickname,
+	}
+	if err := tx.Create(&ghost).Error; err != nil {
+		return 0, err
+	}
+	// Status 带有 default:1，0 不会在创建时写入
+	if err := tx.Model(&ghost).Update("status", 0).Error; err != nil {
+		return 0, err
+	}
+	return ghost.ID, nil
+}
+
+// removeExports 删除用户的个人数据导出文件
+func removeExports(userID uint) {
+	matches, _ := filepath.Glob(filepath.Join(ExportDir(), fmt.Sprintf("user-%d-*.zip", userID)))
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil {
+			logrus.WithError(err).WithField("file", path).Warn("删除导出文件失败")
+		}
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,241 @@
+package account
+
+import (
+	"errors"
+	"time"
+
+	"blog/config"
+	"blog/mailer"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// 邮箱验证相关错误
+var (
+	ErrInvalidEmailToken = errors.New("验证链接无效或已过期")
+	ErrEmailTaken        = errors.New("该邮箱已被其他账号使用")
+	ErrSameEmail         = errors.New("新邮箱与当前邮箱相同")
+)
+
+// 确认邮件令牌后的结果
+const (
+	EmailVerified      = "verified"       // 注册邮箱验证成功
+	EmailChangePending = "change_pending" // 更换邮箱已确认一方，等待另一方确认
+	EmailChanged       = "changed"        // 更换邮箱完成
+)
+
+// SendVerification 发送注册邮箱验证邮件，已验证或处于冷却期时不发送
+func SendVerification(db *gorm.DB, user *models.User, locale string) error {
+	if user.IsEmailVerified() {
+		return nil
+	}
+	if cooling, err := emailCoolingDown(db, user.ID); err != nil || cooling {
+		return err
+	}
+
+	tokens, err := issueEmailTokens(db, user.ID, user.Email, models.EmailTokenVerify)
+	if err != nil {
+		return err
+	}
+	return sendEmailTemplate("email_verify", locale, user, user.Email, tokens[0])
+}
+
+// ResendVerification 按邮箱重新发送验证邮件
+//
+// 与找回密码一样，邮箱不存在时静默返回，避免泄露邮箱是否已注册。
+func ResendVerification(db *gorm.DB, email, locale string) error {
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return SendVerification(db, &user, locale)
+}
+
+// RequestEmailChange 申请更换邮箱
+//
+// 分别向原邮箱和新邮箱发送确认链接，两边都确认后邮箱才会更换。
+func RequestEmailChange(db *gorm.DB, user *models.User, newEmail, locale string) error {
+	if newEmail == user.Email {
+		return ErrSameEmail
+	}
+	if taken, err := emailTaken(db, newEmail, user.ID); err != nil || taken {
+		if err == nil {
+			err = ErrEmailTaken
+		}
+		return err
+	}
+
+	tokens, err := issueEmailTokens(db, user.ID, newEmail, models.EmailTokenChangeOld, models.EmailTokenChangeNew)
+	if err != nil {
+		return err
+	}
+	if err := sendEmailTemplate("email_change_old", locale, user, user.Email, tokens[0], newEmail); err != nil {
+		return err
+	}
+	return sendEmailTemplate("email_change_new", locale, user, newEmail, tokens[1], newEmail)
+}
+
+// ConfirmEmailToken 确认邮件中的令牌，返回确认结果
+func ConfirmEmailToken(db *gorm.DB, token string) (string, *models.User, error) {
+	var (
+		user   models.User
+		result string
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var record models.EmailToken
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+			First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidEmailToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		update := tx.Model(&models.EmailToken{}).Where("id = ? AND used_at IS NULL", record.ID).Update("used_at", now)
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected != 1 {
+			return ErrInvalidEmailToken
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidEmailToken
+			}
+			return err
+		}
+
+		if record.Purpose == models.EmailTokenVerify {
+			// 验证期间邮箱已被更换时，旧地址的验证链接不再有效
+			if record.Email != user.Email {
+				return ErrInvalidEmailToken
+			}
+			result = EmailVerified
+			if user.EmailVerifiedAt == nil {
+				user.EmailVerifiedAt = &now
+				return tx.Model(&user).Update("email_verified_at", now).Error
+			}
+			return nil
+		}
+
+		// 更换邮箱：另一方也已确认时才生效
+		other := models.EmailTokenChangeNew
+		if record.Purpose == models.EmailTokenChangeNew {
+			other = models.EmailTokenChangeOld
+		}
+		var confirmed int64
+		if err := tx.Model(&models.EmailToken{}).
+			Where("user_id = ? AND purpose = ? AND email = ? AND used_at IS NOT NULL", user.ID, other, record.Email).
+			Count(&confirmed).Error; err != nil {
+			return err
+		}
+		if confirmed == 0 {
+			result = EmailChangePending
+			return nil
+		}
+
+		if taken, err := emailTaken(tx, record.Email, user.ID); err != nil || taken {
+			if err == nil {
+				err = ErrEmailTaken
+			}
+			return err
+		}
+		user.Email = record.Email
+		user.EmailVerifiedAt = &now
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":             record.Email,
+			"email_verified_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		result = EmailChanged
+		// 旧邮箱的验证链接和其他未完成的更换申请全部作废
+		return tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.EmailToken{}).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"result":  result,
+	}).Info("邮箱确认成功")
+	return result, &user, nil
+}
+
+// issueEmailTokens 删除用户同类的旧令牌并生成新令牌，返回与 purposes 对应的明文令牌
+//
+// 已确认的旧令牌也一并删除，确保更换邮箱的两个确认来自同一次申请。
+func issueEmailTokens(db *gorm.DB, userID uint, email string, purposes ...string) ([]string, error) {
+	tokens := make([]string, len(purposes))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose IN ?", userID, purposes).
+			Delete(&models.EmailToken{}).Error; err != nil {
+			return err
+		}
+		for i, purpose := range purposes {
+			token, err := newToken()
+			if err != nil {
+				return err
+			}
+			tokens[i] = token
+			if err := tx.Create(&models.EmailToken{
+				UserID:    userID,
+				Purpose:   purpose,
+				Email:     email,
+				TokenHash: hashToken(token),
+				ExpiresAt: time.Now().Add(config.EmailVerification.TokenTTL),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return tokens, err
+}
+
+// sendEmailTemplate 渲染并在后台发送验证类邮件
+func sendEmailTemplate(name, locale string, user *models.User, to, token string, newEmail ...string) error {
+	data := map[string]interface{}{
+		"SiteName":       config.Site.Name,
+		"Username":       displayName(user),
+		"VerifyURL":      linkWithToken(config.EmailVerification.URL, token),
+		"ExpiresMinutes": int(config.EmailVerification.TokenTTL.Minutes()),
+		"OldEmail":       user.Email,
+	}
+	if len(newEmail) > 0 {
+		data["NewEmail"] = newEmail[0]
+	}
+
+	msg, err := mailer.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	send(msg, user.ID)
+	return nil
+}
+
+// emailCoolingDown 冷却期内是否已发送过验证邮件
+func emailCoolingDown(db *gorm.DB, userID uint) (bool, error) {
+	var recent int64
+	err := db.Model(&models.EmailToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, models.EmailTokenVerify,
+			time.Now().Add(-config.EmailVerification.Cooldown)).
+		Count(&recent).Error
+	return recent > 0, err
+}
+
+// emailTaken 邮箱是否已被其他账号使用（包括已软删除的账号，与唯一索引一致）
+func emailTaken(db *gorm.DB, email string, userID uint) (bool, error) {
+	var count int64
+	err := db.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,187 @@
+package account
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blog/config"
+	"blog/jobs"
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+// JobExport 个人数据导出任务类型
+const JobExport = "user_export"
+
+func init() {
+	jobs.Register(JobExport, runExport)
+}
+
+// ExportResult 导出任务结果
+type ExportResult struct {
+	File string `json:"file"`
+	Size int64  `json:"size"`
+}
+
+// exportPost 导出的文章，包含已删除的文章
+type exportPost struct {
+	models.PostResponse
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// exportComment 导出的评论，附带所属文章标题
+type exportComment struct {
+	models.CommentResponse
+	PostTitle string     `json:"post_title"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// exportLogin 导出的登录记录
+type exportLogin struct {
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	LoginAt   time.Time `json:"login_at"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// exportLikes 导出的点赞记录
+//
+// 点赞只累计到文章和评论的 like_count，不记录点赞人，因此 Items 总是为空。
+type exportLikes struct {
+	Note  string     `json:"note"`
+	Items []struct{} `json:"items"`
+}
+
+// ExportDir 个人数据导出文件目录
+func ExportDir() string {
+	return filepath.Join(config.Storage.DataDir, "exports")
+}
+
+// ExportPath 导出任务对应的文件路径
+func ExportPath(userID, jobID uint) string {
+	return filepath.Join(ExportDir(), fmt.Sprintf("user-%d-%d.zip", userID, jobID))
+}
+
+// runExport 执行个人数据导出任务
+func runExport(jc *jobs.Context) error {
+	path := ExportPath(jc.Job.UserID, jc.Job.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = WriteExport(jc.DB(), jc.Job.UserID, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	result := ExportResult{File: filepath.Base(path)}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	return jc.SetResult(result)
+}
+
+// WriteExport 把用户的个人资料、文章、评论、点赞和登录记录写入 zip
+func WriteExport(db *gorm.DB, userID uint, w *os.File) error {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	var posts []models.Post
+	if err := db.Unscoped().Preload("User").Preload("Category").Preload("Tags").
+		Where("user_id = ?", userID).Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+	exportedPosts := make([]exportPost, 0, len(posts))
+	for i := range posts {
+		item := exportPost{PostResponse: posts[i].ToResponse()}
+		if posts[i].DeletedAt.Valid {
+			item.DeletedAt = &posts[i].DeletedAt.Time
+		}
+		exportedPosts = append(exportedPosts, item)
+	}
+
+	var comments []models.Comment
+	if err := db.Unscoped().Preload("User").Preload("Post", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).Where("user_id = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+	exportedComments := make([]exportComment, 0, len(comments))
+	for i := range comments {
+		item := exportComment{
+			CommentResponse: comments[i].ToResponse(),
+			PostTitle:       comments[i].Post.Title,
+			IPAddress:       comments[i].IPAddress,
+			UserAgent:       comments[i].UserAgent,
+		}
+		if comments[i].DeletedAt.Valid {
+			item.DeletedAt = &comments[i].DeletedAt.Time
+		}
+		exportedComments = append(exportedComments, item)
+	}
+
+	var logs []models.LoginLog
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&logs).Error; err != nil {
+		return err
+	}
+	logins := make([]exportLogin, 0, len(logs))
+	for _, log := range logs {
+		logins = append(logins, exportLogin{
+			IPAddress: log.IPAddress,
+			UserAgent: log.UserAgent,
+			LoginAt:   log.LoginAt,
+			Success:   log.Success,
+			Message:   log.Message,
+		})
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user.ToResponse()},
+		{"posts.json", exportedPosts},
+		{"comments.json", exportedComments},
+		{"likes.json", exportLikes{
+			Note:  "本站只统计文章和评论的点赞总数（like_count），不记录点赞人，因此没有你的点赞记录",
+			Items: []struct{}{},
+		}},
+		{"login_history.json", logins},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,56 @@
+package account
+
+import (
+	"strings"
+	"testing"
+
+	"blog/config"
+	"blog/database"
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestDB 打开独立的内存 SQLite 数据库并迁移账号相关的表
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := database.Open(config.DriverSQLite, dsn)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库在最后一个连接关闭时销毁，测试结束前保持连接
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.PasswordResetToken{},
+		&models.EmailToken{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnSession{},
+		&models.UserIdentity{},
+		&models.OAuthState{},
+	); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	return db
+}
+
+// createTestUser 创建一个状态正常的用户
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := &models.User{
+		Username: username,
+		Password: "initial-password-1",
+		Email:    username + "@example.com",
+		Status:   1,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
@@ -0,0 +1,292 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/identity"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOAuthState state 不存在、已使用、已过期，或不是由当前浏览器发起
+	ErrOAuthState = errors.New("登录已过期，请重试")
+	// ErrIdentityLinkUser 绑定回调不是由发起绑定的用户提交
+	ErrIdentityLinkUser = errors.New("请使用发起绑定的账号完成绑定")
+	// ErrIdentityTaken 第三方账号已绑定其他用户
+	ErrIdentityTaken = errors.New("该第三方账号已绑定其他用户")
+	// ErrIdentityEmailUnverified 第三方账号没有已验证的邮箱，无法关联或注册
+	ErrIdentityEmailUnverified = errors.New("第三方账号未提供已验证的邮箱")
+	// ErrIdentityEmailRegistered 邮箱已被未验证邮箱的账号使用，需要登录后手动绑定
+	ErrIdentityEmailRegistered = errors.New("该邮箱已注册，请先登录后在账号设置中绑定")
+	// ErrSignupDisabled 未开放第三方账号注册
+	ErrSignupDisabled = errors.New("未开放第三方账号注册")
+)
+
+// usernameInvalidChars 自动注册时用户名中去掉的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// OAuthResult 第三方登录回调结果
+type OAuthResult struct {
+	User     *models.User
+	Identity *models.UserIdentity
+	Linked   bool // 已登录用户绑定了第三方账号
+	Created  bool // 自动注册了新用户
+}
+
+// BeginOAuth 生成 state、PKCE 校验码和 nonce，返回提供方的授权地址和浏览器绑定值
+//
+// 绑定值需由调用方写入发起登录的浏览器（HttpOnly Cookie），回调时原样提交，
+// 防止他人把自己发起的授权链接发给受害者完成登录或绑定。userID 不为空时表示已登录用户绑定第三方账号。
+func BeginOAuth(ctx context.Context, db *gorm.DB, provider string, userID *uint) (authURL, binding string, err error) {
+	client, err := identity.Lookup(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := newToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := newToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := newToken()
+	if err != nil {
+		return "", "", err
+	}
+	binding, err = newToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err = client.AuthCodeURL(ctx, state, verifier, nonce)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{}).Error; err != nil {
+		logrus.WithError(err).Warn("清理过期的第三方登录状态失败")
+	}
+	if err := db.Create(&models.OAuthState{
+		StateHash:   hashToken(state),
+		Provider:    client.Name(),
+		Verifier:    verifier,
+		Nonce:       nonce,
+		BindingHash: hashToken(binding),
+		UserID:      userID,
+		ExpiresAt:   time.Now().Add(config.OAuth.StateTTL),
+	}).Error; err != nil {
+		return "", "", err
+	}
+	return authURL, binding, nil
+}
+
+// CompleteOAuth 处理提供方回调：校验 state，换取第三方账号信息，然后登录、绑定或注册
+//
+// binding 为 BeginOAuth 返回并保存在浏览器中的绑定值，与 state 不匹配时返回 ErrOAuthState。
+// 绑定流程还要求回调由发起绑定的用户提交（callerID），否则返回 ErrIdentityLinkUser。
+//
+// 没有绑定记录时按已验证的邮箱关联已有用户；已有用户的邮箱未验证时不自动关联，
+// 以免他人预先用该邮箱注册后接管第三方登录。
+func CompleteOAuth(ctx context.Context, db *gorm.DB, code, state, binding string, callerID *uint) (*OAuthResult, error) {
+	record, err := takeOAuthState(db, state, binding)
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != nil && (callerID == nil || *callerID != *record.UserID) {
+		logrus.WithField("user_id", *record.UserID).Warn("第三方账号绑定回调的提交者与发起者不一致")
+		return nil, ErrIdentityLinkUser
+	}
+	client, err := identity.Lookup(record.Provider)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := client.Exchange(ctx, code, record.Verifier, record.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OAuthResult{}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var existing models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", record.Provider, profile.Subject).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found := err == nil
+
+		var user models.User
+		switch {
+		case record.UserID != nil:
+			// 绑定到当前登录用户
+			if found && existing.UserID != *record.UserID {
+				return ErrIdentityTaken
+			}
+			if err := tx.First(&user, *record.UserID).Error; err != nil {
+				return err
+			}
+			result.Linked = true
+
+		case found:
+			if err := tx.First(&user, existing.UserID).Error; err != nil {
+				return err
+			}
+
+		default:
+			if !profile.EmailVerified {
+				return ErrIdentityEmailUnverified
+			}
+			err := tx.Unscoped().Where("email = ?", profile.Email).First(&user).Error
+			switch {
+			case err == nil:
+				if user.DeletedAt.Valid || !user.IsEmailVerified() {
+					return ErrIdentityEmailRegistered
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if !config.OAuth.AllowSignup {
+					return ErrSignupDisabled
+				}
+				if err := createOAuthUser(tx, &user, profile); err != nil {
+					return err
+				}
+				result.Created = true
+			default:
+				return err
+			}
+		}
+
+		now := time.Now()
+		if found {
+			existing.Email, existing.Name, existing.LastLoginAt = truncateRunes(profile.Email, 100), truncateRunes(profile.Name, 100), &now
+			if err := tx.Save(&existing).Error; err != nil {
+				return err
+			}
+		} else {
+			existing = models.UserIdentity{
+				UserID:      user.ID,
+				Provider:    record.Provider,
+				Subject:     profile.Subject,
+				Email:       truncateRunes(profile.Email, 100),
+				Name:        truncateRunes(profile.Name, 100),
+				LastLoginAt: &now,
+			}
+			if err := tx.Create(&existing).Error; err != nil {
+				return err
+			}
+		}
+
+		result.User = &user
+		result.Identity = &existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":  result.User.ID,
+		"provider": record.Provider,
+		"linked":   result.Linked,
+		"created":  result.Created,
+	}).Info("第三方账号验证成功")
+	return result, nil
+}
+
+// takeOAuthState 按 state 和浏览器绑定值取出并删除登录状态，每个 state 只能使用一次
+func takeOAuthState(db *gorm.DB, state, binding string) (*models.OAuthState, error) {
+	if binding == "" {
+		return nil, ErrOAuthState
+	}
+	var record models.OAuthState
+	if err := db.Where("state_hash = ? AND binding_hash = ? AND expires_at > ?", hashToken(state), hashToken(binding), time.Now()).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthState
+		}
+		return nil, err
+	}
+
+	result := db.Delete(&models.OAuthState{}, record.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, ErrOAuthState
+	}
+	return &record, nil
+}
+
+// createOAuthUser 用第三方账号信息注册新用户，邮箱视为已验证
+//
+// 密码随机生成，用户可以通过找回密码设置密码。
+func createOAuthUser(tx *gorm.DB, user *models.User, profile *identity.Profile) error {
+	password, err := newToken()
+	if err != nil {
+		return err
+	}
+	username, err := uniqueUsername(tx, profile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	*user = models.User{
+		Username:        username,
+		Password:        password,
+		Email:           profile.Email,
+		Nickname:        truncateRunes(profile.Name, 50),
+		Avatar:          truncateRunes(profile.Avatar, 255),
+		Role:            models.RoleUser,
+		EmailVerifiedAt: &now,
+	}
+	return tx.Create(user).Error
+}
+
+// uniqueUsername 依次尝试第三方用户名和邮箱前缀，重名时追加随机后缀
+func uniqueUsername(tx *gorm.DB, profile *identity.Profile) (string, error) {
+	base := usernameInvalidChars.ReplaceAllString(profile.Username, "")
+	if len(base) < 3 {
+		local, _, _ := strings.Cut(profile.Email, "@")
+		base = usernameInvalidChars.ReplaceAllString(local, "")
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	base = truncateRunes(base, 40)
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = base + "-" + hex.EncodeToString(suffix)
+	}
+	return "", errors.New("无法生成可用的用户名")
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,360 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// mockOIDC 本地 OIDC 提供方，提供发现文档、JWKS 和令牌端点
+//
+// 测试通过 authorize 模拟用户在提供方完成授权，得到回调中的 code。
+type mockOIDC struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+// mockGrant 授权码对应的 PKCE 挑战、nonce 和要签发的账号信息
+type mockGrant struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+const mockClientID = "blog-test"
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDC{key: key, codes: make(map[string]mockGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// token 令牌端点：校验客户端和 PKCE 校验码，签发 ID Token
+func (m *mockOIDC) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != mockClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   mockClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": grant.nonce,
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize 模拟用户在提供方授权：记录授权地址中的 PKCE 挑战和 nonce，返回回调参数
+func (m *mockOIDC) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if !strings.HasPrefix(authURL, m.server.URL+"/authorize?") || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("授权地址不正确: %s", authURL)
+	}
+
+	code = "code-" + newTestToken(t)
+	m.mu.Lock()
+	m.codes[code] = mockGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestToken(t *testing.T) string {
+	t.Helper()
+	token, err := newToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// useMockProvider 把本地提供方注册为唯一的第三方登录方式，返回提供方名称
+func useMockProvider(t *testing.T, m *mockOIDC) string {
+	t.Helper()
+	// identity 包按名称缓存客户端，每个测试使用不同的名称
+	name := "mock-" + strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+	previous := config.OAuth.Providers
+	config.OAuth.Providers = []config.OAuthProvider{{
+		Name:         name,
+		DisplayName:  "Mock",
+		ClientID:     mockClientID,
+		ClientSecret: "secret",
+		Issuer:       m.server.URL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}}
+	t.Cleanup(func() { config.OAuth.Providers = previous })
+	return name
+}
+
+// oauthFlow 一次完整的第三方登录：发起、在提供方授权、提交回调
+type oauthFlow struct {
+	code, state, binding string
+}
+
+func beginFlow(t *testing.T, db *gorm.DB, m *mockOIDC, provider string, userID *uint, claims jwt.MapClaims) oauthFlow {
+	t.Helper()
+	authURL, binding, err := BeginOAuth(context.Background(), db, provider, userID)
+	if err != nil {
+		t.Fatalf("发起第三方登录失败: %v", err)
+	}
+	code, state := m.authorize(t, authURL, claims)
+	return oauthFlow{code: code, state: state, binding: binding}
+}
+
+func (f oauthFlow) complete(db *gorm.DB, callerID *uint) (*OAuthResult, error) {
+	return CompleteOAuth(context.Background(), db, f.code, f.state, f.binding, callerID)
+}
+
+func verifiedClaims(subject, email string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":                subject,
+		"email":              email,
+		"email_verified":     true,
+		"name":               "Carol",
+		"preferred_username": "carol",
+	}
+}
+
+func TestOAuthSignup(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+
+	result, err := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-1", "carol@example.com")).complete(db, nil)
+	if err != nil {
+		t.Fatalf("第三方登录失败: %v", err)
+	}
+	if !result.Created || result.Linked {
+		t.Errorf("应自动注册新用户: created=%v linked=%v", result.Created, result.Linked)
+	}
+	if result.User.Email != "carol@example.com" || result.User.Username != "carol" || !result.User.IsEmailVerified() {
+		t.Errorf("新用户信息不正确: %+v", result.User)
+	}
+	if result.Identity.Provider != provider || result.Identity.Subject != "sub-1" || result.Identity.UserID != result.User.ID {
+		t.Errorf("绑定记录不正确: %+v", result.Identity)
+	}
+
+	// 再次登录使用已绑定的用户
+	again, err := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-1", "carol@example.com")).complete(db, nil)
+	if err != nil {
+		t.Fatalf("再次登录失败: %v", err)
+	}
+	if again.Created || again.User.ID != result.User.ID {
+		t.Errorf("再次登录应返回同一用户: created=%v user=%d", again.Created, again.User.ID)
+	}
+}
+
+func TestOAuthLinksVerifiedEmail(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+
+	user := createTestUser(t, db, "dave")
+	if err := db.Model(user).Update("email_verified_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-dave", user.Email)).complete(db, nil)
+	if err != nil {
+		t.Fatalf("第三方登录失败: %v", err)
+	}
+	if result.Created || result.User.ID != user.ID {
+		t.Errorf("应关联到邮箱相同的已有用户: created=%v user=%d", result.Created, result.User.ID)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("不应注册新用户，当前用户数 %d", count)
+	}
+}
+
+func TestOAuthRejectsUnverifiedEmail(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+	createTestUser(t, db, "erin")
+
+	claims := verifiedClaims("sub-erin", "erin@example.com")
+	claims["email_verified"] = false
+	_, err := beginFlow(t, db, m, provider, nil, claims).complete(db, nil)
+	if !errors.Is(err, ErrIdentityEmailUnverified) {
+		t.Fatalf("未验证的第三方邮箱应返回 ErrIdentityEmailUnverified，实际 %v", err)
+	}
+
+	var count int64
+	db.Model(&models.UserIdentity{}).Count(&count)
+	if count != 0 {
+		t.Errorf("不应创建绑定记录，当前 %d 条", count)
+	}
+}
+
+func TestOAuthStateSingleUse(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+
+	flow := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-2", "frank@example.com"))
+	if _, err := flow.complete(db, nil); err != nil {
+		t.Fatalf("第三方登录失败: %v", err)
+	}
+	if _, err := flow.complete(db, nil); !errors.Is(err, ErrOAuthState) {
+		t.Fatalf("重复使用 state 应返回 ErrOAuthState，实际 %v", err)
+	}
+}
+
+func TestOAuthWrongVerifier(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+
+	// 把为另一次登录签发的授权码注入本次回调，本次的 PKCE 校验码与授权码不匹配
+	victim := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-3", "grace@example.com"))
+	other := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-4", "mallory@example.com"))
+	victim.code = other.code
+
+	if _, err := victim.complete(db, nil); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("PKCE 校验码不匹配时提供方应拒绝换取令牌，实际 %v", err)
+	}
+	var count int64
+	db.Model(&models.UserIdentity{}).Count(&count)
+	if count != 0 {
+		t.Errorf("不应创建绑定记录，当前 %d 条", count)
+	}
+}
+
+func TestOAuthStateBoundToBrowser(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+
+	// 攻击者把自己发起的授权链接发给受害者，受害者浏览器中没有对应的绑定值
+	flow := beginFlow(t, db, m, provider, nil, verifiedClaims("sub-5", "heidi@example.com"))
+	for _, binding := range []string{"", newTestToken(t)} {
+		forged := flow
+		forged.binding = binding
+		if _, err := forged.complete(db, nil); !errors.Is(err, ErrOAuthState) {
+			t.Errorf("绑定值 %q 不匹配时应返回 ErrOAuthState，实际 %v", binding, err)
+		}
+	}
+}
+
+func TestOAuthLinkRequiresInitiatingUser(t *testing.T) {
+	db := newTestDB(t)
+	m := newMockOIDC(t)
+	provider := useMockProvider(t, m)
+	owner := createTestUser(t, db, "ivan")
+	other := createTestUser(t, db, "judy")
+
+	claims := verifiedClaims("sub-6", "ivan@idp.example.com")
+	for _, caller := range []*uint{nil, &other.ID} {
+		_, err := beginFlow(t, db, m, provider, &owner.ID, claims).complete(db, caller)
+		if !errors.Is(err, ErrIdentityLinkUser) {
+			t.Errorf("非发起用户提交绑定回调应返回 ErrIdentityLinkUser，实际 %v", err)
+		}
+	}
+
+	result, err := beginFlow(t, db, m, provider, &owner.ID, claims).complete(db, &owner.ID)
+	if err != nil {
+		t.Fatalf("绑定失败: %v", err)
+	}
+	if !result.Linked || result.Identity.UserID != owner.ID {
+		t.Errorf("应绑定到发起用户: linked=%v user=%d", result.Linked, result.Identity.UserID)
+	}
+}
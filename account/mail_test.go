@@ -0,0 +1,208 @@
+package account
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/mailer"
+	"blog/models"
+)
+
+// useOutbox 把默认发送器替换为写入临时目录的发件箱
+func useOutbox(t *testing.T) *mailer.OutboxMailer {
+	t.Helper()
+	outbox := &mailer.OutboxMailer{Dir: t.TempDir(), From: "Blog <noreply@example.com>"}
+	previous := mailer.Default()
+	mailer.SetDefault(outbox)
+	t.Cleanup(func() { mailer.SetDefault(previous) })
+	return outbox
+}
+
+// sentMail 发件箱中解析后的一封邮件
+type sentMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// readOutbox 等待后台发送完成并按顺序读取发件箱中的邮件
+func readOutbox(t *testing.T, outbox *mailer.OutboxMailer) []sentMail {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForMail(ctx); err != nil {
+		t.Fatalf("等待邮件发送超时: %v", err)
+	}
+
+	files, err := outbox.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mails []sentMail
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mail.ReadMessage(f)
+		if err != nil {
+			f.Close()
+			t.Fatalf("解析邮件 %s 失败: %v", file, err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mails = append(mails, sentMail{
+			To:      msg.Header.Get("To"),
+			Subject: subject,
+			Body:    strings.ReplaceAll(string(body), "\r\n", "\n"),
+		})
+	}
+	return mails
+}
+
+// tokenFromLink 在邮件正文中找到以 base 开头的链接并取出 token 参数
+func tokenFromLink(t *testing.T, body, base string) string {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, base+"?") {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil {
+			t.Fatalf("链接无效 %q: %v", line, err)
+		}
+		if token := u.Query().Get("token"); token != "" {
+			return token
+		}
+	}
+	t.Fatalf("邮件正文中没有 %s 的链接:\n%s", base, body)
+	return ""
+}
+
+func TestVerificationMailThroughOutbox(t *testing.T) {
+	db := newTestDB(t)
+	outbox := useOutbox(t)
+	user := createTestUser(t, db, "alice")
+
+	if err := SendVerification(db, user, "zh-CN"); err != nil {
+		t.Fatalf("发送验证邮件失败: %v", err)
+	}
+
+	mails := readOutbox(t, outbox)
+	if len(mails) != 1 {
+		t.Fatalf("发件箱应有 1 封邮件，实际 %d 封", len(mails))
+	}
+	if mails[0].To != user.Email {
+		t.Errorf("收件人 = %q，期望 %q", mails[0].To, user.Email)
+	}
+	if !strings.Contains(mails[0].Subject, config.Site.Name) {
+		t.Errorf("主题 %q 中缺少站点名称", mails[0].Subject)
+	}
+
+	token := tokenFromLink(t, mails[0].Body, config.EmailVerification.URL)
+	result, confirmed, err := ConfirmEmailToken(db, token)
+	if err != nil {
+		t.Fatalf("确认邮件中的令牌失败: %v", err)
+	}
+	if result != EmailVerified || !confirmed.IsEmailVerified() {
+		t.Errorf("确认结果 = %q，邮箱应已验证", result)
+	}
+
+	// 令牌只能使用一次
+	if _, _, err := ConfirmEmailToken(db, token); err != ErrInvalidEmailToken {
+		t.Errorf("重复使用令牌应返回 ErrInvalidEmailToken，实际 %v", err)
+	}
+}
+
+func TestPasswordResetMailThroughOutbox(t *testing.T) {
+	db := newTestDB(t)
+	outbox := useOutbox(t)
+	user := createTestUser(t, db, "bob")
+
+	if err := RequestPasswordReset(db, user.Email, "en"); err != nil {
+		t.Fatalf("申请找回密码失败: %v", err)
+	}
+	// 未注册的邮箱静默成功，不发送邮件
+	if err := RequestPasswordReset(db, "nobody@example.com", "en"); err != nil {
+		t.Fatalf("未注册邮箱应静默返回: %v", err)
+	}
+
+	mails := readOutbox(t, outbox)
+	if len(mails) != 1 {
+		t.Fatalf("发件箱应有 1 封邮件，实际 %d 封", len(mails))
+	}
+	if mails[0].To != user.Email {
+		t.Errorf("收件人 = %q，期望 %q", mails[0].To, user.Email)
+	}
+
+	token := tokenFromLink(t, mails[0].Body, config.PasswordReset.URL)
+	const newPassword = "a-much-better-passphrase-42"
+	updated, err := ResetPassword(db, token, newPassword, "en")
+	if err != nil {
+		t.Fatalf("使用邮件中的令牌重置密码失败: %v", err)
+	}
+	if !updated.CheckPassword(newPassword) {
+		t.Error("重置后新密码校验失败")
+	}
+	if _, err := ResetPassword(db, token, newPassword, "en"); err != ErrInvalidResetToken {
+		t.Errorf("重复使用令牌应返回 ErrInvalidResetToken，实际 %v", err)
+	}
+
+	// 重置成功后还会发送密码已修改的通知
+	if mails := readOutbox(t, outbox); len(mails) != 2 || mails[1].To != user.Email {
+		t.Errorf("应再发送一封密码修改通知，发件箱共 %d 封", len(mails))
+	}
+}
+
+func TestChangePasswordRevokesTokensAndNotifies(t *testing.T) {
+	db := newTestDB(t)
+	outbox := useOutbox(t)
+	user := createTestUser(t, db, "carol")
+	version := user.TokenVersion
+
+	const newPassword = "another-strong-passphrase-7"
+	if err := ChangePassword(db, user, "wrong-password-1", newPassword, "en"); err != ErrWrongPassword {
+		t.Fatalf("原密码错误应返回 ErrWrongPassword，实际 %v", err)
+	}
+	if err := ChangePassword(db, user, "initial-password-1", newPassword, "en"); err != nil {
+		t.Fatalf("修改密码失败: %v", err)
+	}
+
+	var updated models.User
+	if err := db.First(&updated, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !updated.CheckPassword(newPassword) {
+		t.Error("修改后新密码校验失败")
+	}
+	if updated.TokenVersion != version+1 {
+		t.Errorf("token_version = %d，期望 %d", updated.TokenVersion, version+1)
+	}
+
+	// 用修改前读到的用户再次修改会因原密码哈希不一致而失败
+	if err := ChangePassword(db, user, "initial-password-1", "yet-another-passphrase-8", "en"); err != ErrWrongPassword {
+		t.Errorf("并发修改应返回 ErrWrongPassword，实际 %v", err)
+	}
+
+	mails := readOutbox(t, outbox)
+	if len(mails) != 1 || mails[0].To != user.Email {
+		t.Fatalf("应发送一封密码修改通知，发件箱共 %d 封", len(mails))
+	}
+}
@@ -0,0 +1,229 @@
+package account
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+	"blog/totp"
+
+	"gorm.io/gorm"
+)
+
+// MFAFailureMessage 两步验证失败时写入登录日志的信息，用于统计错误次数
+const MFAFailureMessage = "两步验证码错误"
+
+var (
+	// ErrMFAEnabled 已启用两步验证
+	ErrMFAEnabled = errors.New("已启用两步验证")
+	// ErrMFANotEnabled 未启用两步验证
+	ErrMFANotEnabled = errors.New("未启用两步验证")
+	// ErrMFANotSetup 尚未生成认证器密钥
+	ErrMFANotSetup = errors.New("请先生成认证器密钥")
+	// ErrInvalidMFACode 验证码或恢复码错误、已使用
+	ErrInvalidMFACode = errors.New("验证码错误")
+)
+
+// recoveryAlphabet 恢复码字符集，32 个字符使每个随机字节取模后分布均匀
+const recoveryAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// BeginTOTPSetup 生成新的认证器密钥，验证首个验证码后才会启用
+//
+// 重复调用会替换尚未启用的密钥。
+func BeginTOTPSetup(db *gorm.DB, user *models.User) (secret, uri string, err error) {
+	if user.MFAEnabled() {
+		return "", "", ErrMFAEnabled
+	}
+	if secret, err = totp.GenerateSecret(); err != nil {
+		return "", "", err
+	}
+	if err = db.Model(user).Update("totp_secret", secret).Error; err != nil {
+		return "", "", err
+	}
+	return secret, totp.URI(config.MFA.Issuer, user.Username, secret), nil
+}
+
+// EnableTOTP 校验认证器生成的验证码并启用两步验证，返回新生成的恢复码
+func EnableTOTP(db *gorm.DB, user *models.User, code string) ([]string, error) {
+	if user.MFAEnabled() {
+		return nil, ErrMFAEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotSetup
+	}
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), config.MFA.Skew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// 条件更新避免并发请求重复启用
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_enabled_at IS NULL AND totp_secret = ?", user.ID, user.TOTPSecret).
+			Updates(map[string]interface{}{"totp_enabled_at": now, "totp_last_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrMFAEnabled
+		}
+		user.TOTPEnabledAt = &now
+		user.TOTPLastStep = step
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA 关闭两步验证并删除全部恢复码
+func DisableMFA(db *gorm.DB, user *models.User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// VerifyMFA 校验认证器验证码或恢复码，恢复码校验通过后即作废
+//
+// 返回值表示是否使用了恢复码。同一时间步的验证码只能使用一次。
+func VerifyMFA(db *gorm.DB, user *models.User, code string) (bool, error) {
+	if !user.MFAEnabled() {
+		return false, ErrMFANotEnabled
+	}
+
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), config.MFA.Skew); ok {
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected != 1 {
+			return false, ErrInvalidMFACode
+		}
+		user.TOTPLastStep = step
+		return false, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != 10 {
+		return false, ErrInvalidMFACode
+	}
+	var record models.RecoveryCode
+	if err := db.Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalized)).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrInvalidMFACode
+		}
+		return false, err
+	}
+	result := db.Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, ErrInvalidMFACode
+	}
+	return true, nil
+}
+
+// RegenerateRecoveryCodes 作废旧恢复码并生成一组新的
+func RegenerateRecoveryCodes(db *gorm.DB, user *models.User) ([]string, error) {
+	if !user.MFAEnabled() {
+		return nil, ErrMFANotEnabled
+	}
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// RemainingRecoveryCodes 统计未使用的恢复码数量
+func RemainingRecoveryCodes(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MFARequired 账号是否被要求启用两步验证
+func MFARequired(user *models.User) bool {
+	return user.IsAdmin() && config.MFA.RequireAdmin
+}
+
+// MFAAttemptsExceeded 最近一段时间内两步验证错误次数是否已达上限
+//
+// 错误次数按账号统计，重新输入密码获取新的临时令牌不会重置计数。
+func MFAAttemptsExceeded(db *gorm.DB, userID uint) (bool, error) {
+	if config.MFA.MaxAttempts <= 0 {
+		return false, nil
+	}
+	var failures int64
+	err := db.Model(&models.LoginLog{}).
+		Where("user_id = ? AND success = ? AND message = ? AND login_at > ?",
+			userID, false, MFAFailureMessage, time.Now().Add(-config.MFA.ChallengeTTL)).
+		Count(&failures).Error
+	return failures >= int64(config.MFA.MaxAttempts), err
+}
+
+// replaceRecoveryCodes 删除用户全部恢复码并生成新的一组，明文只返回这一次
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, config.MFA.RecoveryCodes)
+	records := make([]models.RecoveryCode, 0, config.MFA.RecoveryCodes)
+	for i := 0; i < config.MFA.RecoveryCodes; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	if len(records) > 0 {
+		if err := tx.Create(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// newRecoveryCode 生成形如 abcde-23456 的恢复码（50 位随机数）
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = recoveryAlphabet[int(b[i])%len(recoveryAlphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// normalizeRecoveryCode 忽略大小写、连字符和空格
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
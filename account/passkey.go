@@ -0,0 +1,401 @@
+package account
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPasskeySession 挑战不存在、已使用或已过期
+	ErrPasskeySession = errors.New("验证已过期，请重试")
+	// ErrPasskeyInvalid 浏览器返回的数据无法通过验证
+	ErrPasskeyInvalid = errors.New("通行密钥验证失败")
+	// ErrPasskeyExists 通行密钥已被注册
+	ErrPasskeyExists = errors.New("该通行密钥已注册")
+	// ErrPasskeyCloned 签名计数没有递增，认证器可能已被复制
+	ErrPasskeyCloned = errors.New("通行密钥签名计数异常")
+)
+
+// passkeyUser 实现 webauthn.User 接口
+type passkeyUser struct {
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return userHandle(u.user.ID) }
+func (u *passkeyUser) WebAuthnName() string                       { return u.user.Username }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return displayName(u.user) }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// userHandle 用户句柄为 8 字节大端序的用户 ID，不包含用户名等个人信息
+func userHandle(userID uint) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(userID))
+	return b
+}
+
+// PasskeyAssertion 通行密钥登录结果
+type PasskeyAssertion struct {
+	User         *models.User
+	Credential   *models.WebAuthnCredential
+	UserVerified bool // 认证器是否验证了用户（PIN、指纹等）
+}
+
+// BeginPasskeyRegistration 开始注册通行密钥，返回传给 navigator.credentials.create 的参数
+func BeginPasskeyRegistration(db *gorm.DB, user *models.User) (*protocol.CredentialCreation, error) {
+	wa, err := newWebAuthn()
+	if err != nil {
+		return nil, err
+	}
+	pu, err := loadPasskeyUser(db, user)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := wa.BeginRegistration(pu,
+		webauthn.WithExclusions(webauthn.Credentials(pu.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveSession(db, &user.ID, models.WebAuthnRegister, session); err != nil {
+		return nil, err
+	}
+	return creation, nil
+}
+
+// FinishPasskeyRegistration 校验 navigator.credentials.create 的结果并保存通行密钥
+func FinishPasskeyRegistration(db *gorm.DB, user *models.User, name string, body []byte) (*models.WebAuthnCredential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(body)
+	if err != nil {
+		logrus.WithError(err).Warn("解析通行密钥注册数据失败")
+		return nil, ErrPasskeyInvalid
+	}
+	session, err := takeSession(db, models.WebAuthnRegister, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID == nil || *session.UserID != user.ID {
+		return nil, ErrPasskeySession
+	}
+
+	wa, err := newWebAuthn()
+	if err != nil {
+		return nil, err
+	}
+	pu, err := loadPasskeyUser(db, user)
+	if err != nil {
+		return nil, err
+	}
+	data, err := sessionData(session)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := wa.CreateCredential(pu, *data, parsed)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Warn("通行密钥注册验证失败")
+		return nil, ErrPasskeyInvalid
+	}
+
+	record := newCredentialRecord(user.ID, name, credential)
+	var count int64
+	if err := db.Model(&models.WebAuthnCredential{}).Where("credential_id = ?", record.CredentialID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrPasskeyExists
+	}
+	if err := db.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// BeginPasskeyLogin 开始通行密钥登录，返回传给 navigator.credentials.get 的参数
+//
+// username 为空、账号不存在或没有通行密钥时发起不指定用户的登录，由认证器选择通行密钥。
+func BeginPasskeyLogin(db *gorm.DB, username string) (*protocol.CredentialAssertion, error) {
+	wa, err := newWebAuthn()
+	if err != nil {
+		return nil, err
+	}
+
+	var pu *passkeyUser
+	if username != "" {
+		var user models.User
+		err := db.Where("username = ? OR email = ?", username, username).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			if pu, err = loadPasskeyUser(db, &user); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		userID    *uint
+	)
+	if pu != nil && len(pu.credentials) > 0 {
+		assertion, session, err = wa.BeginLogin(pu)
+		userID = &pu.user.ID
+	} else {
+		assertion, session, err = wa.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationPreferred))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := saveSession(db, userID, models.WebAuthnLogin, session); err != nil {
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// FinishPasskeyLogin 校验 navigator.credentials.get 的结果，返回对应的用户
+//
+// 签名计数没有递增（且不为 0）时拒绝登录，防止使用被复制的认证器。
+func FinishPasskeyLogin(db *gorm.DB, body []byte) (*PasskeyAssertion, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(body)
+	if err != nil {
+		logrus.WithError(err).Warn("解析通行密钥登录数据失败")
+		return nil, ErrPasskeyInvalid
+	}
+	session, err := takeSession(db, models.WebAuthnLogin, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	wa, err := newWebAuthn()
+	if err != nil {
+		return nil, err
+	}
+	data, err := sessionData(session)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pu         *passkeyUser
+		credential *webauthn.Credential
+	)
+	if session.UserID != nil {
+		var user models.User
+		if err := db.First(&user, *session.UserID).Error; err != nil {
+			return nil, ErrPasskeyInvalid
+		}
+		if pu, err = loadPasskeyUser(db, &user); err != nil {
+			return nil, err
+		}
+		credential, err = wa.ValidateLogin(pu, *data, parsed)
+	} else {
+		credential, err = wa.ValidateDiscoverableLogin(func(rawID, handle []byte) (webauthn.User, error) {
+			if len(handle) != 8 {
+				return nil, ErrPasskeyInvalid
+			}
+			var user models.User
+			if err := db.First(&user, binary.BigEndian.Uint64(handle)).Error; err != nil {
+				return nil, err
+			}
+			var err error
+			pu, err = loadPasskeyUser(db, &user)
+			return pu, err
+		}, *data, parsed)
+	}
+	if err != nil {
+		logrus.WithError(err).Warn("通行密钥登录验证失败")
+		return nil, ErrPasskeyInvalid
+	}
+
+	if credential.Authenticator.CloneWarning {
+		logrus.WithField("user_id", pu.user.ID).Warn("通行密钥签名计数没有递增，拒绝登录")
+		return nil, ErrPasskeyCloned
+	}
+
+	var record models.WebAuthnCredential
+	if err := db.Where("user_id = ? AND credential_id = ?", pu.user.ID, encodeBinary(credential.ID)).
+		First(&record).Error; err != nil {
+		return nil, err
+	}
+	// 条件更新保证并发使用同一个计数值时只有一个请求成功
+	now := time.Now()
+	flags := parsed.Response.AuthenticatorData.Flags
+	result := db.Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND sign_count = ?", record.ID, record.SignCount).
+		Updates(map[string]interface{}{
+			"sign_count":   credential.Authenticator.SignCount,
+			"flags":        uint8(flags),
+			"last_used_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, ErrPasskeyCloned
+	}
+	record.SignCount = credential.Authenticator.SignCount
+	record.LastUsedAt = &now
+
+	return &PasskeyAssertion{User: pu.user, Credential: &record, UserVerified: flags.HasUserVerified()}, nil
+}
+
+// CleanupPasskeySessions 删除过期的挑战
+func CleanupPasskeySessions(db *gorm.DB) error {
+	return db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{}).Error
+}
+
+func newWebAuthn() (*webauthn.WebAuthn, error) {
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    config.WebAuthn.ChallengeTTL,
+		TimeoutUVD: config.WebAuthn.ChallengeTTL,
+	}
+	return webauthn.New(&webauthn.Config{
+		RPID:          config.WebAuthn.RPID,
+		RPDisplayName: config.WebAuthn.RPName,
+		RPOrigins:     config.WebAuthn.Origins,
+		Timeouts:      webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+}
+
+// loadPasskeyUser 加载用户已注册的通行密钥
+func loadPasskeyUser(db *gorm.DB, user *models.User) (*passkeyUser, error) {
+	var records []models.WebAuthnCredential
+	if err := db.Where("user_id = ?", user.ID).Order("id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	pu := &passkeyUser{user: user}
+	for _, record := range records {
+		credential, err := toCredential(record)
+		if err != nil {
+			logrus.WithError(err).WithField("credential", record.ID).Error("通行密钥数据损坏")
+			continue
+		}
+		pu.credentials = append(pu.credentials, credential)
+	}
+	return pu, nil
+}
+
+// saveSession 保存挑战，顺带清理过期的挑战
+func saveSession(db *gorm.DB, userID *uint, purpose string, data *webauthn.SessionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := CleanupPasskeySessions(db); err != nil {
+		logrus.WithError(err).Warn("清理过期的通行密钥挑战失败")
+	}
+	return db.Create(&models.WebAuthnSession{
+		UserID:    userID,
+		Purpose:   purpose,
+		Challenge: data.Challenge,
+		Data:      string(raw),
+		ExpiresAt: time.Now().Add(config.WebAuthn.ChallengeTTL),
+	}).Error
+}
+
+// takeSession 按挑战取出并删除会话，每个挑战只能使用一次
+func takeSession(db *gorm.DB, purpose, challenge string) (*models.WebAuthnSession, error) {
+	var session models.WebAuthnSession
+	if err := db.Where("challenge = ? AND purpose = ? AND expires_at > ?", challenge, purpose, time.Now()).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasskeySession
+		}
+		return nil, err
+	}
+
+	result := db.Delete(&models.WebAuthnSession{}, session.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, ErrPasskeySession
+	}
+	return &session, nil
+}
+
+func sessionData(session *models.WebAuthnSession) (*webauthn.SessionData, error) {
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(session.Data), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// newCredentialRecord 把注册结果转换为数据库记录
+func newCredentialRecord(userID uint, name string, credential *webauthn.Credential) *models.WebAuthnCredential {
+	if name == "" {
+		name = "通行密钥"
+	}
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	record := &models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    encodeBinary(credential.ID),
+		PublicKey:       encodeBinary(credential.PublicKey),
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		SignCount:       credential.Authenticator.SignCount,
+	}
+	if aaguid, err := uuid.FromBytes(credential.Authenticator.AAGUID); err == nil {
+		record.AAGUID = aaguid.String()
+	}
+	return record
+}
+
+// toCredential 把数据库记录还原为 webauthn.Credential
+func toCredential(record models.WebAuthnCredential) (webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(record.CredentialID)
+	if err != nil {
+		return webauthn.Credential{}, err
+	}
+	publicKey, err := base64.RawURLEncoding.DecodeString(record.PublicKey)
+	if err != nil {
+		return webauthn.Credential{}, err
+	}
+
+	credential := webauthn.Credential{
+		ID:              id,
+		PublicKey:       publicKey,
+		AttestationType: record.AttestationType,
+		Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(record.Flags)),
+		Authenticator:   webauthn.Authenticator{SignCount: record.SignCount},
+	}
+	if record.Transports != "" {
+		for _, transport := range strings.Split(record.Transports, ",") {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+	}
+	if aaguid, err := uuid.Parse(record.AAGUID); err == nil {
+		credential.Authenticator.AAGUID = aaguid[:]
+	}
+	return credential, nil
+}
+
+// encodeBinary 二进制字段以 base64url 入库，便于跨数据库备份
+func encodeBinary(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
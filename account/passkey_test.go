@@ -0,0 +1,291 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"gorm.io/gorm"
+)
+
+// softAuthenticator 纯软件实现的认证器，生成 none 格式的注册数据和 ES256 签名的登录数据
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &softAuthenticator{key: key, credentialID: id}
+}
+
+// 认证器数据标志位：用户在场、用户已验证、包含凭据数据
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// authenticatorData 依赖方 ID 摘要、标志位、签名计数，以及注册时的凭据数据
+func (a *softAuthenticator) authenticatorData(t *testing.T, attested bool) []byte {
+	t.Helper()
+	rpIDHash := sha256.Sum256([]byte(config.WebAuthn.RPID))
+	data := append([]byte{}, rpIDHash[:]...)
+
+	flags := byte(flagUserPresent | flagUserVerified)
+	if attested {
+		flags |= flagAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, make([]byte, 16)...) // AAGUID 全 0
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	return append(data, publicKey...)
+}
+
+func clientData(t *testing.T, ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]string{
+		"type":      string(ceremony),
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    config.WebAuthn.Origins[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// create 模拟 navigator.credentials.create，返回浏览器提交给服务器的 JSON
+func (a *softAuthenticator) create(t *testing.T, creation *protocol.CredentialCreation) []byte {
+	t.Helper()
+	a.userHandle = creation.Response.User.ID.(protocol.URLEncodedBase64)
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(t, true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.response(t, map[string]string{
+		"clientDataJSON":    encode(clientData(t, protocol.CreateCeremony, creation.Response.Challenge)),
+		"attestationObject": encode(attestation),
+	})
+}
+
+// get 模拟 navigator.credentials.get，每次调用签名计数加 1
+func (a *softAuthenticator) get(t *testing.T, assertion *protocol.CredentialAssertion) []byte {
+	t.Helper()
+	a.signCount++
+	return a.sign(t, assertion)
+}
+
+// sign 按当前签名计数生成登录数据
+func (a *softAuthenticator) sign(t *testing.T, assertion *protocol.CredentialAssertion) []byte {
+	t.Helper()
+	authData := a.authenticatorData(t, false)
+	client := clientData(t, protocol.AssertCeremony, assertion.Response.Challenge)
+	clientHash := sha256.Sum256(client)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a.response(t, map[string]string{
+		"clientDataJSON":    encode(client),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) response(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// registerPasskey 为用户注册软件认证器
+func registerPasskey(t *testing.T, db *gorm.DB, user *models.User, authenticator *softAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	creation, err := BeginPasskeyRegistration(db, user)
+	if err != nil {
+		t.Fatalf("开始注册失败: %v", err)
+	}
+	record, err := FinishPasskeyRegistration(db, user, "测试密钥", authenticator.create(t, creation))
+	if err != nil {
+		t.Fatalf("完成注册失败: %v", err)
+	}
+	return record
+}
+
+// loginPasskey 用软件认证器登录，username 为空时发起不指定用户的登录
+func loginPasskey(t *testing.T, db *gorm.DB, username string, authenticator *softAuthenticator) (*PasskeyAssertion, error) {
+	t.Helper()
+	assertion, err := BeginPasskeyLogin(db, username)
+	if err != nil {
+		t.Fatalf("开始登录失败: %v", err)
+	}
+	return FinishPasskeyLogin(db, authenticator.get(t, assertion))
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+
+	record := registerPasskey(t, db, user, authenticator)
+	if record.UserID != user.ID || record.CredentialID != encode(authenticator.credentialID) {
+		t.Fatalf("保存的通行密钥不正确: %+v", record)
+	}
+
+	for _, username := range []string{user.Username, ""} {
+		result, err := loginPasskey(t, db, username, authenticator)
+		if err != nil {
+			t.Fatalf("登录失败 (username=%q): %v", username, err)
+		}
+		if result.User.ID != user.ID || !result.UserVerified {
+			t.Errorf("登录结果不正确 (username=%q): user=%d verified=%v", username, result.User.ID, result.UserVerified)
+		}
+		if result.Credential.SignCount != authenticator.signCount {
+			t.Errorf("签名计数 = %d，期望 %d", result.Credential.SignCount, authenticator.signCount)
+		}
+	}
+}
+
+func TestPasskeyDuplicateRegistration(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, db, user, authenticator)
+
+	creation, err := BeginPasskeyRegistration(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = FinishPasskeyRegistration(db, user, "重复", authenticator.create(t, creation))
+	if !errors.Is(err, ErrPasskeyExists) {
+		t.Fatalf("重复注册应返回 ErrPasskeyExists，实际 %v", err)
+	}
+}
+
+func TestPasskeySessionMissingOrExpired(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+
+	// 服务器从未签发过的挑战
+	forged := &protocol.CredentialCreation{}
+	forged.Response.Challenge = protocol.URLEncodedBase64("never-issued-challenge-0123456789")
+	forged.Response.User.ID = protocol.URLEncodedBase64(userHandle(user.ID))
+	if _, err := FinishPasskeyRegistration(db, user, "", authenticator.create(t, forged)); !errors.Is(err, ErrPasskeySession) {
+		t.Errorf("未签发的挑战应返回 ErrPasskeySession，实际 %v", err)
+	}
+
+	// 挑战只能使用一次
+	creation, err := BeginPasskeyRegistration(db, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := authenticator.create(t, creation)
+	if _, err := FinishPasskeyRegistration(db, user, "", body); err != nil {
+		t.Fatalf("完成注册失败: %v", err)
+	}
+	if _, err := FinishPasskeyRegistration(db, user, "", body); !errors.Is(err, ErrPasskeySession) {
+		t.Errorf("重放注册数据应返回 ErrPasskeySession，实际 %v", err)
+	}
+
+	// 过期的登录挑战
+	assertion, err := BeginPasskeyLogin(db, user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.WebAuthnSession{}).Where("1 = 1").
+		Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FinishPasskeyLogin(db, authenticator.get(t, assertion)); !errors.Is(err, ErrPasskeySession) {
+		t.Errorf("过期的挑战应返回 ErrPasskeySession，实际 %v", err)
+	}
+}
+
+func TestPasskeySignCountRegression(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice")
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, db, user, authenticator)
+
+	authenticator.signCount = 5
+	if _, err := loginPasskey(t, db, user.Username, authenticator); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+
+	// 被复制的认证器使用了比服务器记录更小的计数
+	authenticator.signCount = 3
+	assertion, err := BeginPasskeyLogin(db, user.Username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FinishPasskeyLogin(db, authenticator.sign(t, assertion)); !errors.Is(err, ErrPasskeyCloned) {
+		t.Fatalf("签名计数回退应返回 ErrPasskeyCloned，实际 %v", err)
+	}
+
+	var record models.WebAuthnCredential
+	if err := db.Where("user_id = ?", user.ID).First(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+	if record.SignCount != 6 {
+		t.Errorf("被拒绝的登录不应修改签名计数，当前为 %d", record.SignCount)
+	}
+}
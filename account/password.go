@@ -0,0 +1,155 @@
+package account
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"blog/config"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrWeakPassword 密码不符合密码策略，具体原因包含在错误信息中
+var ErrWeakPassword = errors.New("密码不符合要求")
+
+// ErrWrongPassword 修改密码时原密码错误
+var ErrWrongPassword = errors.New("原密码错误")
+
+// CheckPasswordPolicy 检查新密码是否符合密码策略
+//
+// 依次检查长度、是否与用户名或邮箱相同，以及是否出现在泄露密码列表中。
+func CheckPasswordPolicy(password, username, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < config.Password.MinLength {
+		return fmt.Errorf("%w: 长度至少为 %d 个字符", ErrWeakPassword, config.Password.MinLength)
+	}
+	if config.Password.MaxLength > 0 && length > config.Password.MaxLength {
+		return fmt.Errorf("%w: 长度不能超过 %d 个字符", ErrWeakPassword, config.Password.MaxLength)
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	for _, s := range []string{username, email, localPart} {
+		if s != "" && strings.EqualFold(password, s) {
+			return fmt.Errorf("%w: 不能与用户名或邮箱相同", ErrWeakPassword)
+		}
+	}
+
+	breached, err := passwordBreached(password)
+	if err != nil {
+		// 列表不可读时不阻止修改密码，只记录错误
+		logrus.WithError(err).Error("检查泄露密码列表失败")
+	} else if breached {
+		return fmt.Errorf("%w: 该密码已出现在公开泄露的数据中，请更换", ErrWeakPassword)
+	}
+	return nil
+}
+
+// passwordBreached 在本地泄露密码列表中查找密码
+//
+// 列表采用 Pwned Passwords 的 k-匿名格式：密码 SHA-1（大写十六进制）的前 5 位作为文件名
+// （如 5BAA6.txt），文件每行为“剩余 35 位:出现次数”。每次只需读取一个小文件。
+func passwordBreached(password string) (bool, error) {
+	if config.Password.BreachedDir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(config.Password.BreachedDir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !strings.EqualFold(entry, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			// 只有哈希没有次数的列表视为出现过一次
+			n = 1
+		}
+		return n >= config.Password.BreachedMinCount, nil
+	}
+	return false, scanner.Err()
+}
+
+// ChangePassword 校验原密码后修改密码
+//
+// 同时递增 token_version，已签发的登录令牌和两步验证临时令牌全部失效，
+// 并发送密码已修改的通知邮件。
+func ChangePassword(db *gorm.DB, user *models.User, oldPassword, newPassword, locale string) error {
+	if !user.CheckPassword(oldPassword) {
+		return ErrWrongPassword
+	}
+	if err := CheckPasswordPolicy(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// 只更新相关字段，并以原密码哈希为条件，避免覆盖并发请求的修改
+	result := db.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		Updates(map[string]interface{}{
+			"password":      hashed,
+			"token_version": gorm.Expr("token_version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return ErrWrongPassword
+	}
+
+	notifyPasswordChanged(user, locale)
+	return nil
+}
+
+// UpgradePasswordHash 登录成功后用当前的 Argon2id 参数重新生成旧的密码哈希（如 bcrypt）
+//
+// 失败只记录日志，不影响登录。
+func UpgradePasswordHash(db *gorm.DB, user *models.User, password string) {
+	if !utils.PasswordNeedsRehash(user.Password) {
+		return
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("重新生成密码哈希失败")
+		return
+	}
+	// 条件更新避免覆盖同时修改的新密码
+	result := db.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		UpdateColumn("password", hashed)
+	if result.Error != nil {
+		logrus.WithError(result.Error).WithField("user_id", user.ID).Error("更新密码哈希失败")
+		return
+	}
+	if result.RowsAffected == 1 {
+		user.Password = hashed
+		logrus.WithField("user_id", user.ID).Info("已升级密码哈希")
+	}
+}
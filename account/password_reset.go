@@ -0,0 +1,154 @@
+package account
+
+import (
+	"errors"
+	"time"
+
+	"blog/config"
+	"blog/mailer"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidResetToken 重置令牌不存在、已使用或已过期
+var ErrInvalidResetToken = errors.New("重置链接无效或已过期")
+
+// RequestPasswordReset 为邮箱对应的账号生成重置令牌并发送邮件
+//
+// 邮箱不存在、账号被禁用或处于发送冷却期时静默返回，调用方不应区分这些情况，
+// 以免泄露邮箱是否已注册。邮件在后台发送。
+func RequestPasswordReset(db *gorm.DB, email, locale string) error {
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status != 1 {
+		return nil
+	}
+
+	var recent int64
+	if err := db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", user.ID, time.Now().Add(-config.PasswordReset.Cooldown)).
+		Count(&recent).Error; err != nil {
+		return err
+	}
+	if recent > 0 {
+		logrus.WithField("user_id", user.ID).Warn("找回密码请求过于频繁")
+		return nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 新令牌生成后，之前未使用的令牌全部作废
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(config.PasswordReset.TokenTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render("password_reset", locale, map[string]interface{}{
+		"SiteName":       config.Site.Name,
+		"Username":       displayName(&user),
+		"ResetURL":       linkWithToken(config.PasswordReset.URL, token),
+		"ExpiresMinutes": int(config.PasswordReset.TokenTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = user.Email
+	send(msg, user.ID)
+
+	logrus.WithField("user_id", user.ID).Info("已发送找回密码邮件")
+	return nil
+}
+
+// ResetPassword 使用重置令牌设置新密码
+//
+// 令牌只能使用一次；重置成功后该账号已签发的全部令牌失效。
+// 新密码不符合密码策略时返回 ErrWeakPassword，令牌不会被消耗。
+func ResetPassword(db *gorm.DB, token, newPassword, locale string) (*models.User, error) {
+	var user models.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var record models.PasswordResetToken
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+			First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		// 条件更新保证并发请求中只有一个能使用该令牌
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", record.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidResetToken
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if err := CheckPasswordPolicy(newPassword, user.Username, user.Email); err != nil {
+			return err
+		}
+		hashed, err := utils.HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+		// 能收到重置邮件说明邮箱属于本人，顺带视为已验证
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":          hashed,
+			"token_version":     gorm.Expr("token_version + 1"),
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifyPasswordChanged(&user, locale)
+
+	logrus.WithField("user_id", user.ID).Info("通过找回密码重置了密码")
+	return &user, nil
+}
+
+// notifyPasswordChanged 在后台发送密码已修改的通知邮件
+func notifyPasswordChanged(user *models.User, locale string) {
+	msg, err := mailer.Render("password_changed", locale, map[string]interface{}{
+		"SiteName":  config.Site.Name,
+		"Username":  displayName(user),
+		"ChangedAt": time.Now().Format("2006-01-02 15:04 MST"),
+	})
+	if err != nil {
+		logrus.WithError(err).Error("渲染密码修改通知失败")
+		return
+	}
+	msg.To = user.Email
+	send(msg, user.ID)
+}
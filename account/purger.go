@@ -0,0 +1,36 @@
+package account
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StartPurger 定期删除冷静期已结束的账号，返回停止函数
+func StartPurger(db *gorm.DB, interval time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			purge(db)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func purge(db *gorm.DB) {
+	n, err := PurgeDue(db)
+	if err != nil {
+		logrus.WithError(err).Error("删除到期注销账号失败")
+	}
+	if n > 0 {
+		logrus.WithField("count", n).Info("已删除到期注销账号")
+	}
+}
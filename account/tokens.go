@@ -0,0 +1,79 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"sync"
+
+	"blog/mailer"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newToken 生成 32 字节随机令牌，用于邮件中的一次性链接
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 令牌只以 SHA-256 摘要形式入库
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// linkWithToken 把令牌作为 token 查询参数附加到页面地址
+func linkWithToken(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// sending 正在后台发送的邮件，退出前等待发送完成
+var sending sync.WaitGroup
+
+// send 在后台发送邮件，失败只记录日志
+func send(msg *mailer.Message, userID uint) {
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+		if err := mailer.Default().Send(msg); err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Error("发送邮件失败")
+		}
+	}()
+}
+
+// WaitForMail 等待后台发送中的邮件完成，最多等到 ctx 结束
+func WaitForMail(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func displayName(user *models.User) string {
+	if user.Nickname != "" {
+		return user.Nickname
+	}
+	return user.Username
+}
@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Write 把全部数据表和媒体文件写入 zip 归档
+//
+// 数据通过通用的行扫描读取，不依赖具体数据库驱动，可以恢复到 MySQL 或 SQLite。
+func Write(db *gorm.DB, w io.Writer, mediaDir string) (*Manifest, error) {
+	manifest := &Manifest{
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Driver:    db.Dialector.Name(),
+		Tables:    []TableEntry{},
+		Media:     []FileEntry{},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, t := range tables {
+		entry, err := writeTable(db, zw, t)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Tables = append(manifest.Tables, *entry)
+	}
+
+	media, err := writeMedia(zw, mediaDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Media = media
+
+	mw, err := zw.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	encoder := json.NewEncoder(mw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteFile 生成备份文件，写入完成后才出现在目标路径
+func WriteFile(db *gorm.DB, path, mediaDir string) (*Manifest, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".backup-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := Write(db, tmp, mediaDir)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeTable 导出一张数据表
+func writeTable(db *gorm.DB, zw *zip.Writer, t table) (*TableEntry, error) {
+	types, err := t.columnTypes(db)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &TableEntry{Name: t.Name, File: tablesPrefix + t.Name + ".jsonl"}
+	fw, err := zw.Create(entry.File)
+	if err != nil {
+		return nil, err
+	}
+	hw := newHashingWriter(fw)
+	buf := bufio.NewWriter(hw)
+
+	rows, err := db.Table(t.Name).Order(t.Order).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			value, err := encodeValue(values[i], types[column])
+			if err != nil {
+				return nil, err
+			}
+			record[column] = value
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+		entry.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := buf.Flush(); err != nil {
+		return nil, err
+	}
+	entry.SHA256 = hw.Sum()
+	return entry, nil
+}
+
+// writeMedia 导出媒体目录，目录不存在时跳过
+func writeMedia(zw *zip.Writer, mediaDir string) ([]FileEntry, error) {
+	entries := []FileEntry{}
+	if mediaDir == "" {
+		return entries, nil
+	}
+	err := filepath.WalkDir(mediaDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == mediaDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(mediaDir, path)
+		if err != nil {
+			return err
+		}
+		entry := FileEntry{Path: filepath.ToSlash(rel), File: mediaPrefix + filepath.ToSlash(rel)}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fw, err := zw.Create(entry.File)
+		if err != nil {
+			return err
+		}
+		hw := newHashingWriter(fw)
+		if _, err := io.Copy(hw, f); err != nil {
+			return err
+		}
+		entry.Size = hw.n
+		entry.SHA256 = hw.Sum()
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"blog/cache"
+	"blog/config"
+	"blog/jobs"
+	"blog/seo"
+)
+
+// 后台任务类型
+const (
+	JobBackup  = "backup"
+	JobRestore = "restore"
+)
+
+func init() {
+	jobs.Register(JobBackup, runBackup)
+	jobs.Register(JobRestore, runRestore)
+}
+
+// fileNamePattern 备份文件名，下载和恢复时只接受该格式，防止路径穿越
+var fileNamePattern = regexp.MustCompile(`^backup-\d{8}-\d{6}(-\d+)?\.zip$`)
+
+// Dir 备份文件目录
+func Dir() string {
+	return filepath.Join(config.Storage.DataDir, "backups")
+}
+
+// NewFileName 生成新的备份文件名
+func NewFileName() string {
+	name := "backup-" + time.Now().Format("20060102-150405") + ".zip"
+	for i := 2; fileExists(filepath.Join(Dir(), name)); i++ {
+		name = fmt.Sprintf("backup-%s-%d.zip", time.Now().Format("20060102-150405"), i)
+	}
+	return name
+}
+
+// FilePath 返回备份文件路径，文件名不合法时返回 false
+func FilePath(name string) (string, bool) {
+	if !fileNamePattern.MatchString(name) {
+		return "", false
+	}
+	return filepath.Join(Dir(), name), true
+}
+
+// FileInfo 备份文件信息
+type FileInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// List 列出备份目录中的备份文件，按时间倒序
+func List() ([]FileInfo, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return []FileInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := []FileInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !fileNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, FileInfo{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+// BackupResult 备份任务结果
+type BackupResult struct {
+	File   string         `json:"file"`
+	Size   int64          `json:"size"`
+	Tables map[string]int `json:"tables"`
+	Media  int            `json:"media"`
+}
+
+// RestorePayload 恢复任务参数
+type RestorePayload struct {
+	File  string `json:"file"`
+	Force bool   `json:"force"`
+}
+
+// runBackup 执行备份任务
+func runBackup(jc *jobs.Context) error {
+	name := NewFileName()
+	path := filepath.Join(Dir(), name)
+	manifest, err := WriteFile(jc.DB(), path, config.Storage.MediaDir)
+	if err != nil {
+		return err
+	}
+
+	result := BackupResult{File: name, Tables: make(map[string]int), Media: len(manifest.Media)}
+	for _, t := range manifest.Tables {
+		result.Tables[t.Name] = t.Rows
+	}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	return jc.SetResult(result)
+}
+
+// runRestore 执行恢复任务，恢复在单个事务中完成，中断后可重新执行
+func runRestore(jc *jobs.Context) error {
+	var payload RestorePayload
+	if err := jc.Payload(&payload); err != nil {
+		return err
+	}
+
+	report, err := Restore(jc.DB(), payload.File, RestoreOptions{
+		Force:    payload.Force,
+		MediaDir: config.Storage.MediaDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 数据整体替换，站点地图和接口缓存中的内容全部作废
+	if sitemap := seo.DefaultSitemap(); sitemap != nil {
+		sitemap.Invalidate()
+	}
+	cache.Default().Clear(context.WithoutCancel(jc.Context()))
+	return jc.SetResult(report)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,66 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"time"
+)
+
+// FormatVersion 备份格式版本，格式发生不兼容变化时递增
+const FormatVersion = 1
+
+// 归档内的文件布局
+const (
+	manifestName = "manifest.json"
+	tablesPrefix = "tables/"
+	mediaPrefix  = "media/"
+)
+
+// Manifest 备份清单，记录每个文件的校验和
+type Manifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Driver    string       `json:"driver"` // 备份来源的数据库驱动，仅供参考
+	Tables    []TableEntry `json:"tables"`
+	Media     []FileEntry  `json:"media"`
+}
+
+// TableEntry 数据表文件，每行一条 JSON 记录
+type TableEntry struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileEntry 媒体文件
+type FileEntry struct {
+	Path   string `json:"path"` // 相对媒体目录的路径
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// hashingWriter 写入时计算 SHA-256
+type hashingWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func newHashingWriter(w io.Writer) *hashingWriter {
+	return &hashingWriter{w: w, h: sha256.New()}
+}
+
+func (hw *hashingWriter) Write(p []byte) (int, error) {
+	n, err := hw.w.Write(p)
+	hw.h.Write(p[:n])
+	hw.n += int64(n)
+	return n, err
+}
+
+func (hw *hashingWriter) Sum() string {
+	return hex.EncodeToString(hw.h.Sum(nil))
+}
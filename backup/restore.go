@@ -0,0 +1,340 @@
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// restoreBatchSize 每批写入的行数
+const restoreBatchSize = 500
+
+// ErrNotEmpty 目标数据库已有数据且未指定覆盖
+var ErrNotEmpty = errors.New("目标数据库已有数据，如需覆盖请指定 force")
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	Force    bool   // 清空已有数据后恢复
+	MediaDir string // 媒体文件恢复目录，为空时不恢复媒体文件
+}
+
+// RestoreReport 恢复结果
+type RestoreReport struct {
+	Version        int            `json:"version"`
+	Driver         string         `json:"driver"` // 备份来源的数据库驱动
+	Tables         map[string]int `json:"tables"` // 表名 -> 恢复行数
+	Media          int            `json:"media"`
+	SkippedTables  []string       `json:"skipped_tables,omitempty"`  // 当前版本不存在的表
+	DroppedColumns []string       `json:"dropped_columns,omitempty"` // 当前版本不存在的列
+}
+
+// Restore 从备份文件恢复
+//
+// 先校验清单中全部文件的校验和，再在一个事务中写入数据，失败时数据库保持不变。
+func Restore(db *gorm.DB, archive string, opts RestoreOptions) (*RestoreReport, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份文件失败: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, err := readManifest(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(files, manifest); err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{
+		Version: manifest.Version,
+		Driver:  manifest.Driver,
+		Tables:  make(map[string]int),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := prepare(tx, opts.Force); err != nil {
+			return err
+		}
+		for _, entry := range manifest.Tables {
+			t, ok := lookupTable(entry.Name)
+			if !ok || !tx.Migrator().HasTable(entry.Name) {
+				report.SkippedTables = append(report.SkippedTables, entry.Name)
+				continue
+			}
+			n, dropped, err := restoreTable(tx, t, files[entry.File])
+			if err != nil {
+				return fmt.Errorf("恢复数据表 %s 失败: %w", entry.Name, err)
+			}
+			if n != entry.Rows {
+				return fmt.Errorf("数据表 %s 行数与清单不一致", entry.Name)
+			}
+			report.Tables[entry.Name] = n
+			report.DroppedColumns = append(report.DroppedColumns, dropped...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MediaDir != "" {
+		for _, entry := range manifest.Media {
+			if err := restoreMedia(files[entry.File], opts.MediaDir, entry.Path); err != nil {
+				return report, fmt.Errorf("恢复媒体文件 %s 失败: %w", entry.Path, err)
+			}
+			report.Media++
+		}
+	}
+	return report, nil
+}
+
+// readManifest 读取并检查备份清单
+func readManifest(files map[string]*zip.File) (*Manifest, error) {
+	f, ok := files[manifestName]
+	if !ok {
+		return nil, errors.New("备份文件缺少 manifest.json")
+	}
+	data, err := readAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析备份清单失败: %w", err)
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("不支持的备份格式版本: %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// verify 校验清单中每个文件的存在性和校验和
+func verify(files map[string]*zip.File, manifest *Manifest) error {
+	check := func(name, sum string) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("备份文件缺少 %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, rc); err != nil {
+			return fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+		if hex.EncodeToString(h.Sum(nil)) != sum {
+			return fmt.Errorf("%s 校验和不匹配，备份文件可能已损坏", name)
+		}
+		return nil
+	}
+
+	for _, entry := range manifest.Tables {
+		if err := check(entry.File, entry.SHA256); err != nil {
+			return err
+		}
+	}
+	for _, entry := range manifest.Media {
+		if _, err := mediaPath("", entry.Path); err != nil {
+			return err
+		}
+		if err := check(entry.File, entry.SHA256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare 检查目标数据库是否为空，force 时倒序清空全部数据表
+func prepare(tx *gorm.DB, force bool) error {
+	for i := len(tables) - 1; i >= 0; i-- {
+		t := tables[i]
+		if !tx.Migrator().HasTable(t.Name) {
+			continue
+		}
+		if !force {
+			var count int64
+			if err := tx.Table(t.Name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrNotEmpty
+			}
+			continue
+		}
+		if t.SelfRef != "" {
+			if err := tx.Table(t.Name).Where(t.SelfRef+" IS NOT NULL").Update(t.SelfRef, nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM ?", clause.Table{Name: t.Name}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreTable 分批写入一张数据表，返回写入行数和被丢弃的列
+func restoreTable(tx *gorm.DB, t table, f *zip.File) (int, []string, error) {
+	types, err := t.columnTypes(tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	columnTypes, err := tx.Migrator().ColumnTypes(t.Name)
+	if err != nil {
+		return 0, nil, err
+	}
+	existing := make(map[string]bool, len(columnTypes))
+	for _, ct := range columnTypes {
+		existing[ct.Name()] = true
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rc.Close()
+
+	droppedSet := make(map[string]bool)
+	var (
+		count    int
+		batch    []map[string]interface{}
+		selfRefs = make(map[interface{}]interface{})
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.Table(t.Name).Create(&batch).Error; err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			return 0, nil, fmt.Errorf("第 %d 行解析失败: %w", count+1, err)
+		}
+
+		row := make(map[string]interface{}, len(record))
+		for column, value := range record {
+			if !existing[column] {
+				droppedSet[t.Name+"."+column] = true
+				continue
+			}
+			decoded, err := decodeValue(value, types[column])
+			if err != nil {
+				return 0, nil, fmt.Errorf("第 %d 行 %s 列: %w", count+1, column, err)
+			}
+			row[column] = decoded
+		}
+		// 自引用的行可能引用尚未写入的记录，先置空，全部写入后回填
+		if t.SelfRef != "" && row[t.SelfRef] != nil {
+			selfRefs[row["id"]] = row[t.SelfRef]
+			row[t.SelfRef] = nil
+		}
+
+		batch = append(batch, row)
+		count++
+		if len(batch) >= restoreBatchSize {
+			if err := flush(); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	if err := flush(); err != nil {
+		return 0, nil, err
+	}
+
+	for id, ref := range selfRefs {
+		if err := tx.Table(t.Name).Where("id = ?", id).Update(t.SelfRef, ref).Error; err != nil {
+			return 0, nil, err
+		}
+	}
+
+	dropped := make([]string, 0, len(droppedSet))
+	for column := range droppedSet {
+		dropped = append(dropped, column)
+	}
+	sort.Strings(dropped)
+	return count, dropped, nil
+}
+
+// restoreMedia 写入一个媒体文件
+func restoreMedia(f *zip.File, mediaDir, name string) error {
+	target, err := mediaPath(mediaDir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// mediaPath 把清单中的相对路径转换为媒体目录下的路径，拒绝越出目录的路径
+func mediaPath(mediaDir, name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || clean != "/"+name || strings.Contains(name, "\\") {
+		return "", fmt.Errorf("非法的媒体文件路径: %s", name)
+	}
+	return filepath.Join(mediaDir, filepath.FromSlash(clean[1:])), nil
+}
+
+func readAll(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
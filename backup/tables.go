@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"blog/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// table 需要备份的数据表
+type table struct {
+	Name    string
+	Model   interface{}                // 用于推断列类型，关联表为 nil
+	Columns map[string]schema.DataType // Model 为 nil 时的列类型
+	Order   string                     // 导出顺序
+	SelfRef string                     // 自引用外键列，恢复时所有行写入后再回填
+}
+
+// tables 按依赖顺序排列，恢复时依次写入，清空时倒序删除
+//
+// jobs 表记录的是运行状态，不在备份范围内。
+var tables = []table{
+	{Name: "users", Model: &models.User{}, Order: "id"},
+	{Name: "categories", Model: &models.Category{}, Order: "id"},
+	{Name: "tags", Model: &models.Tag{}, Order: "id"},
+	{Name: "posts", Model: &models.Post{}, Order: "id"},
+	{Name: "post_tags", Columns: map[string]schema.DataType{"post_id": schema.Uint, "tag_id": schema.Uint}, Order: "post_id, tag_id"},
+	{Name: "comments", Model: &models.Comment{}, Order: "id", SelfRef: "parent_id"},
+	{Name: "post_slug_histories", Model: &models.PostSlugHistory{}, Order: "id"},
+	{Name: "login_logs", Model: &models.LoginLog{}, Order: "id"},
+	{Name: "mfa_recovery_codes", Model: &models.RecoveryCode{}, Order: "id"},
+	{Name: "webauthn_credentials", Model: &models.WebAuthnCredential{}, Order: "id"},
+	{Name: "user_identities", Model: &models.UserIdentity{}, Order: "id"},
+	{Name: "oauth_clients", Model: &models.OAuthClient{}, Order: "id"},
+	{Name: "oauth_consents", Model: &models.OAuthConsent{}, Order: "id"},
+	{Name: "api_keys", Model: &models.APIKey{}, Order: "id"},
+}
+
+// lookupTable 按名称查找数据表
+func lookupTable(name string) (table, bool) {
+	for _, t := range tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return table{}, false
+}
+
+// columnTypes 返回数据表各列的类型
+func (t table) columnTypes(db *gorm.DB) (map[string]schema.DataType, error) {
+	if t.Model == nil {
+		return t.Columns, nil
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(t.Model); err != nil {
+		return nil, err
+	}
+	types := make(map[string]schema.DataType)
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		types[field.DBName] = field.DataType
+	}
+	return types, nil
+}
+
+// encodeValue 把驱动返回的值转换为与驱动无关的 JSON 值
+//
+// 时间统一为 UTC 的 RFC3339 格式，MySQL 的 tinyint 布尔值转换为 true/false。
+func encodeValue(value interface{}, dataType schema.DataType) (interface{}, error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case string:
+		switch dataType {
+		case schema.Time:
+			t, err := parseTime(v)
+			if err != nil {
+				return nil, err
+			}
+			return t.UTC().Format(time.RFC3339Nano), nil
+		case schema.Int, schema.Uint:
+			return strconv.ParseInt(v, 10, 64)
+		case schema.Float:
+			return strconv.ParseFloat(v, 64)
+		case schema.Bool:
+			return v == "1" || v == "true", nil
+		}
+		return v, nil
+	case int64:
+		if dataType == schema.Bool {
+			return v != 0, nil
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// decodeValue 把备份中的 JSON 值转换为写入数据库的值
+func decodeValue(value interface{}, dataType schema.DataType) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		switch dataType {
+		case schema.Float:
+			return v.Float64()
+		case schema.Bool:
+			n, err := v.Int64()
+			return n != 0, err
+		}
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case string:
+		if dataType == schema.Time {
+			return time.Parse(time.RFC3339Nano, v)
+		}
+	}
+	return value, nil
+}
+
+// sqliteTimeLayouts SQLite 以文本保存时间时可能出现的格式
+var sqliteTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", value)
+}
@@ -0,0 +1,103 @@
+// Package cache 热点读接口的缓存
+//
+// 缓存条目带有标签（如某篇文章、文章列表），写操作按标签批量失效；同一个键未命中时只有一个请求查询数据库，
+// 其他并发请求等待并共用结果，避免缓存失效瞬间大量请求同时打到数据库。
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"blog/metrics"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// Store 缓存存储
+//
+// 多实例部署时使用共享存储（如 Redis），一个实例的失效对所有实例生效。
+type Store interface {
+	// Get 读取缓存，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存并关联到标签
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	// Invalidate 删除关联到任一标签的全部缓存
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// tagAll 每个缓存条目都带有的标签，用于清空全部缓存
+const tagAll = "all"
+
+// Cache 带防击穿的缓存，为 nil 时不缓存，每次都调用加载函数
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	group singleflight.Group
+	epoch atomic.Uint64 // 本实例执行失效的次数，加载期间发生过失效时结果可能已过时，不写入缓存
+}
+
+// New 创建缓存，ttl 为条目的有效期
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl}
+}
+
+// Fetch 读取缓存，未命中时调用 load 生成并以 tags 写入缓存
+//
+// 同一个键的并发请求共用一次 load；load 返回错误时不缓存。缓存存储出错时直接调用 load，不影响请求。
+func (c *Cache) Fetch(ctx context.Context, key string, tags []string, load func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return load(ctx)
+	}
+
+	value, ok, err := c.store.Get(ctx, key)
+	switch {
+	case err != nil:
+		metrics.CacheRequests.WithLabelValues("error").Inc()
+		logrus.WithError(err).WithField("key", key).Warn("读取缓存失败")
+	case ok:
+		metrics.CacheRequests.WithLabelValues("hit").Inc()
+		return value, nil
+	default:
+		metrics.CacheRequests.WithLabelValues("miss").Inc()
+	}
+
+	// 由第一个请求加载，加载期间该请求断开不影响其他等待的请求
+	loadCtx := context.WithoutCancel(ctx)
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		epoch := c.epoch.Load()
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		if c.epoch.Load() != epoch {
+			return value, nil
+		}
+		entryTags := append(append(make([]string, 0, len(tags)+1), tags...), tagAll)
+		if err := c.store.Set(loadCtx, key, value, c.ttl, entryTags...); err != nil {
+			logrus.WithError(err).WithField("key", key).Warn("写入缓存失败")
+		}
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// Clear 清空全部缓存，用于恢复备份等整体替换数据的操作
+func (c *Cache) Clear(ctx context.Context) {
+	c.Invalidate(ctx, tagAll)
+}
+
+// Invalidate 按标签失效缓存，失败只记录日志，缓存在有效期后自然过期
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) {
+	if c == nil || len(tags) == 0 {
+		return
+	}
+	c.epoch.Add(1)
+	if err := c.store.Invalidate(ctx, tags...); err != nil {
+		logrus.WithError(err).WithField("tags", tags).Error("失效缓存失败")
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"blog/config"
+	"blog/events"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TagPostList 文章列表的缓存标签
+const TagPostList = "posts"
+
+// TagPost 单篇文章详情的缓存标签
+func TagPost(postID uint) string {
+	return "post:" + strconv.FormatUint(uint64(postID), 10)
+}
+
+// TagComments 文章评论列表的缓存标签
+func TagComments(postID uint) string {
+	return TagPost(postID) + ":comments"
+}
+
+var (
+	defaultCache     *Cache
+	defaultCacheOnce sync.Once
+)
+
+// Init 按配置创建默认缓存并订阅文章、评论事件，未启用时返回 nil
+//
+// Redis 连接失败时退回进程内缓存，不影响服务启动。
+func Init(cfg config.CacheConfig) *Cache {
+	defaultCacheOnce.Do(func() {
+		if !cfg.Enabled {
+			return
+		}
+		defaultCache = New(newStore(cfg), cfg.TTL)
+		defaultCache.subscribe()
+	})
+	return defaultCache
+}
+
+// Default 获取默认缓存，未启用或尚未初始化时为 nil（nil 的方法可以直接调用）
+func Default() *Cache {
+	return defaultCache
+}
+
+func newStore(cfg config.CacheConfig) Store {
+	if cfg.Backend == config.CacheRedis {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client, err := OpenRedis(ctx, cfg.RedisURL)
+		if err == nil {
+			logrus.Info("接口缓存使用 Redis")
+			return NewRedisStore(client, cfg.Prefix)
+		}
+		logrus.WithError(err).Error("连接缓存 Redis 失败，使用进程内缓存")
+	} else if cfg.Backend != config.CacheMemory {
+		logrus.WithField("backend", cfg.Backend).Error("不支持的缓存存储方式，使用进程内缓存")
+	}
+	return NewMemoryStore(cfg.MaxEntries)
+}
+
+// subscribe 文章增删改时失效文章详情和列表，评论增删改时还需失效评论列表（文章的评论数也随之变化）
+func (c *Cache) subscribe() {
+	onPost := func(payload interface{}) {
+		if post, ok := payload.(*models.Post); ok {
+			c.Invalidate(context.Background(), TagPostList, TagPost(post.ID))
+		}
+	}
+	for _, topic := range []string{events.PostCreated, events.PostUpdated, events.PostDeleted} {
+		events.Subscribe(topic, onPost)
+	}
+
+	onComment := func(payload interface{}) {
+		if comment, ok := payload.(*models.Comment); ok {
+			c.Invalidate(context.Background(), TagPostList, TagPost(comment.PostID), TagComments(comment.PostID))
+		}
+	}
+	for _, topic := range []string{events.CommentCreated, events.CommentDeleted, events.CommentUpdated} {
+		events.Subscribe(topic, onComment)
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore 进程内 LRU 缓存，条目数超过上限时淘汰最久未使用的条目
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List // 链表头部为最近使用的条目
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{} // 标签到键的索引
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// NewMemoryStore 创建进程内缓存，maxEntries 不大于 0 时不限制条目数
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get 实现 Store
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.remove(elem)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set 实现 Store
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	entry := &memoryEntry{key: key, value: value, expiresAt: time.Now().Add(ttl), tags: tags}
+	s.entries[key] = s.lru.PushFront(entry)
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+// Invalidate 实现 Store
+func (s *MemoryStore) Invalidate(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if elem, ok := s.entries[key]; ok {
+				s.remove(elem)
+			}
+		}
+		delete(s.tags, tag)
+	}
+	return nil
+}
+
+// remove 删除条目及其标签索引，调用方需持有锁
+func (s *MemoryStore) remove(elem *list.Element) {
+	entry := s.lru.Remove(elem).(*memoryEntry)
+	delete(s.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidateScript 原子地删除标签集合中的全部键及集合本身，避免与并发写入交错导致漏删
+var invalidateScript = redis.NewScript(`
+local keys = redis.call('SMEMBERS', KEYS[1])
+for _, key in ipairs(keys) do
+	redis.call('DEL', ARGV[1] .. key)
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+// RedisStore 使用 Redis 或兼容 Redis 协议的服务（如 Valkey、KeyDB）的缓存
+//
+// 每个标签对应一个集合，保存关联的键，失效时删除集合中的全部键。失效脚本访问的键不全在 KEYS 中，
+// 不支持 Redis Cluster，请使用单节点或主从部署。
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 缓存，prefix 为所有键的前缀
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// OpenRedis 按 redis:// 或 rediss:// 地址连接 Redis 并检查连接
+func OpenRedis(ctx context.Context, url string) (*redis.Client, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Get 实现 Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.entryKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set 实现 Store
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.entryKey(key), value, ttl)
+		for _, tag := range tags {
+			// 所有条目的有效期相同，标签集合随最后写入的条目过期即可
+			pipe.SAdd(ctx, s.tagKey(tag), key)
+			pipe.Expire(ctx, s.tagKey(tag), ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// Invalidate 实现 Store
+func (s *RedisStore) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := invalidateScript.Run(ctx, s.client, []string{s.tagKey(tag)}, s.prefix+"key:").Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RedisStore) entryKey(key string) string {
+	return s.prefix + "key:" + key
+}
+
+func (s *RedisStore) tagKey(tag string) string {
+	return s.prefix + "tag:" + tag
+}
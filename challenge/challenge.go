@@ -0,0 +1,108 @@
+// Package challenge 人机验证：自托管的工作量证明，以及 hCaptcha、Turnstile 等第三方验证服务
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"blog/config"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalid 答案错误、已过期或已使用
+	ErrInvalid = errors.New("人机验证未通过")
+	// ErrUnavailable 第三方验证服务不可用
+	ErrUnavailable = errors.New("人机验证服务暂不可用")
+)
+
+// Challenge 客户端完成验证需要的参数
+type Challenge struct {
+	Provider   string     `json:"provider"`
+	SiteKey    string     `json:"site_key,omitempty"`   // hCaptcha/Turnstile 组件的站点密钥
+	Challenge  string     `json:"challenge,omitempty"`  // 工作量证明题目
+	Difficulty int        `json:"difficulty,omitempty"` // 工作量证明难度（前导零比特数）
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Provider 人机验证方式
+type Provider interface {
+	// Issue 为操作生成验证参数
+	Issue(action string) (*Challenge, error)
+	// Verify 校验客户端提交的答案，答案错误时返回 ErrInvalid
+	Verify(ctx context.Context, action, response, remoteIP string) error
+}
+
+// New 按配置创建验证方式
+func New(cfg config.ChallengeConfig, db *gorm.DB) (Provider, error) {
+	switch cfg.Provider {
+	case config.ChallengePoW:
+		secret := []byte(cfg.Secret)
+		if len(secret) == 0 {
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+		return NewProofOfWork(db, secret, cfg.Difficulty, cfg.TTL), nil
+	case config.ChallengeHCaptcha:
+		if cfg.SiteKey == "" || cfg.SecretKey == "" {
+			return nil, errors.New("使用 hCaptcha 需要设置站点密钥和服务端密钥")
+		}
+		return NewHCaptcha(cfg.SiteKey, cfg.SecretKey), nil
+	case config.ChallengeTurnstile:
+		if cfg.SiteKey == "" || cfg.SecretKey == "" {
+			return nil, errors.New("使用 Turnstile 需要设置站点密钥和服务端密钥")
+		}
+		return NewTurnstile(cfg.SiteKey, cfg.SecretKey), nil
+	}
+	return nil, fmt.Errorf("不支持的人机验证方式: %s", cfg.Provider)
+}
+
+// Reputation IP 信誉查询，可接入第三方信誉服务
+type Reputation interface {
+	Suspicious(ctx context.Context, ip string) bool
+}
+
+// Networks 可疑网段列表
+type Networks []*net.IPNet
+
+// ParseNetworks 解析 IP 或 CIDR 列表，单个 IP 视为只包含该地址的网段
+func ParseNetworks(list []string) (Networks, error) {
+	networks := make(Networks, 0, len(list))
+	for _, item := range list {
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 IP 或网段: %s", item)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Suspicious 实现 Reputation
+func (n Networks) Suspicious(_ context.Context, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range n {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
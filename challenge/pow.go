@@ -0,0 +1,121 @@
+package challenge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProofOfWork hashcash 式工作量证明
+//
+// 题目形如 <操作>:<过期时间>:<难度>:<随机数>:<签名>，由服务端签名后无需保存；
+// 客户端寻找 nonce 使 SHA-256(题目 + ":" + nonce) 的前导零比特数不少于难度，
+// 提交 题目:nonce。每道题目只能使用一次，使用过的题目记录到过期为止。
+type ProofOfWork struct {
+	db         *gorm.DB
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+}
+
+// NewProofOfWork 创建工作量证明验证方式
+func NewProofOfWork(db *gorm.DB, secret []byte, difficulty int, ttl time.Duration) *ProofOfWork {
+	return &ProofOfWork{db: db, secret: secret, difficulty: difficulty, ttl: ttl}
+}
+
+// Issue 实现 Provider
+func (p *ProofOfWork) Issue(action string) (*Challenge, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(p.ttl).Truncate(time.Second)
+	payload := fmt.Sprintf("%s:%d:%d:%s", action, expiresAt.Unix(), p.difficulty, hex.EncodeToString(nonce))
+
+	return &Challenge{
+		Provider:   config.ChallengePoW,
+		Challenge:  payload + ":" + p.sign(payload),
+		Difficulty: p.difficulty,
+		ExpiresAt:  &expiresAt,
+	}, nil
+}
+
+// Verify 实现 Provider
+func (p *ProofOfWork) Verify(ctx context.Context, action, response, _ string) error {
+	parts := strings.Split(response, ":")
+	if len(parts) != 6 || parts[0] != action {
+		return ErrInvalid
+	}
+	payload := strings.Join(parts[:4], ":")
+	if !hmac.Equal([]byte(parts[4]), []byte(p.sign(payload))) {
+		return ErrInvalid
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrInvalid
+	}
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil || difficulty < p.difficulty {
+		return ErrInvalid
+	}
+
+	challenge := strings.Join(parts[:5], ":")
+	sum := sha256.Sum256([]byte(challenge + ":" + parts[5]))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return ErrInvalid
+	}
+	return p.redeem(ctx, challenge, time.Unix(expires, 0))
+}
+
+// redeem 记录题目已使用，已记录过时返回 ErrInvalid
+func (p *ProofOfWork) redeem(ctx context.Context, challenge string, expiresAt time.Time) error {
+	db := p.db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.ChallengeRedemption{}).Error; err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256([]byte(challenge))
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ChallengeRedemption{
+		Hash:      hex.EncodeToString(sum[:]),
+		ExpiresAt: expiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func (p *ProofOfWork) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// leadingZeroBits 字节序列开头连续为 0 的比特数
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c != 0 {
+			return n + bits.LeadingZeros8(c)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"blog/backup"
+	"blog/cache"
+	"blog/config"
+	"blog/database"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "backup",
+		Usage: "备份数据和媒体文件：backup [--out file.zip]",
+		Run:   runBackup,
+	})
+	Register(&Command{
+		Name:  "restore",
+		Usage: "从备份恢复：restore [--force] [--skip-media] <file.zip>",
+		Run:   runRestore,
+	})
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := flags.String("out", "", "备份文件路径，默认写入数据目录下的 backups")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = filepath.Join(backup.Dir(), backup.NewFileName())
+	}
+
+	database.InitDB()
+	manifest, err := backup.WriteFile(database.GetDB(), *out, config.Storage.MediaDir)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range manifest.Tables {
+		fmt.Printf("%-22s %d 行\n", t.Name, t.Rows)
+	}
+	fmt.Printf("媒体文件 %d 个\n备份已写入 %s\n", len(manifest.Media), *out)
+	return nil
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	force := flags.Bool("force", false, "清空现有数据后恢复")
+	skipMedia := flags.Bool("skip-media", false, "不恢复媒体文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("请指定备份文件")
+	}
+
+	mediaDir := config.Storage.MediaDir
+	if *skipMedia {
+		mediaDir = ""
+	}
+
+	database.InitDB()
+	report, err := backup.Restore(database.GetDB(), flags.Arg(0), backup.RestoreOptions{Force: *force, MediaDir: mediaDir})
+	if err != nil {
+		return err
+	}
+	// 共享的 Redis 缓存可以在这里清空；进程内缓存属于服务进程，在有效期后过期
+	if config.Cache.Enabled && config.Cache.Backend == config.CacheRedis {
+		cache.Init(config.Cache).Clear(context.Background())
+	}
+	return printJSON(report)
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Command 命令行子命令
+type Command struct {
+	Name  string
+	Usage string
+	Run   func(args []string) error
+}
+
+var registry = make(map[string]*Command)
+
+// Register 注册子命令
+func Register(cmd *Command) {
+	registry[cmd.Name] = cmd
+}
+
+// Run 执行子命令
+//
+// args 为去掉程序名后的命令行参数；未指定子命令或指定 serve 时返回 false，
+// 由调用方启动 HTTP 服务。执行子命令后返回 true。
+func Run(args []string) bool {
+	if len(args) == 0 || args[0] == "serve" {
+		return false
+	}
+
+	cmd, ok := registry[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", args[0])
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cmd.Run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s 执行失败: %v\n", cmd.Name, err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func printUsage() {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "用法: blog [serve | <命令> [参数]]")
+	fmt.Fprintln(os.Stderr, "\n可用命令:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, registry[name].Usage)
+	}
+}
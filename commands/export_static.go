@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"blog/database"
+	"blog/routes"
+	"blog/staticsite"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "export-static",
+		Usage: "导出静态站点：export-static --out dir [--force]",
+		Run:   runExportStatic,
+	})
+}
+
+func runExportStatic(args []string) error {
+	flags := flag.NewFlagSet("export-static", flag.ContinueOnError)
+	out := flags.String("out", "", "导出目录")
+	force := flags.Bool("force", false, "忽略清单，重新生成全部页面")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("必须通过 --out 指定导出目录")
+	}
+
+	database.InitDB()
+	exporter := staticsite.NewExporter(database.GetDB(), routes.SetupRoutes(), *out, *force)
+
+	report, err := exporter.Export()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("导出完成：写入 %d，跳过 %d，删除 %d\n", report.Written, report.Skipped, report.Removed)
+	return nil
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"blog/config"
+	"blog/keyring"
+	"blog/utils"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "rotate-jwt-key",
+		Usage: "生成新的 JWT 签名密钥，旧密钥在已签发的令牌过期前仍可验证",
+		Run:   runRotateJWTKey,
+	})
+}
+
+func runRotateJWTKey(args []string) error {
+	flags := flag.NewFlagSet("rotate-jwt-key", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !config.JWT.Asymmetric() {
+		return errors.New("当前使用 HS256，请先设置 JWT_ALGORITHM 为 RS256 或 EdDSA")
+	}
+
+	exists, err := keyring.HasKeys(config.JWT.KeysDir, config.JWT.Algorithm)
+	if err != nil {
+		return err
+	}
+	ring, err := utils.SigningKeys()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Printf("已生成第一个签名密钥 %s\n", ring.Signing().ID)
+		return nil
+	}
+
+	key, err := ring.Rotate()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("新的签名密钥 %s 已生成，运行中的服务将在 %s 内开始使用\n", key.ID, config.JWT.ReloadInterval)
+	for _, jwk := range ring.JWKS().Keys {
+		if jwk.KeyID != key.ID {
+			fmt.Printf("旧密钥 %s 继续用于验证，直到已签发的令牌过期\n", jwk.KeyID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"blog/database"
+	"blog/frontmatter"
+	"blog/models"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "import-markdown",
+		Usage: "导入 Hugo/Jekyll Markdown：import-markdown [--dry-run] [--author 用户名] <目录或文件>...",
+		Run:   runImportMarkdown,
+	})
+	Register(&Command{
+		Name:  "export-markdown",
+		Usage: "导出为 Markdown：export-markdown --out dir [--format yaml|toml] [--author 用户名]",
+		Run:   runExportMarkdown,
+	})
+}
+
+func runImportMarkdown(args []string) error {
+	flags := flag.NewFlagSet("import-markdown", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只输出报告，不写入数据库")
+	author := flags.String("author", "", "默认作者（front matter 未指定或作者不存在时使用）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("请指定要导入的目录或文件")
+	}
+
+	var files []frontmatter.File
+	for _, root := range flags.Args() {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			name, _ := filepath.Rel(root, p)
+			if name == "." {
+				name = filepath.Base(p)
+			}
+			files = append(files, frontmatter.File{Name: filepath.ToSlash(name), Data: data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	database.InitDB()
+	db := database.GetDB()
+
+	opts := frontmatter.ImportOptions{DryRun: *dryRun}
+	if *author != "" {
+		user, err := findUser(*author)
+		if err != nil {
+			return err
+		}
+		opts.DefaultAuthorID = user.ID
+	}
+
+	report := frontmatter.NewImporter(db, opts).Import(files)
+	return printJSON(report)
+}
+
+func runExportMarkdown(args []string) error {
+	flags := flag.NewFlagSet("export-markdown", flag.ContinueOnError)
+	out := flags.String("out", "", "导出目录")
+	format := flags.String("format", frontmatter.FormatYAML, "front matter 格式：yaml 或 toml")
+	author := flags.String("author", "", "只导出该用户的文章")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("必须通过 --out 指定导出目录")
+	}
+
+	database.InitDB()
+
+	var userID uint
+	if *author != "" {
+		user, err := findUser(*author)
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+	}
+
+	files, err := frontmatter.Export(database.GetDB(), *format, userID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(*out, file.Name), file.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("已导出 %d 篇文章到 %s\n", len(files), *out)
+	return nil
+}
+
+// findUser 按用户名或邮箱查找用户
+func findUser(name string) (*models.User, error) {
+	var user models.User
+	if err := database.GetDB().Where("username = ? OR email = ?", name, name).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %s", name)
+	}
+	return &user, nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"blog/database"
+	"blog/models"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "set-role",
+		Usage: "设置用户角色：set-role <用户名> <user|admin>",
+		Run:   runSetRole,
+	})
+}
+
+func runSetRole(args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("用法: set-role <用户名> <user|admin>")
+	}
+
+	role := flags.Arg(1)
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("未知角色: %s", role)
+	}
+
+	database.InitDB()
+	user, err := findUser(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := database.GetDB().Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	fmt.Printf("用户 %s 的角色已设置为 %s\n", user.Username, role)
+	return nil
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"blog/config"
+	"blog/database"
+	"blog/jobs"
+	"blog/models"
+	"blog/wordpress"
+)
+
+func init() {
+	Register(&Command{
+		Name:  "import-wordpress",
+		Usage: "导入 WordPress WXR：import-wordpress [--media-url /media] <文件> | --resume <任务ID>",
+		Run:   runImportWordPress,
+	})
+}
+
+func runImportWordPress(args []string) error {
+	flags := flag.NewFlagSet("import-wordpress", flag.ContinueOnError)
+	mediaURL := flags.String("media-url", config.Storage.MediaURL, "wp-content/uploads 替换后的地址前缀")
+	resume := flags.Uint("resume", 0, "从断点继续执行中断的导入任务")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	database.InitDB()
+	db := database.GetDB()
+	runner := jobs.NewRunner(db)
+
+	jobID := *resume
+	if jobID == 0 {
+		if flags.NArg() != 1 {
+			return errors.New("请指定 WXR 文件")
+		}
+		job, err := runner.Create(wordpress.JobType, 0, wordpress.Payload{File: flags.Arg(0), MediaURL: *mediaURL})
+		if err != nil {
+			return err
+		}
+		jobID = job.ID
+		fmt.Printf("导入任务 %d 已创建，中断后可使用 --resume %d 继续\n", jobID, jobID)
+	}
+
+	runner.Run(jobID)
+
+	var job models.Job
+	if err := db.First(&job, jobID).Error; err != nil {
+		return err
+	}
+	if job.Status != models.JobCompleted {
+		return errors.New("导入任务 " + strconv.Itoa(int(jobID)) + " 未完成: " + job.Error)
+	}
+	fmt.Println(job.Result)
+	return nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// 注销账号时文章的处理方式
+const (
+	PostPolicyReassign = "reassign" // 转移给"已注销用户"，保留内容
+	PostPolicyDelete   = "delete"   // 连同评论一起删除
+)
+
+// AccountConfig 账号注销配置
+type AccountConfig struct {
+	DeletionGrace time.Duration // 申请注销后的冷静期，期间可以撤销
+	PostPolicy    string        // 文章处理方式：reassign 或 delete
+	PurgeInterval time.Duration // 检查到期注销申请的间隔
+}
+
+// Account 账号注销配置实例
+var Account = AccountConfig{
+	DeletionGrace: GetEnvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+	PostPolicy:    GetEnv("ACCOUNT_DELETION_POSTS", PostPolicyReassign),
+	PurgeInterval: GetEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+}
@@ -0,0 +1,31 @@
+package config
+
+import "time"
+
+// 缓存存储方式
+const (
+	CacheMemory = "memory" // 进程内 LRU，多实例部署时各实例分别缓存
+	CacheRedis  = "redis"  // Redis 或兼容 Redis 协议的服务，多实例共享缓存和失效
+)
+
+// CacheConfig 热点读接口缓存配置
+type CacheConfig struct {
+	Enabled    bool
+	Backend    string        // 存储方式：memory 或 redis
+	MaxEntries int           // 进程内缓存的最大条目数
+	RedisURL   string        // Redis 地址，如 redis://:password@localhost:6379/0
+	Prefix     string        // Redis 键前缀，多个服务共用一个 Redis 时区分
+	TTL        time.Duration // 缓存有效期，文章和评论修改时会提前失效
+	HTTPMaxAge time.Duration // 响应头 Cache-Control 的 max-age，允许浏览器和 CDN 缓存公开接口的时间
+}
+
+// Cache 缓存配置实例
+var Cache = CacheConfig{
+	Enabled:    GetEnvBool("CACHE_ENABLED", true),
+	Backend:    GetEnv("CACHE_BACKEND", CacheMemory),
+	MaxEntries: GetEnvInt("CACHE_MAX_ENTRIES", 10000),
+	RedisURL:   GetEnv("CACHE_REDIS_URL", "redis://localhost:6379/0"),
+	Prefix:     GetEnv("CACHE_PREFIX", "blog:cache:"),
+	TTL:        GetEnvDuration("CACHE_TTL", time.Minute),
+	HTTPMaxAge: GetEnvDuration("CACHE_HTTP_MAX_AGE", 30*time.Second),
+}
@@ -0,0 +1,78 @@
+package config
+
+import "time"
+
+// 人机验证方式
+const (
+	ChallengePoW       = "pow"       // 自托管的工作量证明，无需第三方服务
+	ChallengeHCaptcha  = "hcaptcha"  // hCaptcha
+	ChallengeTurnstile = "turnstile" // Cloudflare Turnstile
+)
+
+// 接口何时要求人机验证
+const (
+	ChallengeAlways = "always" // 每次都要求
+	ChallengeRisk   = "risk"   // 出现风险信号时要求
+	ChallengeOff    = "off"    // 不要求
+)
+
+// ActionRegister 注册账号，与 ActionComment 一起作为需要人机验证的操作
+const ActionRegister = "register"
+
+// ChallengeConfig 人机验证配置
+type ChallengeConfig struct {
+	Provider   string        // 验证方式：pow、hcaptcha 或 turnstile
+	SiteKey    string        // hCaptcha/Turnstile 站点密钥，返回给前端渲染组件
+	SecretKey  string        // hCaptcha/Turnstile 服务端密钥
+	Secret     string        // 工作量证明的签名密钥，多实例部署时必须一致；未设置时每次启动随机生成
+	Difficulty int           // 工作量证明难度：哈希前导零比特数，每加 1 计算量翻倍
+	TTL        time.Duration // 工作量证明题目的有效期
+
+	Register string // 注册何时要求验证：always、risk 或 off
+	Comment  string // 发表评论何时要求验证
+
+	RegisterFreeRate RateLimitRule // 同一 IP 在此频率内注册无需验证
+	CommentFreeRate  RateLimitRule // 同一用户在此频率内评论无需验证
+	NewAccountAge    time.Duration // 注册时间短于此时长的账号评论时要求验证
+	SuspiciousIPs    []string      // 可疑的 IP 或网段（CIDR），来自这些地址的请求总是要求验证
+}
+
+// Challenge 人机验证配置实例
+var Challenge = ChallengeConfig{
+	Provider:   GetEnv("CHALLENGE_PROVIDER", ChallengePoW),
+	SiteKey:    GetEnv("CHALLENGE_SITE_KEY", ""),
+	SecretKey:  GetEnv("CHALLENGE_SECRET_KEY", ""),
+	Secret:     GetEnv("CHALLENGE_POW_SECRET", ""),
+	Difficulty: GetEnvInt("CHALLENGE_POW_DIFFICULTY", 20),
+	TTL:        GetEnvDuration("CHALLENGE_POW_TTL", 5*time.Minute),
+
+	Register: GetEnv("CHALLENGE_REGISTER", ChallengeRisk),
+	Comment:  GetEnv("CHALLENGE_COMMENT", ChallengeRisk),
+
+	RegisterFreeRate: GetEnvRate("CHALLENGE_REGISTER_FREE_RATE", RateLimitRule{Limit: 2, Period: time.Hour}),
+	CommentFreeRate:  GetEnvRate("CHALLENGE_COMMENT_FREE_RATE", RateLimitRule{Limit: 5, Period: 10 * time.Minute}),
+	NewAccountAge:    GetEnvDuration("CHALLENGE_NEW_ACCOUNT_AGE", 24*time.Hour),
+	SuspiciousIPs:    GetEnvList("CHALLENGE_SUSPICIOUS_IPS", nil),
+}
+
+// Mode 操作何时要求人机验证
+func (c ChallengeConfig) Mode(action string) string {
+	switch action {
+	case ActionRegister:
+		return c.Register
+	case ActionComment:
+		return c.Comment
+	}
+	return ChallengeOff
+}
+
+// FreeRate 操作无需人机验证的频率
+func (c ChallengeConfig) FreeRate(action string) RateLimitRule {
+	switch action {
+	case ActionRegister:
+		return c.RegisterFreeRate
+	case ActionComment:
+		return c.CommentFreeRate
+	}
+	return RateLimitRule{}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv 读取字符串环境变量，未设置时返回默认值
+func GetEnv(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetEnvInt 读取整数环境变量，解析失败时返回默认值
+func GetEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(GetEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvBool 读取布尔环境变量，解析失败时返回默认值
+func GetEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(GetEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvFloat 读取浮点数环境变量，解析失败时返回默认值
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(GetEnv(key, ""), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvDuration 读取时长环境变量（如 30s、15m），解析失败时返回默认值
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(GetEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// GetEnvList 读取逗号分隔的列表环境变量
+func GetEnvList(key string, defaultValue []string) []string {
+	raw := GetEnv(key, "")
+	if raw == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
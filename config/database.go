@@ -0,0 +1,41 @@
+package config
+
+import "fmt"
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver string // mysql 或 sqlite
+	DSN    string // 连接串，未设置时根据驱动生成
+}
+
+// Database 数据库配置实例
+var Database = DatabaseConfig{
+	Driver: GetEnv("DB_DRIVER", DriverMySQL),
+	DSN:    GetEnv("DB_DSN", ""),
+}
+
+// ConnString 返回当前驱动的连接串
+//
+// MySQL 未设置 DB_DSN 时由 DB_HOST、DB_PORT、DB_USERNAME、DB_PASSWORD、DB_NAME 拼接，
+// SQLite 默认使用数据目录下的 blog.db。
+func (c DatabaseConfig) ConnString() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+	if c.Driver == DriverSQLite {
+		return Storage.DataDir + "/blog.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		GetEnv("DB_USERNAME", "root"),
+		GetEnv("DB_PASSWORD", "password"),
+		GetEnv("DB_HOST", "localhost"),
+		GetEnv("DB_PORT", "3306"),
+		GetEnv("DB_NAME", "blog"),
+	)
+}
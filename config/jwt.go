@@ -0,0 +1,34 @@
+package config
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// JWT 签名算法
+const (
+	JWTAlgorithmHS256 = "HS256" // 共享密钥，其他服务需要同一密钥才能验证
+	JWTAlgorithmRS256 = "RS256" // RSA 2048
+	JWTAlgorithmEdDSA = "EdDSA" // Ed25519
+)
+
+// JWTConfig 登录令牌签名配置
+type JWTConfig struct {
+	Algorithm      string        // 签名算法：HS256、RS256 或 EdDSA
+	Secret         string        // HS256 密钥；使用非对称算法时设置此项可继续接受切换前签发的 HS256 令牌
+	KeysDir        string        // 非对称密钥目录，每个密钥一个 PEM 文件
+	ReloadInterval time.Duration // 重新读取密钥目录的间隔，多实例部署时用于获取轮换后的密钥
+}
+
+// JWT 登录令牌签名配置实例
+var JWT = JWTConfig{
+	Algorithm:      GetEnv("JWT_ALGORITHM", JWTAlgorithmHS256),
+	Secret:         GetEnv("JWT_SECRET", ""),
+	KeysDir:        GetEnv("JWT_KEYS_DIR", filepath.Join(Storage.DataDir, "jwt-keys")),
+	ReloadInterval: GetEnvDuration("JWT_KEYS_RELOAD_INTERVAL", time.Minute),
+}
+
+// Asymmetric 是否使用非对称算法签名
+func (c JWTConfig) Asymmetric() bool {
+	return c.Algorithm == JWTAlgorithmRS256 || c.Algorithm == JWTAlgorithmEdDSA
+}
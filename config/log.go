@@ -0,0 +1,21 @@
+package config
+
+// 日志格式
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level       string // 日志级别：debug、info、warn、error
+	Format      string // 输出格式：json 或 text
+	RedactEmail bool   // 是否遮盖日志中的邮箱地址
+}
+
+// Log 日志配置实例
+var Log = LogConfig{
+	Level:       GetEnv("LOG_LEVEL", "info"),
+	Format:      GetEnv("LOG_FORMAT", LogFormatJSON),
+	RedactEmail: GetEnvBool("LOG_REDACT_EMAIL", true),
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// 邮件发送方式
+const (
+	MailDriverSMTP   = "smtp"   // 通过 SMTP 服务器发送
+	MailDriverOutbox = "outbox" // 写入本地发件箱目录，便于开发和测试时查看
+)
+
+// MailConfig 邮件配置
+type MailConfig struct {
+	Driver        string // smtp 或 outbox
+	From          string // 发件人地址
+	OutboxDir     string // outbox 模式下邮件保存目录
+	TemplateDir   string // 自定义邮件模板目录，存在同名模板时覆盖内置模板
+	DefaultLocale string // 无法从请求确定语言时使用的语言
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+}
+
+// Mail 邮件配置实例
+var Mail = MailConfig{
+	Driver:        GetEnv("MAIL_DRIVER", MailDriverOutbox),
+	From:          GetEnv("MAIL_FROM", "no-reply@localhost"),
+	OutboxDir:     GetEnv("MAIL_OUTBOX_DIR", filepath.Join(Storage.DataDir, "outbox")),
+	TemplateDir:   GetEnv("MAIL_TEMPLATE_DIR", ""),
+	DefaultLocale: GetEnv("MAIL_DEFAULT_LOCALE", "zh-CN"),
+
+	SMTPHost:     GetEnv("SMTP_HOST", "localhost"),
+	SMTPPort:     GetEnvInt("SMTP_PORT", 587),
+	SMTPUsername: GetEnv("SMTP_USERNAME", ""),
+	SMTPPassword: GetEnv("SMTP_PASSWORD", ""),
+	SMTPTimeout:  GetEnvDuration("SMTP_TIMEOUT", 10*time.Second),
+}
+
+// PasswordResetConfig 找回密码配置
+type PasswordResetConfig struct {
+	TokenTTL time.Duration // 重置链接有效期
+	URL      string        // 重置页面地址，令牌以 token 查询参数附加
+	Cooldown time.Duration // 同一账号两次发送重置邮件的最小间隔
+}
+
+// PasswordReset 找回密码配置实例
+var PasswordReset = PasswordResetConfig{
+	TokenTTL: GetEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+	URL:      GetEnv("PASSWORD_RESET_URL", Site.URL+"/reset-password"),
+	Cooldown: GetEnvDuration("PASSWORD_RESET_COOLDOWN", time.Minute),
+}
+
+// 未验证邮箱的用户可能被限制的操作
+const (
+	ActionLogin   = "login"
+	ActionPost    = "post"
+	ActionComment = "comment"
+)
+
+// EmailVerificationConfig 邮箱验证配置
+type EmailVerificationConfig struct {
+	TokenTTL time.Duration // 验证链接有效期
+	URL      string        // 验证页面地址，令牌以 token 查询参数附加
+	Cooldown time.Duration // 同一账号两次发送验证邮件的最小间隔
+	Deny     []string      // 未验证邮箱的用户禁止的操作：login、post、comment
+}
+
+// EmailVerification 邮箱验证配置实例
+var EmailVerification = EmailVerificationConfig{
+	TokenTTL: GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+	URL:      GetEnv("EMAIL_VERIFICATION_URL", Site.URL+"/verify-email"),
+	Cooldown: GetEnvDuration("EMAIL_VERIFICATION_COOLDOWN", time.Minute),
+	Deny:     GetEnvList("EMAIL_UNVERIFIED_DENY", []string{ActionPost, ActionComment}),
+}
+
+// Denies 未验证邮箱的用户是否禁止执行该操作
+func (c EmailVerificationConfig) Denies(action string) bool {
+	for _, denied := range c.Deny {
+		if denied == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package config
+
+// MetricsConfig 监控指标配置
+type MetricsConfig struct {
+	Enabled bool
+	Addr    string // 单独监听的管理地址（如 127.0.0.1:9090），设置后 /metrics 只在该地址提供
+	Token   string // 未设置 Addr 时，在主端口访问 /metrics 需携带的 Bearer 令牌
+}
+
+// Metrics 监控指标配置实例
+var Metrics = MetricsConfig{
+	Enabled: GetEnvBool("METRICS_ENABLED", true),
+	Addr:    GetEnv("METRICS_ADDR", ""),
+	Token:   GetEnv("METRICS_TOKEN", ""),
+}
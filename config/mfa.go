@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// MFAConfig 两步验证配置
+type MFAConfig struct {
+	Issuer        string        // 认证器应用中显示的发行方名称
+	ChallengeTTL  time.Duration // 登录时密码验证通过后，输入两步验证码的有效期
+	MaxAttempts   int           // ChallengeTTL 时间内允许的验证码错误次数
+	Skew          int           // 允许的时钟偏差（时间步数，每步 30 秒）
+	RecoveryCodes int           // 每次生成的恢复码数量
+	RequireAdmin  bool          // 管理员必须启用两步验证才能访问管理接口
+}
+
+// MFA 两步验证配置实例
+var MFA = MFAConfig{
+	Issuer:        GetEnv("MFA_ISSUER", Site.Name),
+	ChallengeTTL:  GetEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+	MaxAttempts:   GetEnvInt("MFA_MAX_ATTEMPTS", 5),
+	Skew:          GetEnvInt("MFA_SKEW", 1),
+	RecoveryCodes: GetEnvInt("MFA_RECOVERY_CODES", 10),
+	RequireAdmin:  GetEnvBool("MFA_REQUIRE_ADMIN", true),
+}
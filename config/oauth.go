@@ -0,0 +1,97 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuthProvider 第三方登录提供方配置
+//
+// 设置 Issuer 时通过 OIDC 发现获取端点并校验 ID Token；
+// 否则使用 AuthURL、TokenURL 和 UserInfoURL（如 GitHub 这类只支持 OAuth2 的提供方）。
+type OAuthProvider struct {
+	Name         string   // 路由中使用的标识，如 github
+	DisplayName  string   // 登录按钮上显示的名称
+	ClientID     string   // 客户端 ID
+	ClientSecret string   // 客户端密钥
+	Issuer       string   // OIDC 签发方地址
+	AuthURL      string   // 授权端点
+	TokenURL     string   // 令牌端点
+	UserInfoURL  string   // 用户信息端点
+	EmailsURL    string   // 已验证邮箱列表端点（GitHub）
+	Scopes       []string // 申请的权限范围
+}
+
+// oauthPresets 常用提供方的默认配置，只需设置客户端 ID 和密钥
+var oauthPresets = map[string]OAuthProvider{
+	"github": {
+		DisplayName: "GitHub",
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+	"google": {
+		DisplayName: "Google",
+		Issuer:      "https://accounts.google.com",
+	},
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	Providers   []OAuthProvider
+	RedirectURL string        // 提供方授权后跳转的前端页面，页面把 code 和 state 提交给回调接口
+	StateTTL    time.Duration // 从发起登录到完成回调的有效期
+	AllowSignup bool          // 第三方账号没有对应用户时是否自动注册
+}
+
+// OAuth 第三方登录配置实例
+var OAuth = OAuthConfig{
+	Providers:   loadOAuthProviders(),
+	RedirectURL: GetEnv("OAUTH_REDIRECT_URL", Site.URL+"/oauth/callback"),
+	StateTTL:    GetEnvDuration("OAUTH_STATE_TTL", 10*time.Minute),
+	AllowSignup: GetEnvBool("OAUTH_ALLOW_SIGNUP", true),
+}
+
+// Provider 按名称查找已启用的提供方
+func (c OAuthConfig) Provider(name string) (OAuthProvider, bool) {
+	for _, provider := range c.Providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return OAuthProvider{}, false
+}
+
+// loadOAuthProviders 读取 OAUTH_PROVIDERS 列出的提供方，每个提供方的配置使用 OAUTH_<名称>_ 前缀
+func loadOAuthProviders() []OAuthProvider {
+	var providers []OAuthProvider
+	for _, name := range GetEnvList("OAUTH_PROVIDERS", nil) {
+		name = strings.ToLower(name)
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		provider := oauthPresets[name]
+		provider.Name = name
+		provider.DisplayName = GetEnv(prefix+"NAME", provider.DisplayName)
+		if provider.DisplayName == "" {
+			provider.DisplayName = name
+		}
+		provider.ClientID = GetEnv(prefix+"CLIENT_ID", "")
+		provider.ClientSecret = GetEnv(prefix+"CLIENT_SECRET", "")
+		provider.Issuer = strings.TrimRight(GetEnv(prefix+"ISSUER", provider.Issuer), "/")
+		provider.AuthURL = GetEnv(prefix+"AUTH_URL", provider.AuthURL)
+		provider.TokenURL = GetEnv(prefix+"TOKEN_URL", provider.TokenURL)
+		provider.UserInfoURL = GetEnv(prefix+"USERINFO_URL", provider.UserInfoURL)
+		provider.EmailsURL = GetEnv(prefix+"EMAILS_URL", provider.EmailsURL)
+
+		defaultScopes := provider.Scopes
+		if defaultScopes == nil {
+			defaultScopes = []string{"openid", "email", "profile"}
+		}
+		provider.Scopes = GetEnvList(prefix+"SCOPES", defaultScopes)
+
+		providers = append(providers, provider)
+	}
+	return providers
+}
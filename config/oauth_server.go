@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+// OAuthServerConfig 本站作为 OAuth2 授权服务器时的配置（供第三方编辑器、移动应用接入）
+type OAuthServerConfig struct {
+	Issuer          string        // 授权服务器标识，用于元数据
+	ConsentURL      string        // 前端授权确认页面，第三方应用把用户跳转到这里
+	CodeTTL         time.Duration // 授权码有效期
+	AccessTokenTTL  time.Duration // 访问令牌有效期
+	RefreshTokenTTL time.Duration // 刷新令牌有效期
+}
+
+// OAuthServer 授权服务器配置实例
+var OAuthServer = OAuthServerConfig{
+	Issuer:          Site.URL,
+	ConsentURL:      GetEnv("OAUTH_SERVER_CONSENT_URL", Site.URL+"/oauth/authorize"),
+	CodeTTL:         GetEnvDuration("OAUTH_SERVER_CODE_TTL", 5*time.Minute),
+	AccessTokenTTL:  GetEnvDuration("OAUTH_SERVER_ACCESS_TOKEN_TTL", time.Hour),
+	RefreshTokenTTL: GetEnvDuration("OAUTH_SERVER_REFRESH_TOKEN_TTL", 30*24*time.Hour),
+}
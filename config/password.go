@@ -0,0 +1,25 @@
+package config
+
+// PasswordConfig 密码策略和哈希参数
+type PasswordConfig struct {
+	MinLength        int    // 最短长度（按字符计）
+	MaxLength        int    // 最长长度，防止超长密码拖慢哈希计算
+	BreachedDir      string // 泄露密码哈希列表目录，为空时不检查
+	BreachedMinCount int    // 在泄露数据中出现至少这么多次才拒绝
+
+	Argon2Memory      uint32 // Argon2id 内存开销（KiB）
+	Argon2Iterations  uint32 // Argon2id 迭代次数
+	Argon2Parallelism uint8  // Argon2id 并行度
+}
+
+// Password 密码策略配置实例
+var Password = PasswordConfig{
+	MinLength:        GetEnvInt("PASSWORD_MIN_LENGTH", 10),
+	MaxLength:        GetEnvInt("PASSWORD_MAX_LENGTH", 128),
+	BreachedDir:      GetEnv("PASSWORD_BREACHED_DIR", ""),
+	BreachedMinCount: GetEnvInt("PASSWORD_BREACHED_MIN_COUNT", 1),
+
+	Argon2Memory:      uint32(GetEnvInt("PASSWORD_ARGON2_MEMORY", 19*1024)),
+	Argon2Iterations:  uint32(GetEnvInt("PASSWORD_ARGON2_ITERATIONS", 2)),
+	Argon2Parallelism: uint8(GetEnvInt("PASSWORD_ARGON2_PARALLELISM", 1)),
+}
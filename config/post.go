@@ -0,0 +1,11 @@
+package config
+
+// PostConfig 文章接口配置
+type PostConfig struct {
+	RequireIfMatch bool // 更新文章时必须在 If-Match 中提供 ETag，未提供时返回 428
+}
+
+// Post 文章接口配置实例
+var Post = PostConfig{
+	RequireIfMatch: GetEnvBool("POST_REQUIRE_IF_MATCH", false),
+}
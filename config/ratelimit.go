@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitRule 限流规则：每 Period 允许 Limit 次请求，可以一次性用完
+type RateLimitRule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitConfig 接口限流配置
+type RateLimitConfig struct {
+	Enabled        bool
+	TrustedProxies []string // 可信反向代理，只有来自这些地址的 X-Forwarded-For 才用于识别客户端 IP
+
+	API        RateLimitRule // 全部接口，按 IP
+	Auth       RateLimitRule // 登录、找回密码等认证接口，按 IP
+	Register   RateLimitRule // 注册，按 IP
+	OAuthToken RateLimitRule // 授权服务器的令牌、撤销和查询接口，按 IP
+	Post       RateLimitRule // 创建和导入文章，按 API 密钥或用户
+	Comment    RateLimitRule // 发表评论，按 API 密钥或用户
+}
+
+// RateLimit 接口限流配置实例
+var RateLimit = RateLimitConfig{
+	Enabled:        GetEnvBool("RATE_LIMIT_ENABLED", true),
+	TrustedProxies: GetEnvList("TRUSTED_PROXIES", nil),
+
+	API:        GetEnvRate("RATE_LIMIT_API", RateLimitRule{Limit: 300, Period: time.Minute}),
+	Auth:       GetEnvRate("RATE_LIMIT_AUTH", RateLimitRule{Limit: 10, Period: time.Minute}),
+	Register:   GetEnvRate("RATE_LIMIT_REGISTER", RateLimitRule{Limit: 5, Period: time.Hour}),
+	OAuthToken: GetEnvRate("RATE_LIMIT_OAUTH_TOKEN", RateLimitRule{Limit: 60, Period: time.Minute}),
+	Post:       GetEnvRate("RATE_LIMIT_POST", RateLimitRule{Limit: 20, Period: time.Hour}),
+	Comment:    GetEnvRate("RATE_LIMIT_COMMENT", RateLimitRule{Limit: 10, Period: time.Minute}),
+}
+
+// GetEnvRate 读取限流规则环境变量，格式为 次数/时长（如 10/1m），0 或 off 表示不限流，解析失败时返回默认值
+func GetEnvRate(key string, defaultValue RateLimitRule) RateLimitRule {
+	raw := GetEnv(key, "")
+	if raw == "0" || strings.EqualFold(raw, "off") {
+		return RateLimitRule{}
+	}
+
+	count, period, ok := strings.Cut(raw, "/")
+	if !ok {
+		return defaultValue
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || limit <= 0 {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return RateLimitRule{Limit: limit, Period: duration}
+}
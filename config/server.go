@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Port              string
+	ReadHeaderTimeout time.Duration // 读取请求头的超时，防止慢速连接占满服务
+	ReadTimeout       time.Duration // 读取整个请求（含上传的文件）的超时
+	WriteTimeout      time.Duration // 写出响应（含下载备份）的超时
+	IdleTimeout       time.Duration // 保持连接的空闲超时
+	ShutdownDelay     time.Duration // 收到退出信号后先让 /readyz 返回 503，等待负载均衡摘除流量的时间
+	ShutdownTimeout   time.Duration // 等待处理中的请求和后台任务结束的最长时间
+}
+
+// Server HTTP 服务配置实例
+var Server = ServerConfig{
+	Port:              GetEnv("PORT", "8080"),
+	ReadHeaderTimeout: GetEnvDuration("SERVER_READ_HEADER_TIMEOUT", 10*time.Second),
+	ReadTimeout:       GetEnvDuration("SERVER_READ_TIMEOUT", time.Minute),
+	WriteTimeout:      GetEnvDuration("SERVER_WRITE_TIMEOUT", 2*time.Minute),
+	IdleTimeout:       GetEnvDuration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+	ShutdownDelay:     GetEnvDuration("SHUTDOWN_DELAY", 0),
+	ShutdownTimeout:   GetEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+}
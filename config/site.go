@@ -0,0 +1,21 @@
+package config
+
+import "strings"
+
+// SiteConfig 站点配置
+type SiteConfig struct {
+	Name        string   // 站点名称
+	URL         string   // 站点对外访问地址（不含末尾斜杠）
+	RobotsAllow []string // robots.txt 允许抓取的路径
+	RobotsDeny  []string // robots.txt 禁止抓取的路径
+	RobotsFile  string   // 自定义 robots.txt 文件路径，设置后直接使用文件内容
+}
+
+// Site 站点配置实例
+var Site = SiteConfig{
+	Name:        GetEnv("SITE_NAME", "我的博客"),
+	URL:         strings.TrimRight(GetEnv("SITE_URL", "http://localhost:8080"), "/"),
+	RobotsAllow: GetEnvList("ROBOTS_ALLOW", []string{"/"}),
+	RobotsDeny:  GetEnvList("ROBOTS_DISALLOW", []string{"/api/"}),
+	RobotsFile:  GetEnv("ROBOTS_FILE", ""),
+}
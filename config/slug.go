@@ -0,0 +1,15 @@
+package config
+
+// SlugConfig 文章 slug 生成配置
+type SlugConfig struct {
+	Transliterate  string // 中日韩字符的转写方式：pinyin（默认）或 none
+	FallbackPrefix string // 标题无法转写时使用的前缀，生成如 post-1a2b3c4d
+	MaxLength      int    // slug 最大长度
+}
+
+// Slug slug 配置实例
+var Slug = SlugConfig{
+	Transliterate:  GetEnv("SLUG_TRANSLITERATE", "pinyin"),
+	FallbackPrefix: GetEnv("SLUG_FALLBACK_PREFIX", "post"),
+	MaxLength:      GetEnvInt("SLUG_MAX_LENGTH", 80),
+}
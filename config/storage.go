@@ -0,0 +1,27 @@
+package config
+
+import "path/filepath"
+
+// StorageConfig 本地存储配置
+type StorageConfig struct {
+	DataDir  string // 数据目录（导入文件、导出文件等）
+	MediaDir string // 媒体文件目录
+	MediaURL string // 媒体文件访问路径前缀
+}
+
+// Storage 本地存储配置实例
+var Storage = StorageConfig{
+	DataDir:  GetEnv("DATA_DIR", "data"),
+	MediaDir: GetEnv("MEDIA_DIR", filepath.Join(GetEnv("DATA_DIR", "data"), "media")),
+	MediaURL: GetEnv("MEDIA_URL", "/media"),
+}
+
+// JobsConfig 后台任务配置
+type JobsConfig struct {
+	Workers int // 并发执行的任务数
+}
+
+// Jobs 后台任务配置实例
+var Jobs = JobsConfig{
+	Workers: GetEnvInt("JOB_WORKERS", 2),
+}
@@ -0,0 +1,24 @@
+package config
+
+// 链路追踪导出方式
+const (
+	TracingNone   = "none"   // 不记录
+	TracingOTLP   = "otlp"   // 通过 OTLP/HTTP 发送到采集器
+	TracingStdout = "stdout" // 输出到标准输出，用于本地调试和测试
+)
+
+// TracingConfig 链路追踪配置，环境变量沿用 OpenTelemetry 的标准名称
+//
+// 采集器地址、请求头等由 OTLP 导出器直接读取 OTEL_EXPORTER_OTLP_ENDPOINT、OTEL_EXPORTER_OTLP_HEADERS 等变量。
+type TracingConfig struct {
+	Exporter    string  // 导出方式：none、otlp 或 stdout
+	ServiceName string  // 服务名
+	SampleRatio float64 // 采样比例，0 到 1；上游已决定采样的请求沿用上游的决定
+}
+
+// Tracing 链路追踪配置实例
+var Tracing = TracingConfig{
+	Exporter:    GetEnv("OTEL_TRACES_EXPORTER", TracingNone),
+	ServiceName: GetEnv("OTEL_SERVICE_NAME", "blog"),
+	SampleRatio: GetEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+}
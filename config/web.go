@@ -0,0 +1,17 @@
+package config
+
+// WebConfig 前台页面配置
+type WebConfig struct {
+	ThemeDir string // 主题根目录
+	Theme    string // 当前主题名称
+	Debug    bool   // 调试模式：每次请求重新加载模板
+	PageSize int    // 列表页每页文章数
+}
+
+// Web 前台页面配置实例
+var Web = WebConfig{
+	ThemeDir: GetEnv("THEME_DIR", "themes"),
+	Theme:    GetEnv("THEME", "default"),
+	Debug:    GetEnvBool("WEB_DEBUG", false),
+	PageSize: GetEnvInt("WEB_PAGE_SIZE", 10),
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"net/url"
+	"time"
+)
+
+// WebAuthnConfig 通行密钥（WebAuthn）配置
+type WebAuthnConfig struct {
+	RPID         string        // 依赖方 ID，通常为站点域名，注册后不可更改
+	RPName       string        // 浏览器和认证器中显示的站点名称
+	Origins      []string      // 允许发起验证的页面来源
+	ChallengeTTL time.Duration // 注册和登录挑战的有效期
+}
+
+// WebAuthn 通行密钥配置实例
+var WebAuthn = WebAuthnConfig{
+	RPID:         GetEnv("WEBAUTHN_RP_ID", siteHost()),
+	RPName:       GetEnv("WEBAUTHN_RP_NAME", Site.Name),
+	Origins:      GetEnvList("WEBAUTHN_ORIGINS", []string{Site.URL}),
+	ChallengeTTL: GetEnvDuration("WEBAUTHN_CHALLENGE_TTL", 5*time.Minute),
+}
+
+// siteHost 返回站点地址中的主机名（不含端口）
+func siteHost() string {
+	u, err := url.Parse(Site.URL)
+	if err != nil || u.Hostname() == "" {
+		return "localhost"
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"blog/account"
+	"blog/database"
+	"blog/logger"
+	"blog/middleware"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey 创建 API 密钥，完整密钥只在此时返回一次
+func (uc *UserController) CreateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("创建 API 密钥参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+
+	key, raw, err := account.CreateAPIKey(db, &user, &req)
+	if err != nil {
+		if errors.Is(err, account.ErrAPIKeyScope) {
+			utils.BadRequestResponse(c, err.Error())
+		} else {
+			logger.From(c).WithError(err).Error("创建 API 密钥失败")
+			utils.InternalServerErrorResponse(c, "创建 API 密钥失败")
+		}
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id": userID,
+		"api_key": key.Prefix,
+	}).Info("用户创建 API 密钥")
+
+	response := key.ToResponse()
+	response.Key = raw
+	utils.SuccessResponse(c, response, "创建成功，请妥善保存密钥")
+}
+
+// ListAPIKeys 获取当前用户的 API 密钥
+func (uc *UserController) ListAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var keys []models.APIKey
+	if err := database.WithContext(c.Request.Context()).Where("user_id = ?", userID).Order("id").Find(&keys).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询 API 密钥失败")
+		utils.InternalServerErrorResponse(c, "获取 API 密钥失败")
+		return
+	}
+
+	responses := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, keys[i].ToResponse())
+	}
+	utils.SuccessResponse(c, responses)
+}
+
+// DeleteAPIKey 吊销 API 密钥
+func (uc *UserController) DeleteAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "无效的 API 密钥ID")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var key models.APIKey
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "API 密钥不存在")
+		} else {
+			logger.From(c).WithError(err).Error("查询 API 密钥失败")
+			utils.InternalServerErrorResponse(c, "吊销 API 密钥失败")
+		}
+		return
+	}
+
+	if err := db.Delete(&key).Error; err != nil {
+		logger.From(c).WithError(err).Error("吊销 API 密钥失败")
+		utils.InternalServerErrorResponse(c, "吊销 API 密钥失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id": userID,
+		"api_key": key.Prefix,
+	}).Info("用户吊销 API 密钥")
+
+	utils.SuccessResponse(c, nil, "API 密钥已吊销")
+}
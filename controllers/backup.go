@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"blog/backup"
+	"blog/config"
+	"blog/jobs"
+	"blog/logger"
+	"blog/middleware"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupController 备份与恢复控制器（管理员）
+type BackupController struct{}
+
+// NewBackupController 创建备份控制器实例
+func NewBackupController() *BackupController {
+	return &BackupController{}
+}
+
+// CreateBackup 创建后台备份任务
+func (bc *BackupController) CreateBackup(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	job, err := jobs.Enqueue(backup.JobBackup, userID, struct{}{})
+	if err != nil {
+		logger.From(c).WithError(err).Error("创建备份任务失败")
+		utils.InternalServerErrorResponse(c, "创建备份任务失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"user_id": userID,
+	}).Info("备份任务已创建")
+
+	utils.SuccessResponse(c, job.ToResponse(), "备份任务已创建")
+}
+
+// ListBackups 获取备份文件列表
+func (bc *BackupController) ListBackups(c *gin.Context) {
+	files, err := backup.List()
+	if err != nil {
+		logger.From(c).WithError(err).Error("读取备份目录失败")
+		utils.InternalServerErrorResponse(c, "获取备份列表失败")
+		return
+	}
+	utils.SuccessResponse(c, files, "获取备份列表成功")
+}
+
+// DownloadBackup 下载备份文件
+func (bc *BackupController) DownloadBackup(c *gin.Context) {
+	path, ok := backup.FilePath(c.Param("name"))
+	if !ok {
+		utils.NotFoundResponse(c, "备份文件不存在")
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		utils.NotFoundResponse(c, "备份文件不存在")
+		return
+	}
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// RestoreBackup 从上传的备份文件或已有备份创建恢复任务
+//
+// 表单字段 file 为上传的备份文件，name 为备份目录中的文件名，二选一；
+// force=true 时清空现有数据后恢复。
+func (bc *BackupController) RestoreBackup(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	force, _ := strconv.ParseBool(c.PostForm("force"))
+
+	var path string
+	if header, err := c.FormFile("file"); err == nil {
+		dir := filepath.Join(config.Storage.DataDir, "imports")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			logger.From(c).WithError(err).Error("创建导入目录失败")
+			utils.InternalServerErrorResponse(c, "保存上传文件失败")
+			return
+		}
+		path = filepath.Join(dir, fmt.Sprintf("restore-%d-%d.zip", userID, time.Now().UnixNano()))
+		if err := c.SaveUploadedFile(header, path); err != nil {
+			logger.From(c).WithError(err).Error("保存备份文件失败")
+			utils.InternalServerErrorResponse(c, "保存上传文件失败")
+			return
+		}
+	} else {
+		var ok bool
+		path, ok = backup.FilePath(c.PostForm("name"))
+		if !ok {
+			utils.BadRequestResponse(c, "请上传备份文件或指定备份文件名")
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			utils.NotFoundResponse(c, "备份文件不存在")
+			return
+		}
+	}
+
+	job, err := jobs.Enqueue(backup.JobRestore, userID, backup.RestorePayload{File: path, Force: force})
+	if err != nil {
+		logger.From(c).WithError(err).Error("创建恢复任务失败")
+		utils.InternalServerErrorResponse(c, "创建恢复任务失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"user_id": userID,
+		"force":   force,
+	}).Warn("恢复任务已创建")
+
+	utils.SuccessResponse(c, job.ToResponse(), "恢复任务已创建")
+}
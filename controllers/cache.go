@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"blog/cache"
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// apiError 生成可缓存响应时的错误，按 status 输出错误响应且不缓存
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// versioned 带版本号的响应数据，ETag 中包含版本号，供 If-Match 条件更新比较
+type versioned interface {
+	ResourceVersion() uint
+}
+
+// responseETag 响应体的 ETag，带版本号的数据使用 utils.VersionETag
+func responseETag(data interface{}, body []byte) string {
+	if v, ok := data.(versioned); ok {
+		return utils.VersionETag(v.ResourceVersion(), body)
+	}
+	return utils.ETag(body)
+}
+
+// respondWithETag 输出成功响应并带上 ETag，用于不缓存但需要 ETag 的接口（如更新文章后返回最新版本）
+func respondWithETag(c *gin.Context, data interface{}, message string) {
+	body, err := json.Marshal(utils.APIResponse{Code: http.StatusOK, Message: message, Data: data})
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成响应失败")
+		utils.InternalServerErrorResponse(c, "服务器内部错误")
+		return
+	}
+	c.Header("ETag", responseETag(data, body))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// respondCached 输出可缓存的公开读接口响应，成功时返回 true
+//
+// 响应按 key 缓存在服务端，并带有 ETag 和 Cache-Control 供浏览器和 CDN 缓存；
+// 请求的 If-None-Match 与 ETag 相同时返回 304。load 返回响应数据，返回 *apiError 时输出对应的错误响应。
+func respondCached(c *gin.Context, store *cache.Cache, key string, tags []string, message string, load func(db *gorm.DB) (interface{}, error)) bool {
+	entry, err := store.Fetch(c.Request.Context(), key, tags, func(ctx context.Context) ([]byte, error) {
+		data, err := load(database.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(utils.APIResponse{Code: http.StatusOK, Message: message, Data: data})
+		if err != nil {
+			return nil, err
+		}
+		// 缓存条目为 ETag、换行和响应体，命中时无需重新计算 ETag
+		return append([]byte(responseETag(data, body)+"\n"), body...), nil
+	})
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			utils.ErrorResponse(c, apiErr.status, apiErr.message)
+		} else {
+			logger.From(c).WithError(err).Error("生成响应失败")
+			utils.InternalServerErrorResponse(c, "服务器内部错误")
+		}
+		return false
+	}
+
+	etag, body, _ := bytes.Cut(entry, []byte("\n"))
+	c.Header("ETag", string(etag))
+	if maxAge := int(config.Cache.HTTPMaxAge.Seconds()); maxAge > 0 {
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	} else {
+		c.Header("Cache-Control", "public, no-cache")
+	}
+
+	if utils.ETagMatch(c.GetHeader("If-None-Match"), string(etag)) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	return true
+}
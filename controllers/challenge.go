@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"blog/config"
+	"blog/logger"
+	"blog/middleware"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChallengeController 人机验证控制器
+type ChallengeController struct{}
+
+// NewChallengeController 创建人机验证控制器实例
+func NewChallengeController() *ChallengeController {
+	return &ChallengeController{}
+}
+
+// Issue 获取人机验证题目（工作量证明）或第三方验证组件的站点密钥
+func (cc *ChallengeController) Issue(c *gin.Context) {
+	action := c.Query("action")
+	if action != config.ActionRegister && action != config.ActionComment {
+		utils.BadRequestResponse(c, "不支持的操作: "+action)
+		return
+	}
+
+	provider, err := middleware.ChallengeProvider()
+	if err != nil {
+		logger.From(c).WithError(err).Error("初始化人机验证失败")
+		utils.InternalServerErrorResponse(c, "人机验证配置错误")
+		return
+	}
+
+	challenge, err := provider.Issue(action)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成人机验证题目失败")
+		utils.InternalServerErrorResponse(c, "生成人机验证题目失败")
+		return
+	}
+	utils.SuccessResponse(c, challenge)
+}
@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"fmt"
+	"net/http"
 	"strconv"
 
+	"blog/cache"
 	"blog/database"
+	"blog/events"
+	"blog/logger"
 	"blog/middleware"
 	"blog/models"
 	"blog/utils"
@@ -14,11 +19,13 @@ import (
 )
 
 // CommentController 评论控制器
-type CommentController struct{}
+type CommentController struct {
+	cache *cache.Cache
+}
 
-// NewCommentController 创建评论控制器实例
-func NewCommentController() *CommentController {
-	return &CommentController{}
+// NewCommentController 创建评论控制器实例，responseCache 为 nil 时不缓存
+func NewCommentController(responseCache *cache.Cache) *CommentController {
+	return &CommentController{cache: responseCache}
 }
 
 // CreateComment 创建评论
@@ -29,7 +36,7 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		return
 	}
 
-	postIDStr := c.Param("post_id")
+	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
 	if err != nil {
 		utils.BadRequestResponse(c, "无效的文章ID")
@@ -38,12 +45,12 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 
 	var req models.CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("创建评论参数绑定失败")
+		logger.From(c).WithError(err).Error("创建评论参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 
 	// 检查文章是否存在
 	var post models.Post
@@ -51,7 +58,7 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "文章不存在")
 		} else {
-			logrus.WithError(err).Error("查询文章失败")
+			logger.From(c).WithError(err).Error("查询文章失败")
 			utils.InternalServerErrorResponse(c, "查询文章失败")
 		}
 		return
@@ -75,54 +82,43 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 	}
 
 	if err := db.Create(&comment).Error; err != nil {
-		logrus.WithError(err).Error("创建评论失败")
+		logger.From(c).WithError(err).Error("创建评论失败")
 		utils.InternalServerErrorResponse(c, "创建评论失败")
 		return
 	}
 
 	// 更新文章评论数
 	if err := db.Model(&post).Update("comment_count", gorm.Expr("comment_count + ?", 1)).Error; err != nil {
-		logrus.WithError(err).Warn("更新文章评论数失败")
+		logger.From(c).WithError(err).Warn("更新文章评论数失败")
 	}
 
 	// 预加载用户信息
 	if err := db.Preload("User").First(&comment, comment.ID).Error; err != nil {
-		logrus.WithError(err).Error("获取评论详情失败")
+		logger.From(c).WithError(err).Error("获取评论详情失败")
 		utils.InternalServerErrorResponse(c, "获取评论详情失败")
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"comment_id": comment.ID,
 		"post_id":    postID,
 		"user_id":    userID,
 	}).Info("评论创建成功")
 
+	events.Publish(events.CommentCreated, &comment)
+
 	utils.SuccessResponse(c, comment.ToResponse(), "评论创建成功")
 }
 
 // GetComments 获取文章评论列表
 func (cc *CommentController) GetComments(c *gin.Context) {
-	postIDStr := c.Param("post_id")
+	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
 	if err != nil {
 		utils.BadRequestResponse(c, "无效的文章ID")
 		return
 	}
-
-	db := database.GetDB()
-
-	// 检查文章是否存在
-	var post models.Post
-	if err := db.First(&post, uint(postID)).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			utils.NotFoundResponse(c, "文章不存在")
-		} else {
-			logrus.WithError(err).Error("查询文章失败")
-			utils.InternalServerErrorResponse(c, "查询文章失败")
-		}
-		return
-	}
+	id := uint(postID)
 
 	// 获取查询参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -131,47 +127,56 @@ func (cc *CommentController) GetComments(c *gin.Context) {
 	// 计算偏移量
 	offset := (page - 1) * pageSize
 
-	var comments []models.Comment
-	var total int64
+	key := fmt.Sprintf("post:%d:comments:page=%d:size=%d", id, page, pageSize)
+	respondCached(c, cc.cache, key, []string{cache.TagComments(id)}, "获取评论列表成功", func(db *gorm.DB) (interface{}, error) {
+		// 检查文章是否存在
+		var post models.Post
+		if err := db.First(&post, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &apiError{http.StatusNotFound, "文章不存在"}
+			}
+			logger.From(c).WithError(err).Error("查询文章失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询文章失败"}
+		}
 
-	// 查询总数
-	if err := db.Model(&models.Comment{}).
-		Where("post_id = ? AND status = ?", uint(postID), 1).
-		Count(&total).Error; err != nil {
-		logrus.WithError(err).Error("查询评论总数失败")
-		utils.InternalServerErrorResponse(c, "查询评论列表失败")
-		return
-	}
+		var comments []models.Comment
+		var total int64
 
-	// 查询评论列表（预加载用户信息）
-	if err := db.Preload("User").
-		Where("post_id = ? AND status = ?", uint(postID), 1).
-		Order("created_at ASC").
-		Limit(pageSize).
-		Offset(offset).
-		Find(&comments).Error; err != nil {
-		logrus.WithError(err).Error("查询评论列表失败")
-		utils.InternalServerErrorResponse(c, "查询评论列表失败")
-		return
-	}
+		// 查询总数
+		if err := db.Model(&models.Comment{}).
+			Where("post_id = ? AND status = ?", id, 1).
+			Count(&total).Error; err != nil {
+			logger.From(c).WithError(err).Error("查询评论总数失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询评论列表失败"}
+		}
 
-	// 转换为响应格式
-	var commentResponses []models.CommentResponse
-	for _, comment := range comments {
-		commentResponses = append(commentResponses, comment.ToResponse())
-	}
+		// 查询评论列表（预加载用户信息）
+		if err := db.Preload("User").
+			Where("post_id = ? AND status = ?", id, 1).
+			Order("created_at ASC").
+			Limit(pageSize).
+			Offset(offset).
+			Find(&comments).Error; err != nil {
+			logger.From(c).WithError(err).Error("查询评论列表失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询评论列表失败"}
+		}
 
-	response := gin.H{
-		"comments": commentResponses,
-		"pagination": gin.H{
-			"page":       page,
-			"page_size":  pageSize,
-			"total":      total,
-			"total_page": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
-	}
+		// 转换为响应格式
+		var commentResponses []models.CommentResponse
+		for _, comment := range comments {
+			commentResponses = append(commentResponses, comment.ToResponse())
+		}
 
-	utils.SuccessResponse(c, response, "获取评论列表成功")
+		return gin.H{
+			"comments": commentResponses,
+			"pagination": gin.H{
+				"page":       page,
+				"page_size":  pageSize,
+				"total":      total,
+				"total_page": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		}, nil
+	})
 }
 
 // DeleteComment 删除评论
@@ -189,7 +194,7 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var comment models.Comment
 
 	// 查询评论
@@ -197,7 +202,7 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "评论不存在")
 		} else {
-			logrus.WithError(err).Error("查询评论失败")
+			logger.From(c).WithError(err).Error("查询评论失败")
 			utils.InternalServerErrorResponse(c, "查询评论失败")
 		}
 		return
@@ -205,7 +210,7 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 
 	// 检查权限（只有评论作者可以删除）
 	if comment.UserID != userID {
-		logrus.WithFields(logrus.Fields{
+		logger.From(c).WithFields(logrus.Fields{
 			"comment_id":   comment.ID,
 			"comment_user": comment.UserID,
 			"current_user": userID,
@@ -216,7 +221,7 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 
 	// 软删除评论
 	if err := db.Delete(&comment).Error; err != nil {
-		logrus.WithError(err).Error("删除评论失败")
+		logger.From(c).WithError(err).Error("删除评论失败")
 		utils.InternalServerErrorResponse(c, "删除评论失败")
 		return
 	}
@@ -225,14 +230,16 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 	var post models.Post
 	if err := db.First(&post, comment.PostID).Error; err == nil {
 		if err := db.Model(&post).Update("comment_count", gorm.Expr("comment_count - ?", 1)).Error; err != nil {
-			logrus.WithError(err).Warn("更新文章评论数失败")
+			logger.From(c).WithError(err).Warn("更新文章评论数失败")
 		}
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"comment_id": comment.ID,
 		"user_id":    userID,
 	}).Info("评论删除成功")
 
+	events.Publish(events.CommentDeleted, &comment)
+
 	utils.SuccessResponse(c, nil, "评论删除成功")
 }
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"blog/database"
+	"blog/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shuttingDown 服务已收到退出信号
+var shuttingDown atomic.Bool
+
+// BeginShutdown 标记服务正在退出，之后就绪检查返回 503，负载均衡不再分配新请求
+func BeginShutdown() {
+	shuttingDown.Store(true)
+}
+
+// HealthController 健康检查控制器
+type HealthController struct{}
+
+// NewHealthController 创建健康检查控制器实例
+func NewHealthController() *HealthController {
+	return &HealthController{}
+}
+
+// Livez 存活检查：进程能处理请求即返回 200
+//
+// 不检查数据库等依赖，避免依赖故障时进程被反复重启。
+func (hc *HealthController) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪检查：数据库可连接、表结构已迁移且服务未在退出时返回 200，否则返回 503
+func (hc *HealthController) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	ready := true
+	checks := gin.H{"database": "ok", "migrations": "ok"}
+	if err := database.Ping(ctx); err != nil {
+		logger.From(c).WithError(err).Warn("就绪检查：数据库不可用")
+		checks["database"] = "unavailable"
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if err := database.CheckMigrations(ctx); err != nil {
+		logger.From(c).WithError(err).Warn("就绪检查：数据库未完成迁移")
+		checks["migrations"] = "pending"
+		ready = false
+	}
+	if shuttingDown.Load() {
+		checks["server"] = "shutting_down"
+		ready = false
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "unavailable",
+			"message": "服务暂不可用",
+			"checks":  checks,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"message": "博客系统运行正常",
+		"checks":  checks,
+	})
+}
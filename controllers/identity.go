@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/account"
+	"blog/config"
+	"blog/database"
+	"blog/identity"
+	"blog/logger"
+	"blog/middleware"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// oauthBindingCookie 保存第三方登录浏览器绑定值的 Cookie，只在回调接口中发送
+const (
+	oauthBindingCookie = "oauth_binding"
+	oauthBindingPath   = "/api/v1/auth/oauth/callback"
+)
+
+// setOAuthBinding 写入或清除（maxAge < 0）浏览器绑定 Cookie
+func setOAuthBinding(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthBindingCookie, value, maxAge, oauthBindingPath, "", strings.HasPrefix(config.Site.URL, "https://"), true)
+}
+
+// OAuthProviders 获取可用的第三方登录方式
+func (uc *UserController) OAuthProviders(c *gin.Context) {
+	providers := make([]models.OAuthProviderResponse, 0, len(config.OAuth.Providers))
+	for _, provider := range config.OAuth.Providers {
+		providers = append(providers, models.OAuthProviderResponse{
+			Name:        provider.Name,
+			DisplayName: provider.DisplayName,
+		})
+	}
+	utils.SuccessResponse(c, providers)
+}
+
+// BeginOAuthLogin 跳转到第三方登录页面
+func (uc *UserController) BeginOAuthLogin(c *gin.Context) {
+	authURL, binding, err := account.BeginOAuth(c.Request.Context(), database.WithContext(c.Request.Context()), c.Param("provider"), nil)
+	if err != nil {
+		if errors.Is(err, identity.ErrUnknownProvider) {
+			utils.NotFoundResponse(c, err.Error())
+		} else {
+			logger.From(c).WithError(err).WithField("provider", c.Param("provider")).Error("发起第三方登录失败")
+			utils.InternalServerErrorResponse(c, "发起第三方登录失败")
+		}
+		return
+	}
+
+	setOAuthBinding(c, binding, int(config.OAuth.StateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OAuthCallback 完成第三方登录或绑定
+//
+// 请求须带有发起登录时写入的浏览器绑定 Cookie；绑定第三方账号时还须带上发起绑定的用户的令牌。
+// 登录时返回与密码登录相同的令牌；已启用两步验证的账号返回两步验证临时令牌。
+func (uc *UserController) OAuthCallback(c *gin.Context) {
+	var req models.OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("第三方登录回调参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 绑定值只能使用一次，无论成功与否都清除
+	binding, _ := c.Cookie(oauthBindingCookie)
+	setOAuthBinding(c, "", -1)
+
+	var callerID *uint
+	if userID, ok := middleware.GetCurrentUserID(c); ok {
+		callerID = &userID
+	}
+
+	result, err := account.CompleteOAuth(c.Request.Context(), database.WithContext(c.Request.Context()), req.Code, req.State, binding, callerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrOAuthState):
+			utils.UnauthorizedResponse(c, err.Error())
+		case errors.Is(err, account.ErrIdentityLinkUser),
+			errors.Is(err, account.ErrIdentityTaken),
+			errors.Is(err, account.ErrIdentityEmailUnverified),
+			errors.Is(err, account.ErrIdentityEmailRegistered),
+			errors.Is(err, account.ErrSignupDisabled):
+			utils.ForbiddenResponse(c, err.Error())
+		case errors.Is(err, identity.ErrUnknownProvider):
+			utils.NotFoundResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("第三方登录失败")
+			utils.BadRequestResponse(c, "第三方登录失败")
+		}
+		return
+	}
+
+	if result.Linked {
+		logger.From(c).WithFields(logrus.Fields{
+			"user_id":  result.User.ID,
+			"provider": result.Identity.Provider,
+		}).Info("用户绑定第三方账号")
+		utils.SuccessResponse(c, result.Identity, "绑定成功")
+		return
+	}
+
+	user := result.User
+	if user.Status != 1 {
+		logger.From(c).WithField("user_id", user.ID).Warn("尝试登录被禁用的账户")
+		recordLogin(c, user.ID, false, "账户已被禁用")
+		utils.UnauthorizedResponse(c, "账户已被禁用")
+		return
+	}
+	if !emailAllowsLogin(c, user) {
+		return
+	}
+	if user.MFAEnabled() {
+		beginMFAChallenge(c, user)
+		return
+	}
+
+	completeLogin(c, user)
+}
+
+// LinkIdentity 已登录用户绑定第三方账号，返回提供方的授权地址
+func (uc *UserController) LinkIdentity(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	authURL, binding, err := account.BeginOAuth(c.Request.Context(), database.WithContext(c.Request.Context()), c.Param("provider"), &userID)
+	if err != nil {
+		if errors.Is(err, identity.ErrUnknownProvider) {
+			utils.NotFoundResponse(c, err.Error())
+		} else {
+			logger.From(c).WithError(err).WithField("provider", c.Param("provider")).Error("发起第三方账号绑定失败")
+			utils.InternalServerErrorResponse(c, "发起绑定失败")
+		}
+		return
+	}
+
+	setOAuthBinding(c, binding, int(config.OAuth.StateTTL.Seconds()))
+	utils.SuccessResponse(c, gin.H{"authorization_url": authURL})
+}
+
+// ListIdentities 获取当前用户绑定的第三方账号
+func (uc *UserController) ListIdentities(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var identities []models.UserIdentity
+	if err := database.WithContext(c.Request.Context()).Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询第三方账号失败")
+		utils.InternalServerErrorResponse(c, "获取第三方账号失败")
+		return
+	}
+
+	utils.SuccessResponse(c, identities)
+}
+
+// UnlinkIdentity 解除第三方账号绑定
+func (uc *UserController) UnlinkIdentity(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "无效的第三方账号ID")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var record models.UserIdentity
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "第三方账号不存在")
+		} else {
+			logger.From(c).WithError(err).Error("查询第三方账号失败")
+			utils.InternalServerErrorResponse(c, "解除绑定失败")
+		}
+		return
+	}
+
+	if err := db.Delete(&record).Error; err != nil {
+		logger.From(c).WithError(err).Error("解除第三方账号绑定失败")
+		utils.InternalServerErrorResponse(c, "解除绑定失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":  userID,
+		"provider": record.Provider,
+	}).Info("用户解除第三方账号绑定")
+
+	utils.SuccessResponse(c, nil, "已解除绑定")
+}
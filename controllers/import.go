@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blog/config"
+	"blog/jobs"
+	"blog/logger"
+	"blog/middleware"
+	"blog/utils"
+	"blog/wordpress"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxWXRUploadSize WXR 文件最大体积
+const maxWXRUploadSize = 512 << 20
+
+// ImportController 站点导入控制器（管理员）
+type ImportController struct{}
+
+// NewImportController 创建站点导入控制器实例
+func NewImportController() *ImportController {
+	return &ImportController{}
+}
+
+// ImportWordPress 上传 WordPress WXR 文件并创建后台导入任务
+func (ic *ImportController) ImportWordPress(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequestResponse(c, "请上传 WXR 文件")
+		return
+	}
+	if header.Size > maxWXRUploadSize {
+		utils.BadRequestResponse(c, "文件过大")
+		return
+	}
+
+	dir := filepath.Join(config.Storage.DataDir, "imports")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.From(c).WithError(err).Error("创建导入目录失败")
+		utils.InternalServerErrorResponse(c, "保存上传文件失败")
+		return
+	}
+	path := filepath.Join(dir, fmt.Sprintf("wxr-%d-%d.xml", userID, time.Now().UnixNano()))
+	if err := c.SaveUploadedFile(header, path); err != nil {
+		logger.From(c).WithError(err).Error("保存WXR文件失败")
+		utils.InternalServerErrorResponse(c, "保存上传文件失败")
+		return
+	}
+
+	job, err := jobs.Enqueue(wordpress.JobType, userID, wordpress.Payload{
+		File:     path,
+		MediaURL: c.DefaultPostForm("media_url", config.Storage.MediaURL),
+	})
+	if err != nil {
+		logger.From(c).WithError(err).Error("创建导入任务失败")
+		utils.InternalServerErrorResponse(c, "创建导入任务失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"user_id": userID,
+		"file":    header.Filename,
+	}).Info("WordPress导入任务已创建")
+
+	utils.SuccessResponse(c, job.ToResponse(), "导入任务已创建")
+}
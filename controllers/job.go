@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"strconv"
+
+	"blog/database"
+	"blog/logger"
+	"blog/middleware"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobController 后台任务控制器
+type JobController struct{}
+
+// NewJobController 创建后台任务控制器实例
+func NewJobController() *JobController {
+	return &JobController{}
+}
+
+// GetJob 查询后台任务进度（任务创建者或管理员）
+func (jc *JobController) GetJob(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "无效的任务ID")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var job models.Job
+	if err := db.First(&job, uint(jobID)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "任务不存在")
+		} else {
+			logger.From(c).WithError(err).Error("查询任务失败")
+			utils.InternalServerErrorResponse(c, "查询任务失败")
+		}
+		return
+	}
+
+	if job.UserID != userID {
+		var user models.User
+		if err := db.Select("id", "role").First(&user, userID).Error; err != nil || !user.IsAdmin() {
+			utils.NotFoundResponse(c, "任务不存在")
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, job.ToResponse(), "获取任务进度成功")
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"blog/database"
+	"blog/frontmatter"
+	"blog/logger"
+	"blog/middleware"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxImportUploadSize 单次导入上传的最大体积
+const maxImportUploadSize = 32 << 20
+
+// MarkdownController Markdown 导入导出控制器
+type MarkdownController struct{}
+
+// NewMarkdownController 创建 Markdown 导入导出控制器实例
+func NewMarkdownController() *MarkdownController {
+	return &MarkdownController{}
+}
+
+// ImportPosts 导入 Markdown 文章（multipart 字段 files，支持 .md 和 .zip）
+//
+// 导入的文章均归属当前用户，?dry_run=true 时只返回报告。
+func (mc *MarkdownController) ImportPosts(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("上传文件总大小不能超过 %d MB", maxImportUploadSize>>20))
+			return
+		}
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	var files []frontmatter.File
+	extracted := 0 // 已解压的总字节数，多个 zip 合计不超过单个 zip 的上限
+	for _, header := range form.File["files"] {
+		f, err := header.Open()
+		if err != nil {
+			utils.BadRequestResponse(c, "读取上传文件失败")
+			return
+		}
+		var buf bytes.Buffer
+		_, err = io.Copy(&buf, f)
+		f.Close()
+		if err != nil {
+			utils.BadRequestResponse(c, "读取上传文件失败")
+			return
+		}
+
+		if strings.EqualFold(path.Ext(header.Filename), ".zip") {
+			entries, err := frontmatter.ReadZip(buf.Bytes())
+			if err != nil {
+				utils.BadRequestResponse(c, "解析zip文件失败: "+err.Error())
+				return
+			}
+			for _, entry := range entries {
+				extracted += len(entry.Data)
+			}
+			if extracted > frontmatter.MaxArchiveSize || len(files)+len(entries) > frontmatter.MaxArchiveEntries {
+				utils.BadRequestResponse(c, "zip文件解压后过大或文件过多")
+				return
+			}
+			files = append(files, entries...)
+			continue
+		}
+		files = append(files, frontmatter.File{Name: header.Filename, Data: buf.Bytes()})
+	}
+	if len(files) == 0 {
+		utils.BadRequestResponse(c, "请上传 Markdown 文件")
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	importer := frontmatter.NewImporter(database.WithContext(c.Request.Context()), frontmatter.ImportOptions{
+		DryRun:   dryRun,
+		AuthorID: userID,
+	})
+	report := importer.Import(files)
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id": userID,
+		"dry_run": dryRun,
+		"files":   len(files),
+	}).Info("Markdown导入请求处理完成")
+
+	utils.SuccessResponse(c, report, "导入完成")
+}
+
+// ExportPosts 将当前用户的文章导出为 Markdown zip 包
+func (mc *MarkdownController) ExportPosts(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	format := c.DefaultQuery("format", frontmatter.FormatYAML)
+	if format != frontmatter.FormatYAML && format != frontmatter.FormatTOML {
+		utils.BadRequestResponse(c, "format 只能是 yaml 或 toml")
+		return
+	}
+
+	files, err := frontmatter.Export(database.WithContext(c.Request.Context()), format, userID)
+	if err != nil {
+		logger.From(c).WithError(err).Error("导出Markdown失败")
+		utils.InternalServerErrorResponse(c, "导出失败")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := frontmatter.WriteZip(&buf, files); err != nil {
+		logger.From(c).WithError(err).Error("打包Markdown失败")
+		utils.InternalServerErrorResponse(c, "导出失败")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="posts.zip"`)
+	c.Data(200, "application/zip", buf.Bytes())
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"blog/account"
+	"blog/database"
+	"blog/logger"
+	"blog/metrics"
+	"blog/middleware"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController 两步验证控制器
+type MFAController struct{}
+
+// NewMFAController 创建两步验证控制器实例
+func NewMFAController() *MFAController {
+	return &MFAController{}
+}
+
+// VerifyLogin 使用认证器验证码或恢复码完成登录
+func (mc *MFAController) VerifyLogin(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("两步验证参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	claims, err := utils.ParseMFAToken(req.MFAToken)
+	if err != nil {
+		logger.From(c).WithError(err).Warn("两步验证令牌无效")
+		metrics.AuthFailures.WithLabelValues(metrics.AuthMFA, "invalid_token").Inc()
+		utils.UnauthorizedResponse(c, "验证已过期，请重新登录")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
+		utils.UnauthorizedResponse(c, "验证已过期，请重新登录")
+		return
+	}
+	// 临时令牌签发后账号被禁用、修改密码或关闭两步验证，都需要重新登录
+	if user.Status != 1 || user.TokenVersion != claims.Version || !user.MFAEnabled() {
+		logger.From(c).WithField("user_id", user.ID).Warn("两步验证令牌已失效")
+		utils.UnauthorizedResponse(c, "验证已过期，请重新登录")
+		return
+	}
+
+	exceeded, err := account.MFAAttemptsExceeded(db, user.ID)
+	if err != nil {
+		logger.From(c).WithError(err).Error("统计两步验证错误次数失败")
+		utils.InternalServerErrorResponse(c, "登录失败")
+		return
+	}
+	if exceeded {
+		logger.From(c).WithField("user_id", user.ID).Warn("两步验证错误次数过多")
+		metrics.AuthFailures.WithLabelValues(metrics.AuthMFA, "too_many_attempts").Inc()
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "验证码错误次数过多，请稍后再试")
+		return
+	}
+
+	recovery, err := account.VerifyMFA(db, &user, req.Code)
+	if err != nil {
+		if errors.Is(err, account.ErrInvalidMFACode) {
+			logger.From(c).WithField("user_id", user.ID).Warn("两步验证失败：验证码错误")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthMFA, "invalid_code").Inc()
+			recordLogin(c, user.ID, false, account.MFAFailureMessage)
+			utils.UnauthorizedResponse(c, "验证码错误")
+		} else {
+			logger.From(c).WithError(err).Error("两步验证失败")
+			utils.InternalServerErrorResponse(c, "登录失败")
+		}
+		return
+	}
+	if recovery {
+		logger.From(c).WithField("user_id", user.ID).Warn("用户使用恢复码登录")
+	}
+
+	completeLogin(c, &user)
+}
+
+// GetStatus 获取两步验证状态
+func (mc *MFAController) GetStatus(c *gin.Context) {
+	user, ok := mc.currentUser(c)
+	if !ok {
+		return
+	}
+
+	remaining, err := account.RemainingRecoveryCodes(database.WithContext(c.Request.Context()), user.ID)
+	if err != nil {
+		logger.From(c).WithError(err).Error("查询恢复码失败")
+		utils.InternalServerErrorResponse(c, "获取两步验证状态失败")
+		return
+	}
+
+	utils.SuccessResponse(c, models.MFAStatusResponse{
+		Enabled:                user.MFAEnabled(),
+		EnabledAt:              user.TOTPEnabledAt,
+		RecoveryCodesRemaining: remaining,
+		Required:               account.MFARequired(user),
+	})
+}
+
+// SetupTOTP 生成认证器密钥，需在 EnableTOTP 中提交验证码后才会启用
+func (mc *MFAController) SetupTOTP(c *gin.Context) {
+	var req models.MFASetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("绑定认证器参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	user, ok := mc.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		logger.From(c).WithField("user_id", user.ID).Warn("绑定认证器失败：密码错误")
+		utils.BadRequestResponse(c, "密码错误")
+		return
+	}
+
+	secret, uri, err := account.BeginTOTPSetup(database.WithContext(c.Request.Context()), user)
+	if err != nil {
+		if errors.Is(err, account.ErrMFAEnabled) {
+			utils.BadRequestResponse(c, err.Error())
+		} else {
+			logger.From(c).WithError(err).Error("生成认证器密钥失败")
+			utils.InternalServerErrorResponse(c, "生成认证器密钥失败")
+		}
+		return
+	}
+
+	logger.From(c).WithField("user_id", user.ID).Info("用户开始绑定认证器")
+	utils.SuccessResponse(c, models.TOTPSetupResponse{Secret: secret, URI: uri}, "请使用认证器扫描二维码")
+}
+
+// EnableTOTP 提交认证器生成的验证码，启用两步验证并返回恢复码
+func (mc *MFAController) EnableTOTP(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("启用两步验证参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	user, ok := mc.currentUser(c)
+	if !ok {
+		return
+	}
+
+	codes, err := account.EnableTOTP(database.WithContext(c.Request.Context()), user, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrMFAEnabled), errors.Is(err, account.ErrMFANotSetup), errors.Is(err, account.ErrInvalidMFACode):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("启用两步验证失败")
+			utils.InternalServerErrorResponse(c, "启用两步验证失败")
+		}
+		return
+	}
+
+	logger.From(c).WithField("user_id", user.ID).Info("用户启用两步验证")
+	utils.SuccessResponse(c, models.RecoveryCodesResponse{RecoveryCodes: codes}, "两步验证已启用，请妥善保存恢复码")
+}
+
+// DisableMFA 关闭两步验证，需要密码和当前验证码（或恢复码）
+func (mc *MFAController) DisableMFA(c *gin.Context) {
+	var req models.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("关闭两步验证参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	user, ok := mc.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		logger.From(c).WithField("user_id", user.ID).Warn("关闭两步验证失败：密码错误")
+		utils.BadRequestResponse(c, "密码错误")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	if !mc.verifyCode(c, user, req.Code) {
+		return
+	}
+
+	if err := account.DisableMFA(db, user); err != nil {
+		logger.From(c).WithError(err).Error("关闭两步验证失败")
+		utils.InternalServerErrorResponse(c, "关闭两步验证失败")
+		return
+	}
+
+	logger.From(c).WithField("user_id", user.ID).Warn("用户关闭两步验证")
+	utils.SuccessResponse(c, nil, "两步验证已关闭")
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (mc *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("生成恢复码参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	user, ok := mc.currentUser(c)
+	if !ok {
+		return
+	}
+	if !mc.verifyCode(c, user, req.Code) {
+		return
+	}
+
+	codes, err := account.RegenerateRecoveryCodes(database.WithContext(c.Request.Context()), user)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成恢复码失败")
+		utils.InternalServerErrorResponse(c, "生成恢复码失败")
+		return
+	}
+
+	logger.From(c).WithField("user_id", user.ID).Info("用户重新生成恢复码")
+	utils.SuccessResponse(c, models.RecoveryCodesResponse{RecoveryCodes: codes}, "已重新生成恢复码")
+}
+
+// currentUser 加载当前登录用户，失败时已写入响应
+func (mc *MFAController) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.WithContext(c.Request.Context()).First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return nil, false
+	}
+	return &user, true
+}
+
+// verifyCode 校验已启用两步验证用户提交的验证码，失败时已写入响应
+func (mc *MFAController) verifyCode(c *gin.Context, user *models.User, code string) bool {
+	if _, err := account.VerifyMFA(database.WithContext(c.Request.Context()), user, code); err != nil {
+		switch {
+		case errors.Is(err, account.ErrMFANotEnabled), errors.Is(err, account.ErrInvalidMFACode):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("校验两步验证码失败")
+			utils.InternalServerErrorResponse(c, "校验验证码失败")
+		}
+		return false
+	}
+	return true
+}
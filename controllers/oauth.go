@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"blog/middleware"
+	"blog/models"
+	"blog/oauthserver"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OAuthController 授权服务器控制器（本站作为 OAuth2 提供方）
+//
+// 令牌、撤销和查询端点按 OAuth2 标准格式返回，不使用统一响应结构。
+type OAuthController struct{}
+
+// NewOAuthController 创建授权服务器控制器实例
+func NewOAuthController() *OAuthController {
+	return &OAuthController{}
+}
+
+// Metadata 授权服务器元数据（RFC 8414）
+func (oc *OAuthController) Metadata(c *gin.Context) {
+	scopes := make([]string, 0, len(oauthserver.Scopes))
+	for _, scope := range oauthserver.Scopes {
+		scopes = append(scopes, scope.Name)
+	}
+	api := config.Site.URL + "/api/v1/oauth"
+
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                config.OAuthServer.Issuer,
+		"authorization_endpoint":                config.OAuthServer.ConsentURL,
+		"token_endpoint":                        api + "/token",
+		"revocation_endpoint":                   api + "/revoke",
+		"introspection_endpoint":                api + "/introspect",
+		"scopes_supported":                      scopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"jwks_uri":                              config.Site.URL + "/.well-known/jwks.json",
+	})
+}
+
+// JWKS 验证登录令牌用的公钥（RFC 7517），其他服务据此验证令牌而无需共享密钥
+func (oc *OAuthController) JWKS(c *gin.Context) {
+	set, err := utils.JWKS()
+	if err != nil {
+		logger.From(c).WithError(err).Error("读取 JWT 签名密钥失败")
+		utils.InternalServerErrorResponse(c, "获取公钥失败")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+// RegisterClient 注册第三方应用，客户端密钥只在此时返回一次
+func (oc *OAuthController) RegisterClient(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.RegisterOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("注册第三方应用参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	client, secret, err := oauthserver.RegisterClient(database.WithContext(c.Request.Context()), userID, &req)
+	if err != nil {
+		if errors.Is(err, oauthserver.ErrInvalidRedirectURI) || errors.Is(err, oauthserver.ErrUnknownScope) {
+			utils.BadRequestResponse(c, err.Error())
+		} else {
+			logger.From(c).WithError(err).Error("注册第三方应用失败")
+			utils.InternalServerErrorResponse(c, "注册第三方应用失败")
+		}
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":   userID,
+		"client_id": client.ClientID,
+	}).Info("用户注册第三方应用")
+
+	response := client.ToResponse()
+	response.ClientSecret = secret
+	utils.SuccessResponse(c, response, "注册成功，请妥善保存客户端密钥")
+}
+
+// ListClients 获取当前用户注册的第三方应用
+func (oc *OAuthController) ListClients(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var clients []models.OAuthClient
+	if err := database.WithContext(c.Request.Context()).Where("user_id = ?", userID).Order("id").Find(&clients).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询第三方应用失败")
+		utils.InternalServerErrorResponse(c, "获取第三方应用失败")
+		return
+	}
+
+	responses := make([]models.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		responses = append(responses, clients[i].ToResponse())
+	}
+	utils.SuccessResponse(c, responses)
+}
+
+// DeleteClient 删除第三方应用，已签发的令牌随之失效
+func (oc *OAuthController) DeleteClient(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var client models.OAuthClient
+	if err := db.Where("client_id = ? AND user_id = ?", c.Param("client_id"), userID).First(&client).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "第三方应用不存在")
+		} else {
+			logger.From(c).WithError(err).Error("查询第三方应用失败")
+			utils.InternalServerErrorResponse(c, "删除第三方应用失败")
+		}
+		return
+	}
+
+	if err := oauthserver.DeleteClient(db, &client); err != nil {
+		logger.From(c).WithError(err).Error("删除第三方应用失败")
+		utils.InternalServerErrorResponse(c, "删除第三方应用失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":   userID,
+		"client_id": client.ClientID,
+	}).Info("用户删除第三方应用")
+
+	utils.SuccessResponse(c, nil, "第三方应用已删除")
+}
+
+// AuthorizeInfo 授权确认页面查询应用信息和申请的权限，参数与授权请求相同
+func (oc *OAuthController) AuthorizeInfo(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	auth, ok := validateAuthorize(c, db, &req)
+	if !ok {
+		return
+	}
+
+	granted, err := oauthserver.ConsentGranted(db, userID, auth)
+	if err != nil {
+		logger.From(c).WithError(err).Error("查询授权记录失败")
+		utils.InternalServerErrorResponse(c, "获取授权信息失败")
+		return
+	}
+
+	client := auth.Client.ToResponse()
+	scopes := make([]models.OAuthScopeResponse, 0, len(auth.Scopes))
+	for _, name := range auth.Scopes {
+		scope, _ := oauthserver.LookupScope(name)
+		scopes = append(scopes, models.OAuthScopeResponse{Name: scope.Name, Description: scope.Description})
+	}
+	utils.SuccessResponse(c, models.OAuthAuthorizeResponse{
+		Client:          &client,
+		Scopes:          scopes,
+		ConsentRequired: !granted,
+	})
+}
+
+// Authorize 用户确认或拒绝授权，返回前端应跳转的回调地址
+func (oc *OAuthController) Authorize(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.OAuthConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("授权确认参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	auth, ok := validateAuthorize(c, db, &req.OAuthAuthorizeRequest)
+	if !ok {
+		return
+	}
+
+	if !req.Approve {
+		logger.From(c).WithFields(logrus.Fields{
+			"user_id":   userID,
+			"client_id": auth.Client.ClientID,
+		}).Info("用户拒绝授权第三方应用")
+		redirectTo := auth.ErrorRedirect(&oauthserver.Error{Code: oauthserver.ErrCodeAccessDenied, Description: "用户拒绝授权"})
+		utils.SuccessResponse(c, models.OAuthAuthorizeResponse{RedirectTo: redirectTo})
+		return
+	}
+
+	redirectTo, err := oauthserver.Approve(db, userID, auth)
+	if err != nil {
+		logger.From(c).WithError(err).Error("签发授权码失败")
+		utils.InternalServerErrorResponse(c, "授权失败")
+		return
+	}
+	utils.SuccessResponse(c, models.OAuthAuthorizeResponse{RedirectTo: redirectTo}, "授权成功")
+}
+
+// validateAuthorize 校验授权请求；可以跳转回第三方应用的错误以 redirect_to 返回
+func validateAuthorize(c *gin.Context, db *gorm.DB, req *models.OAuthAuthorizeRequest) (*oauthserver.Authorization, bool) {
+	auth, err := oauthserver.ValidateAuthorize(db, req)
+	if err == nil {
+		return auth, true
+	}
+
+	oauthErr, isOAuth := err.(*oauthserver.Error)
+	switch {
+	case !isOAuth:
+		logger.From(c).WithError(err).Error("校验授权请求失败")
+		utils.InternalServerErrorResponse(c, "授权失败")
+	case auth == nil:
+		utils.BadRequestResponse(c, oauthErr.Description)
+	default:
+		utils.SuccessResponse(c, models.OAuthAuthorizeResponse{RedirectTo: auth.ErrorRedirect(oauthErr)})
+	}
+	return nil, false
+}
+
+// Token 令牌端点（RFC 6749 第 3.2 节），支持 authorization_code 和 refresh_token
+func (oc *OAuthController) Token(c *gin.Context) {
+	db := database.WithContext(c.Request.Context())
+	client, ok := authenticateClient(c, db)
+	if !ok {
+		return
+	}
+
+	var (
+		token *oauthserver.TokenResponse
+		err   error
+	)
+	switch c.PostForm("grant_type") {
+	case "authorization_code":
+		code := c.PostForm("code")
+		if code == "" {
+			oauthErrorResponse(c, &oauthserver.Error{Code: oauthserver.ErrCodeInvalidRequest, Description: "缺少 code"})
+			return
+		}
+		token, err = oauthserver.ExchangeCode(db, client, code, c.PostForm("redirect_uri"), c.PostForm("code_verifier"))
+	case "refresh_token":
+		refreshToken := c.PostForm("refresh_token")
+		if refreshToken == "" {
+			oauthErrorResponse(c, &oauthserver.Error{Code: oauthserver.ErrCodeInvalidRequest, Description: "缺少 refresh_token"})
+			return
+		}
+		token, err = oauthserver.Refresh(db, client, refreshToken, c.PostForm("scope"))
+	default:
+		oauthErrorResponse(c, &oauthserver.Error{Code: oauthserver.ErrCodeUnsupportedGrantType, Description: "不支持的授权类型"})
+		return
+	}
+	if err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, token)
+}
+
+// Revoke 撤销令牌（RFC 7009），令牌无效时同样返回 200
+func (oc *OAuthController) Revoke(c *gin.Context) {
+	db := database.WithContext(c.Request.Context())
+	client, ok := authenticateClient(c, db)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthErrorResponse(c, &oauthserver.Error{Code: oauthserver.ErrCodeInvalidRequest, Description: "缺少 token"})
+		return
+	}
+	if err := oauthserver.Revoke(db, client, token); err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	logger.From(c).WithField("client_id", client.ClientID).Info("第三方应用撤销令牌")
+	c.Status(http.StatusOK)
+}
+
+// Introspect 查询令牌状态（RFC 7662）
+func (oc *OAuthController) Introspect(c *gin.Context) {
+	db := database.WithContext(c.Request.Context())
+	client, ok := authenticateClient(c, db)
+	if !ok {
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		oauthErrorResponse(c, &oauthserver.Error{Code: oauthserver.ErrCodeInvalidRequest, Description: "缺少 token"})
+		return
+	}
+	result, err := oauthserver.Introspect(db, client, token)
+	if err != nil {
+		oauthErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, result)
+}
+
+// ListAuthorizations 获取当前用户已授权的第三方应用
+func (oc *OAuthController) ListAuthorizations(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var consents []models.OAuthConsent
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&consents).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询授权记录失败")
+		utils.InternalServerErrorResponse(c, "获取已授权应用失败")
+		return
+	}
+
+	clientIDs := make([]string, 0, len(consents))
+	for _, consent := range consents {
+		clientIDs = append(clientIDs, consent.ClientID)
+	}
+	var clients []models.OAuthClient
+	if err := db.Where("client_id IN ?", clientIDs).Find(&clients).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询第三方应用失败")
+		utils.InternalServerErrorResponse(c, "获取已授权应用失败")
+		return
+	}
+	names := make(map[string]string, len(clients))
+	for _, client := range clients {
+		names[client.ClientID] = client.Name
+	}
+
+	responses := make([]models.OAuthAuthorizationResponse, 0, len(consents))
+	for _, consent := range consents {
+		responses = append(responses, models.OAuthAuthorizationResponse{
+			ClientID:  consent.ClientID,
+			Name:      names[consent.ClientID],
+			Scopes:    strings.Fields(consent.Scope),
+			CreatedAt: consent.CreatedAt,
+			UpdatedAt: consent.UpdatedAt,
+		})
+	}
+	utils.SuccessResponse(c, responses)
+}
+
+// RevokeAuthorization 取消对第三方应用的授权，已签发的令牌立即失效
+func (oc *OAuthController) RevokeAuthorization(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	clientID := c.Param("client_id")
+	if err := oauthserver.RevokeConsent(database.WithContext(c.Request.Context()), userID, clientID); err != nil {
+		logger.From(c).WithError(err).Error("取消第三方应用授权失败")
+		utils.InternalServerErrorResponse(c, "取消授权失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":   userID,
+		"client_id": clientID,
+	}).Info("用户取消第三方应用授权")
+
+	utils.SuccessResponse(c, nil, "已取消授权")
+}
+
+// authenticateClient 从 HTTP Basic 认证或表单参数中读取并校验客户端身份
+func authenticateClient(c *gin.Context, db *gorm.DB) (*models.OAuthClient, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// RFC 6749 第 2.3.1 节要求 Basic 认证中的值先做表单编码
+		if v, err := url.QueryUnescape(clientID); err == nil {
+			clientID = v
+		}
+		if v, err := url.QueryUnescape(secret); err == nil {
+			secret = v
+		}
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	client, err := oauthserver.AuthenticateClient(db, clientID, secret)
+	if err != nil {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthErrorResponse(c, err)
+		return nil, false
+	}
+	return client, true
+}
+
+// oauthErrorResponse 按 OAuth2 标准格式返回错误
+func oauthErrorResponse(c *gin.Context, err error) {
+	oauthErr, ok := err.(*oauthserver.Error)
+	if !ok {
+		logger.From(c).WithError(err).Error("授权服务器内部错误")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"path":  c.Request.URL.Path,
+		"error": oauthErr.Code,
+	}).Warn(oauthErr.Description)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(oauthErr.Status(), gin.H{
+		"error":             oauthErr.Code,
+		"error_description": oauthErr.Description,
+	})
+}
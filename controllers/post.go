@@ -1,10 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
+	"blog/cache"
+	"blog/config"
 	"blog/database"
+	"blog/events"
+	"blog/logger"
 	"blog/middleware"
 	"blog/models"
 	"blog/utils"
@@ -15,11 +22,13 @@ import (
 )
 
 // PostController 文章控制器
-type PostController struct{}
+type PostController struct {
+	cache *cache.Cache
+}
 
-// NewPostController 创建文章控制器实例
-func NewPostController() *PostController {
-	return &PostController{}
+// NewPostController 创建文章控制器实例，responseCache 为 nil 时不缓存
+func NewPostController(responseCache *cache.Cache) *PostController {
+	return &PostController{cache: responseCache}
 }
 
 // CreatePost 创建文章
@@ -32,16 +41,35 @@ func (pc *PostController) CreatePost(c *gin.Context) {
 
 	var req models.CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("创建文章参数绑定失败")
+		logger.From(c).WithError(err).Error("创建文章参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
+
+	// 校验作者自定义的 slug（未提供时在 BeforeCreate 中根据标题生成）
+	if req.Slug != "" {
+		if !utils.IsValidSlug(req.Slug) {
+			utils.BadRequestResponse(c, "slug 只能包含小写字母、数字和连字符")
+			return
+		}
+		taken, err := models.SlugTaken(db, req.Slug, 0)
+		if err != nil {
+			logger.From(c).WithError(err).Error("检查slug失败")
+			utils.InternalServerErrorResponse(c, "创建文章失败")
+			return
+		}
+		if taken {
+			utils.BadRequestResponse(c, "slug 已被使用")
+			return
+		}
+	}
 
 	// 创建文章
 	post := models.Post{
 		Title:        req.Title,
+		Slug:         req.Slug,
 		Content:      req.Content,
 		Excerpt:      req.Excerpt,
 		UserID:       userID,
@@ -63,31 +91,31 @@ func (pc *PostController) CreatePost(c *gin.Context) {
 	post.PublishedAt = &now
 
 	if err := db.Create(&post).Error; err != nil {
-		logrus.WithError(err).Error("创建文章失败")
+		logger.From(c).WithError(err).Error("创建文章失败")
 		utils.InternalServerErrorResponse(c, "创建文章失败")
 		return
 	}
 
 	// 预加载用户信息
 	if err := db.Preload("User").First(&post, post.ID).Error; err != nil {
-		logrus.WithError(err).Error("获取文章详情失败")
+		logger.From(c).WithError(err).Error("获取文章详情失败")
 		utils.InternalServerErrorResponse(c, "获取文章详情失败")
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"post_id": post.ID,
 		"user_id": userID,
 		"title":   post.Title,
 	}).Info("文章创建成功")
 
-	utils.SuccessResponse(c, post.ToResponse(), "文章创建成功")
+	events.Publish(events.PostCreated, &post)
+
+	respondWithETag(c, post.ToResponse(), "文章创建成功")
 }
 
 // GetPosts 获取文章列表
 func (pc *PostController) GetPosts(c *gin.Context) {
-	db := database.GetDB()
-
 	// 获取查询参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
@@ -95,45 +123,44 @@ func (pc *PostController) GetPosts(c *gin.Context) {
 	// 计算偏移量
 	offset := (page - 1) * pageSize
 
-	var posts []models.Post
-	var total int64
+	key := fmt.Sprintf("posts:page=%d:size=%d", page, pageSize)
+	respondCached(c, pc.cache, key, []string{cache.TagPostList}, "获取文章列表成功", func(db *gorm.DB) (interface{}, error) {
+		var posts []models.Post
+		var total int64
 
-	// 查询总数
-	if err := db.Model(&models.Post{}).Where("status = ?", 1).Count(&total).Error; err != nil {
-		logrus.WithError(err).Error("查询文章总数失败")
-		utils.InternalServerErrorResponse(c, "查询文章列表失败")
-		return
-	}
-
-	// 查询文章列表（预加载用户信息）
-	if err := db.Preload("User").
-		Where("status = ?", 1).
-		Order("is_top DESC, published_at DESC").
-		Limit(pageSize).
-		Offset(offset).
-		Find(&posts).Error; err != nil {
-		logrus.WithError(err).Error("查询文章列表失败")
-		utils.InternalServerErrorResponse(c, "查询文章列表失败")
-		return
-	}
+		// 查询总数
+		if err := db.Model(&models.Post{}).Where("status = ?", 1).Count(&total).Error; err != nil {
+			logger.From(c).WithError(err).Error("查询文章总数失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询文章列表失败"}
+		}
 
-	// 转换为响应格式
-	var postResponses []models.PostResponse
-	for _, post := range posts {
-		postResponses = append(postResponses, post.ToResponse())
-	}
+		// 查询文章列表（预加载用户信息）
+		if err := db.Preload("User").
+			Where("status = ?", 1).
+			Order("is_top DESC, published_at DESC").
+			Limit(pageSize).
+			Offset(offset).
+			Find(&posts).Error; err != nil {
+			logger.From(c).WithError(err).Error("查询文章列表失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询文章列表失败"}
+		}
 
-	response := gin.H{
-		"posts": postResponses,
-		"pagination": gin.H{
-			"page":       page,
-			"page_size":  pageSize,
-			"total":      total,
-			"total_page": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
-	}
+		// 转换为响应格式
+		var postResponses []models.PostResponse
+		for _, post := range posts {
+			postResponses = append(postResponses, post.ToResponse())
+		}
 
-	utils.SuccessResponse(c, response, "获取文章列表成功")
+		return gin.H{
+			"posts": postResponses,
+			"pagination": gin.H{
+				"page":       page,
+				"page_size":  pageSize,
+				"total":      total,
+				"total_page": (total + int64(pageSize) - 1) / int64(pageSize),
+			},
+		}, nil
+	})
 }
 
 // GetPost 获取单篇文章详情
@@ -144,33 +171,84 @@ func (pc *PostController) GetPost(c *gin.Context) {
 		utils.BadRequestResponse(c, "无效的文章ID")
 		return
 	}
+	pc.respondPost(c, uint(postID))
+}
 
-	db := database.GetDB()
+// GetPostBySlug 通过 slug 获取文章详情，旧 slug 301 跳转到当前地址
+func (pc *PostController) GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	db := database.WithContext(c.Request.Context())
 	var post models.Post
 
-	// 查询文章（预加载用户信息）
-	if err := db.Preload("User").First(&post, uint(postID)).Error; err != nil {
+	err := db.Select("id").Where("slug = ?", slug).First(&post).Error
+	if err == nil {
+		pc.respondPost(c, post.ID)
+		return
+	}
+	if err != gorm.ErrRecordNotFound {
+		logger.From(c).WithError(err).Error("查询文章详情失败")
+		utils.InternalServerErrorResponse(c, "查询文章详情失败")
+		return
+	}
+
+	// 查找历史 slug
+	var history models.PostSlugHistory
+	if err := db.Where("slug = ?", slug).First(&history).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "文章不存在")
 		} else {
-			logrus.WithError(err).Error("查询文章详情失败")
+			logger.From(c).WithError(err).Error("查询文章历史slug失败")
 			utils.InternalServerErrorResponse(c, "查询文章详情失败")
 		}
 		return
 	}
 
-	// 检查文章状态
-	if post.Status != 1 {
+	if err := db.Select("id", "slug", "status").First(&post, history.PostID).Error; err != nil || post.Status != 1 {
 		utils.NotFoundResponse(c, "文章不存在")
 		return
 	}
 
-	// 增加浏览次数
-	if err := db.Model(&post).Update("view_count", gorm.Expr("view_count + ?", 1)).Error; err != nil {
-		logrus.WithError(err).Warn("更新文章浏览次数失败")
+	c.Redirect(http.StatusMovedPermanently, "/api/v1/posts/by-slug/"+post.Slug)
+}
+
+// respondPost 输出已发布文章详情并增加浏览次数
+//
+// 通过 ID 和 slug 访问共用同一个缓存条目和 ETag，If-None-Match 匹配时返回 304。
+func (pc *PostController) respondPost(c *gin.Context, id uint) {
+	key := fmt.Sprintf("post:%d", id)
+	ok := respondCached(c, pc.cache, key, []string{cache.TagPost(id)}, "获取文章详情成功", func(db *gorm.DB) (interface{}, error) {
+		var post models.Post
+
+		// 查询文章（预加载用户信息）
+		if err := db.Preload("User").First(&post, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, &apiError{http.StatusNotFound, "文章不存在"}
+			}
+			logger.From(c).WithError(err).Error("查询文章详情失败")
+			return nil, &apiError{http.StatusInternalServerError, "查询文章详情失败"}
+		}
+
+		// 检查文章状态
+		if post.Status != 1 {
+			return nil, &apiError{http.StatusNotFound, "文章不存在"}
+		}
+		return post.ToResponse(), nil
+	})
+	if ok {
+		pc.countView(c, id)
 	}
+}
 
-	utils.SuccessResponse(c, post.ToResponse(), "获取文章详情成功")
+// countView 增加浏览次数
+//
+// 浏览次数不算作文章修改，不更新 updated_at，也不失效缓存；缓存中的浏览次数在缓存过期后更新。
+func (pc *PostController) countView(c *gin.Context, postID uint) {
+	db := database.WithContext(c.Request.Context())
+	if err := db.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error; err != nil {
+		logger.From(c).WithError(err).Warn("更新文章浏览次数失败")
+	}
 }
 
 // UpdatePost 更新文章
@@ -190,12 +268,12 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 
 	var req models.UpdatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("更新文章参数绑定失败")
+		logger.From(c).WithError(err).Error("更新文章参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var post models.Post
 
 	// 查询文章
@@ -203,7 +281,7 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "文章不存在")
 		} else {
-			logrus.WithError(err).Error("查询文章失败")
+			logger.From(c).WithError(err).Error("查询文章失败")
 			utils.InternalServerErrorResponse(c, "查询文章失败")
 		}
 		return
@@ -211,7 +289,7 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 
 	// 检查权限（只有作者可以修改）
 	if post.UserID != userID {
-		logrus.WithFields(logrus.Fields{
+		logger.From(c).WithFields(logrus.Fields{
 			"post_id":      post.ID,
 			"post_user":    post.UserID,
 			"current_user": userID,
@@ -220,6 +298,17 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	// 条件更新：If-Match 中的版本号与当前版本不同，说明文章在客户端读取后已被修改
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" && config.Post.RequireIfMatch {
+		utils.ErrorResponse(c, http.StatusPreconditionRequired, "请在 If-Match 请求头中提供文章的 ETag")
+		return
+	}
+	if ifMatch != "" && !utils.VersionMatch(ifMatch, post.Version) {
+		pc.respondConflict(c, &post, http.StatusPreconditionFailed)
+		return
+	}
+
 	// 更新文章信息
 	if req.Title != "" {
 		post.Title = req.Title
@@ -231,25 +320,104 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 		post.Excerpt = req.Excerpt
 	}
 
-	if err := db.Save(&post).Error; err != nil {
-		logrus.WithError(err).Error("更新文章失败")
+	// 修改 slug 时校验唯一性
+	oldSlug := post.Slug
+	if req.Slug != "" && req.Slug != post.Slug {
+		if !utils.IsValidSlug(req.Slug) {
+			utils.BadRequestResponse(c, "slug 只能包含小写字母、数字和连字符")
+			return
+		}
+		taken, err := models.SlugTaken(db, req.Slug, post.ID)
+		if err != nil {
+			logger.From(c).WithError(err).Error("检查slug失败")
+			utils.InternalServerErrorResponse(c, "更新文章失败")
+			return
+		}
+		if taken {
+			utils.BadRequestResponse(c, "slug 已被使用")
+			return
+		}
+		post.Slug = req.Slug
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 只更新修改的字段，并以读取时的版本号为条件，期间被其他请求修改时不覆盖
+		result := tx.Model(&models.Post{}).
+			Where("id = ? AND version = ?", post.ID, post.Version).
+			Updates(map[string]interface{}{
+				"title":   post.Title,
+				"content": post.Content,
+				"excerpt": post.Excerpt,
+				"slug":    post.Slug,
+				"version": gorm.Expr("version + ?", 1),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPostConflict
+		}
+		if post.Slug == oldSlug || oldSlug == "" {
+			return nil
+		}
+
+		// 记录旧 slug 用于跳转；改回历史 slug 时移除对应记录
+		if err := tx.Where("post_id = ? AND slug = ?", post.ID, post.Slug).Delete(&models.PostSlugHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PostSlugHistory{PostID: post.ID, Slug: oldSlug}).Error
+	})
+	if errors.Is(err, errPostConflict) {
+		// 读取后被其他请求修改或删除：带 If-Match 时按条件请求返回 412，否则返回 409
+		var current models.Post
+		if err := db.Select("id", "version").First(&current, post.ID).Error; err != nil {
+			utils.NotFoundResponse(c, "文章不存在")
+			return
+		}
+		status := http.StatusConflict
+		if ifMatch != "" {
+			status = http.StatusPreconditionFailed
+		}
+		pc.respondConflict(c, &current, status)
+		return
+	}
+	if err != nil {
+		logger.From(c).WithError(err).Error("更新文章失败")
 		utils.InternalServerErrorResponse(c, "更新文章失败")
 		return
 	}
 
 	// 预加载用户信息
 	if err := db.Preload("User").First(&post, post.ID).Error; err != nil {
-		logrus.WithError(err).Error("获取更新后文章详情失败")
+		logger.From(c).WithError(err).Error("获取更新后文章详情失败")
 		utils.InternalServerErrorResponse(c, "获取文章详情失败")
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"post_id": post.ID,
 		"user_id": userID,
 	}).Info("文章更新成功")
 
-	utils.SuccessResponse(c, post.ToResponse(), "文章更新成功")
+	events.Publish(events.PostUpdated, &post)
+
+	respondWithETag(c, post.ToResponse(), "文章更新成功")
+}
+
+// errPostConflict 更新时文章已被其他请求修改
+var errPostConflict = errors.New("文章已被修改")
+
+// respondConflict 文章已被修改，返回冲突状态码和当前版本号，客户端获取最新内容后重试
+func (pc *PostController) respondConflict(c *gin.Context, post *models.Post, status int) {
+	logger.From(c).WithFields(logrus.Fields{
+		"post_id": post.ID,
+		"version": post.Version,
+	}).Warn("文章更新冲突")
+	c.JSON(status, utils.APIResponse{
+		Code:    status,
+		Message: "文章已被修改，请获取最新内容后重试",
+		Data:    gin.H{"current_version": post.Version},
+	})
 }
 
 // DeletePost 删除文章
@@ -267,7 +435,7 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var post models.Post
 
 	// 查询文章
@@ -275,7 +443,7 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "文章不存在")
 		} else {
-			logrus.WithError(err).Error("查询文章失败")
+			logger.From(c).WithError(err).Error("查询文章失败")
 			utils.InternalServerErrorResponse(c, "查询文章失败")
 		}
 		return
@@ -283,7 +451,7 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 
 	// 检查权限（只有作者可以删除）
 	if post.UserID != userID {
-		logrus.WithFields(logrus.Fields{
+		logger.From(c).WithFields(logrus.Fields{
 			"post_id":      post.ID,
 			"post_user":    post.UserID,
 			"current_user": userID,
@@ -294,15 +462,17 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 
 	// 软删除文章
 	if err := db.Delete(&post).Error; err != nil {
-		logrus.WithError(err).Error("删除文章失败")
+		logger.From(c).WithError(err).Error("删除文章失败")
 		utils.InternalServerErrorResponse(c, "删除文章失败")
 		return
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"post_id": post.ID,
 		"user_id": userID,
 	}).Info("文章删除成功")
 
+	events.Publish(events.PostDeleted, &post)
+
 	utils.SuccessResponse(c, nil, "文章删除成功")
 }
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blog/database"
+	"blog/logger"
+	"blog/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SEOController 搜索引擎相关控制器（sitemap、robots.txt）
+type SEOController struct {
+	sitemap *seo.Sitemap
+}
+
+// NewSEOController 创建SEO控制器实例
+func NewSEOController(sitemap *seo.Sitemap) *SEOController {
+	return &SEOController{sitemap: sitemap}
+}
+
+// Sitemap 输出 /sitemap.xml（文章较多时为 sitemap 索引）
+func (sc *SEOController) Sitemap(c *gin.Context) {
+	sc.serveSitemap(c, 0)
+}
+
+// SitemapPart 输出拆分后的 sitemap 分片
+func (sc *SEOController) SitemapPart(c *gin.Context) {
+	name := c.Param("name")
+	if !strings.HasPrefix(name, "sitemap-") || !strings.HasSuffix(name, ".xml") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "sitemap-"), ".xml"))
+	if err != nil || n < 1 {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	sc.serveSitemap(c, n)
+}
+
+func (sc *SEOController) serveSitemap(c *gin.Context, n int) {
+	data, ok, err := sc.sitemap.File(n)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成站点地图失败")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+// Robots 输出 /robots.txt
+func (sc *SEOController) Robots(c *gin.Context) {
+	content, err := seo.RobotsTxt()
+	if err != nil {
+		logger.From(c).WithError(err).Error("读取robots.txt失败")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.String(http.StatusOK, content)
+}
+
+// Feed 输出 /feed.xml（RSS 2.0）
+func (sc *SEOController) Feed(c *gin.Context) {
+	data, err := seo.RSSFeed(database.WithContext(c.Request.Context()))
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成RSS订阅失败")
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(http.StatusOK, "application/rss+xml; charset=utf-8", data)
+}
@@ -1,7 +1,19 @@
 package controllers
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"blog/account"
+	"blog/config"
 	"blog/database"
+	"blog/jobs"
+	"blog/logger"
+	"blog/mailer"
+	"blog/metrics"
 	"blog/middleware"
 	"blog/models"
 	"blog/utils"
@@ -25,24 +37,30 @@ func (uc *UserController) Register(c *gin.Context) {
 
 	// 绑定JSON数据到结构体
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("用户注册参数绑定失败")
+		logger.From(c).WithError(err).Error("用户注册参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	// 检查密码强度
+	if err := account.CheckPasswordPolicy(req.Password, req.Username, req.Email); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
 
 	// 检查用户名是否已存在
 	var existUser models.User
 	if err := db.Where("username = ?", req.Username).First(&existUser).Error; err == nil {
-		logrus.WithField("username", req.Username).Warn("用户名已存在")
+		logger.From(c).WithField("username", req.Username).Warn("用户名已存在")
 		utils.BadRequestResponse(c, "用户名已存在")
 		return
 	}
 
 	// 检查邮箱是否已存在
 	if err := db.Where("email = ?", req.Email).First(&existUser).Error; err == nil {
-		logrus.WithField("email", req.Email).Warn("邮箱已被注册")
+		logger.From(c).WithField("email", req.Email).Warn("邮箱已被注册")
 		utils.BadRequestResponse(c, "邮箱已被注册")
 		return
 	}
@@ -58,15 +76,21 @@ func (uc *UserController) Register(c *gin.Context) {
 
 	// 保存用户到数据库
 	if err := db.Create(&user).Error; err != nil {
-		logrus.WithError(err).Error("用户注册失败")
+		logger.From(c).WithError(err).Error("用户注册失败")
 		utils.InternalServerErrorResponse(c, "注册失败")
 		return
 	}
 
+	// 发送邮箱验证邮件，失败不影响注册，用户可以重新发送
+	locale := mailer.ResolveLocale("", c.GetHeader("Accept-Language"))
+	if err := account.SendVerification(db, &user, locale); err != nil {
+		logger.From(c).WithError(err).WithField("user_id", user.ID).Error("发送验证邮件失败")
+	}
+
 	// 生成JWT令牌
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	token, err := utils.GenerateToken(user.ID, user.Username, user.TokenVersion)
 	if err != nil {
-		logrus.WithError(err).Error("生成JWT令牌失败")
+		logger.From(c).WithError(err).Error("生成JWT令牌失败")
 		utils.InternalServerErrorResponse(c, "生成令牌失败")
 		return
 	}
@@ -77,7 +101,7 @@ func (uc *UserController) Register(c *gin.Context) {
 		User:  user.ToResponse(),
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"user_id":  user.ID,
 		"username": user.Username,
 	}).Info("用户注册成功")
@@ -91,21 +115,22 @@ func (uc *UserController) Login(c *gin.Context) {
 
 	// 绑定JSON数据到结构体
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("用户登录参数绑定失败")
+		logger.From(c).WithError(err).Error("用户登录参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 
 	// 查找用户（支持用户名或邮箱登录）
 	var user models.User
 	if err := db.Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logrus.WithField("username", req.Username).Warn("用户登录失败：用户不存在")
+			logger.From(c).WithField("username", req.Username).Warn("用户登录失败：用户不存在")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthPassword, "unknown_user").Inc()
 			utils.UnauthorizedResponse(c, "用户名或密码错误")
 		} else {
-			logrus.WithError(err).Error("查询用户失败")
+			logger.From(c).WithError(err).Error("查询用户失败")
 			utils.InternalServerErrorResponse(c, "登录失败")
 		}
 		return
@@ -113,33 +138,86 @@ func (uc *UserController) Login(c *gin.Context) {
 
 	// 检查用户状态
 	if user.Status != 1 {
-		logrus.WithField("user_id", user.ID).Warn("尝试登录被禁用的账户")
+		logger.From(c).WithField("user_id", user.ID).Warn("尝试登录被禁用的账户")
+		metrics.AuthFailures.WithLabelValues(metrics.AuthPassword, "disabled").Inc()
+		recordLogin(c, user.ID, false, "账户已被禁用")
 		utils.UnauthorizedResponse(c, "账户已被禁用")
 		return
 	}
 
 	// 验证密码
 	if !user.CheckPassword(req.Password) {
-		logrus.WithField("user_id", user.ID).Warn("用户登录失败：密码错误")
+		logger.From(c).WithField("user_id", user.ID).Warn("用户登录失败：密码错误")
+		metrics.AuthFailures.WithLabelValues(metrics.AuthPassword, "invalid_password").Inc()
+		recordLogin(c, user.ID, false, "密码错误")
 		utils.UnauthorizedResponse(c, "用户名或密码错误")
 		return
 	}
 
+	// 旧的 bcrypt 哈希在登录成功时升级为 Argon2id
+	account.UpgradePasswordHash(db, &user, req.Password)
+
+	if !emailAllowsLogin(c, &user) {
+		return
+	}
+
+	// 已启用两步验证时只返回临时令牌，验证码通过后再签发访问令牌
+	if user.MFAEnabled() {
+		beginMFAChallenge(c, &user)
+		return
+	}
+
+	completeLogin(c, &user)
+}
+
+// emailAllowsLogin 按配置禁止未验证邮箱的用户登录，拒绝时已写入响应
+func emailAllowsLogin(c *gin.Context, user *models.User) bool {
+	if !user.IsEmailVerified() && config.EmailVerification.Denies(config.ActionLogin) {
+		logger.From(c).WithField("user_id", user.ID).Warn("未验证邮箱的用户尝试登录")
+		recordLogin(c, user.ID, false, "邮箱未验证")
+		utils.ForbiddenResponse(c, "请先验证邮箱")
+		return false
+	}
+	return true
+}
+
+// beginMFAChallenge 返回两步验证临时令牌
+func beginMFAChallenge(c *gin.Context, user *models.User) {
+	mfaToken, err := utils.GenerateMFAToken(user.ID, user.Username, user.TokenVersion, config.MFA.ChallengeTTL)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成两步验证令牌失败")
+		utils.InternalServerErrorResponse(c, "生成令牌失败")
+		return
+	}
+
+	logger.From(c).WithField("user_id", user.ID).Info("首个验证因素通过，等待两步验证")
+	utils.SuccessResponse(c, models.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int(config.MFA.ChallengeTTL.Seconds()),
+	}, "请输入两步验证码")
+}
+
+// completeLogin 签发访问令牌并返回登录成功响应
+func completeLogin(c *gin.Context, user *models.User) {
 	// 生成JWT令牌
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	token, err := utils.GenerateToken(user.ID, user.Username, user.TokenVersion)
 	if err != nil {
-		logrus.WithError(err).Error("生成JWT令牌失败")
+		logger.From(c).WithError(err).Error("生成JWT令牌失败")
 		utils.InternalServerErrorResponse(c, "生成令牌失败")
 		return
 	}
 
 	// 返回登录成功响应
 	response := models.LoginResponse{
-		Token: token,
-		User:  user.ToResponse(),
+		Token:            token,
+		User:             user.ToResponse(),
+		MFASetupRequired: account.MFARequired(user) && !user.MFAEnabled(),
 	}
 
-	logrus.WithFields(logrus.Fields{
+	recordLogin(c, user.ID, true, "")
+
+	logger.From(c).WithFields(logrus.Fields{
 		"user_id":  user.ID,
 		"username": user.Username,
 	}).Info("用户登录成功")
@@ -155,14 +233,14 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var user models.User
 
 	if err := db.First(&user, userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			utils.NotFoundResponse(c, "用户不存在")
 		} else {
-			logrus.WithError(err).Error("获取用户信息失败")
+			logger.From(c).WithError(err).Error("获取用户信息失败")
 			utils.InternalServerErrorResponse(c, "获取用户信息失败")
 		}
 		return
@@ -181,12 +259,12 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("更新用户信息参数绑定失败")
+		logger.From(c).WithError(err).Error("更新用户信息参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var user models.User
 
 	if err := db.First(&user, userID).Error; err != nil {
@@ -206,12 +284,12 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 	}
 
 	if err := db.Save(&user).Error; err != nil {
-		logrus.WithError(err).Error("更新用户信息失败")
+		logger.From(c).WithError(err).Error("更新用户信息失败")
 		utils.InternalServerErrorResponse(c, "更新失败")
 		return
 	}
 
-	logrus.WithField("user_id", userID).Info("用户信息更新成功")
+	logger.From(c).WithField("user_id", userID).Info("用户信息更新成功")
 	utils.SuccessResponse(c, user.ToResponse(), "更新成功")
 }
 
@@ -225,12 +303,12 @@ func (uc *UserController) ChangePassword(c *gin.Context) {
 
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.WithError(err).Error("修改密码参数绑定失败")
+		logger.From(c).WithError(err).Error("修改密码参数绑定失败")
 		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	db := database.GetDB()
+	db := database.WithContext(c.Request.Context())
 	var user models.User
 
 	if err := db.First(&user, userID).Error; err != nil {
@@ -238,29 +316,307 @@ func (uc *UserController) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// 验证旧密码
-	if !user.CheckPassword(req.OldPassword) {
-		logrus.WithField("user_id", userID).Warn("修改密码失败：原密码错误")
-		utils.BadRequestResponse(c, "原密码错误")
+	locale := mailer.ResolveLocale("", c.GetHeader("Accept-Language"))
+	if err := account.ChangePassword(db, &user, req.OldPassword, req.NewPassword, locale); err != nil {
+		switch {
+		case errors.Is(err, account.ErrWrongPassword):
+			logger.From(c).WithField("user_id", userID).Warn("修改密码失败：原密码错误")
+			utils.BadRequestResponse(c, err.Error())
+		case errors.Is(err, account.ErrWeakPassword):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("密码修改失败")
+			utils.InternalServerErrorResponse(c, "密码修改失败")
+		}
+		return
+	}
+
+	logger.From(c).WithField("user_id", userID).Info("密码修改成功")
+	utils.SuccessResponse(c, nil, "密码修改成功，请重新登录")
+}
+
+// ForgotPassword 发送找回密码邮件
+//
+// 无论邮箱是否注册都返回相同的结果，避免泄露账号信息。
+func (uc *UserController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("找回密码参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	locale := mailer.ResolveLocale(req.Locale, c.GetHeader("Accept-Language"))
+	if err := account.RequestPasswordReset(database.WithContext(c.Request.Context()), req.Email, locale); err != nil {
+		logger.From(c).WithError(err).Error("处理找回密码请求失败")
+		utils.InternalServerErrorResponse(c, "发送失败，请稍后重试")
+		return
+	}
+
+	utils.SuccessResponse(c, nil, "如果该邮箱已注册，你将收到一封重置密码的邮件")
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("重置密码参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	// 加密新密码
-	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	locale := mailer.ResolveLocale("", c.GetHeader("Accept-Language"))
+	if _, err := account.ResetPassword(database.WithContext(c.Request.Context()), req.Token, req.NewPassword, locale); err != nil {
+		if errors.Is(err, account.ErrInvalidResetToken) || errors.Is(err, account.ErrWeakPassword) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		logger.From(c).WithError(err).Error("重置密码失败")
+		utils.InternalServerErrorResponse(c, "重置密码失败")
+		return
+	}
+
+	utils.SuccessResponse(c, nil, "密码已重置，请使用新密码登录")
+}
+
+// ResendVerification 重新发送邮箱验证邮件
+//
+// 无论邮箱是否注册都返回相同的结果，未验证邮箱时禁止登录的用户也可以使用。
+func (uc *UserController) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("重发验证邮件参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	locale := mailer.ResolveLocale(req.Locale, c.GetHeader("Accept-Language"))
+	if err := account.ResendVerification(database.WithContext(c.Request.Context()), req.Email, locale); err != nil {
+		logger.From(c).WithError(err).Error("重发验证邮件失败")
+		utils.InternalServerErrorResponse(c, "发送失败，请稍后重试")
+		return
+	}
+
+	utils.SuccessResponse(c, nil, "如果该邮箱已注册且尚未验证，你将收到一封验证邮件")
+}
+
+// VerifyEmail 确认邮件中的验证链接（注册验证和更换邮箱共用）
+func (uc *UserController) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("邮箱验证参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, user, err := account.ConfirmEmailToken(database.WithContext(c.Request.Context()), req.Token)
 	if err != nil {
-		logrus.WithError(err).Error("密码加密失败")
-		utils.InternalServerErrorResponse(c, "密码加密失败")
+		if errors.Is(err, account.ErrInvalidEmailToken) || errors.Is(err, account.ErrEmailTaken) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		logger.From(c).WithError(err).Error("邮箱验证失败")
+		utils.InternalServerErrorResponse(c, "邮箱验证失败")
 		return
 	}
 
-	// 更新密码
-	user.Password = hashedPassword
-	if err := db.Save(&user).Error; err != nil {
-		logrus.WithError(err).Error("密码修改失败")
-		utils.InternalServerErrorResponse(c, "密码修改失败")
+	messages := map[string]string{
+		account.EmailVerified:      "邮箱验证成功",
+		account.EmailChangePending: "已确认，请继续确认另一个邮箱中的链接",
+		account.EmailChanged:       "邮箱已更换",
+	}
+	utils.SuccessResponse(c, gin.H{"result": result, "user": user.ToResponse()}, messages[result])
+}
+
+// ChangeEmail 申请更换邮箱，原邮箱和新邮箱都确认后生效
+func (uc *UserController) ChangeEmail(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("更换邮箱参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		logger.From(c).WithField("user_id", userID).Warn("更换邮箱失败：密码错误")
+		utils.BadRequestResponse(c, "密码错误")
 		return
 	}
 
-	logrus.WithField("user_id", userID).Info("密码修改成功")
-	utils.SuccessResponse(c, nil, "密码修改成功")
+	locale := mailer.ResolveLocale(req.Locale, c.GetHeader("Accept-Language"))
+	if err := account.RequestEmailChange(db, &user, req.NewEmail, locale); err != nil {
+		if errors.Is(err, account.ErrEmailTaken) || errors.Is(err, account.ErrSameEmail) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		logger.From(c).WithError(err).Error("申请更换邮箱失败")
+		utils.InternalServerErrorResponse(c, "申请更换邮箱失败")
+		return
+	}
+
+	logger.From(c).WithField("user_id", userID).Info("用户申请更换邮箱")
+	utils.SuccessResponse(c, nil, "确认邮件已发送到原邮箱和新邮箱，两边都确认后生效")
+}
+
+// ExportData 创建个人数据导出任务
+func (uc *UserController) ExportData(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	job, err := jobs.Enqueue(account.JobExport, userID, struct{}{})
+	if err != nil {
+		logger.From(c).WithError(err).Error("创建导出任务失败")
+		utils.InternalServerErrorResponse(c, "创建导出任务失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"job_id":  job.ID,
+		"user_id": userID,
+	}).Info("个人数据导出任务已创建")
+
+	utils.SuccessResponse(c, job.ToResponse(), "导出任务已创建")
+}
+
+// DownloadExport 下载个人数据导出文件，参数为导出任务ID
+func (uc *UserController) DownloadExport(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "无效的任务ID")
+		return
+	}
+
+	var job models.Job
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND user_id = ? AND type = ?", jobID, userID, account.JobExport).
+		First(&job).Error; err != nil {
+		utils.NotFoundResponse(c, "导出文件不存在")
+		return
+	}
+	if job.Status != models.JobCompleted {
+		utils.BadRequestResponse(c, "导出任务尚未完成")
+		return
+	}
+
+	path := account.ExportPath(userID, job.ID)
+	if _, err := os.Stat(path); err != nil {
+		utils.NotFoundResponse(c, "导出文件不存在")
+		return
+	}
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// DeleteAccount 申请注销账号
+//
+// 立即撤销全部令牌，冷静期结束后删除账号；冷静期内重新登录可以撤销申请。
+func (uc *UserController) DeleteAccount(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("注销账号参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		logger.From(c).WithField("user_id", userID).Warn("注销账号失败：密码错误")
+		utils.BadRequestResponse(c, "密码错误")
+		return
+	}
+
+	scheduledAt, err := account.ScheduleDeletion(db, &user)
+	if err != nil {
+		logger.From(c).WithError(err).Error("注销账号失败")
+		utils.InternalServerErrorResponse(c, "注销账号失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"scheduled_at": scheduledAt,
+	}).Warn("用户申请注销账号")
+
+	utils.SuccessResponse(c, gin.H{"deletion_scheduled_at": scheduledAt}, "已申请注销账号")
+}
+
+// CancelDeletion 撤销注销申请
+func (uc *UserController) CancelDeletion(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		utils.BadRequestResponse(c, "账号未申请注销")
+		return
+	}
+
+	if err := account.CancelDeletion(db, &user); err != nil {
+		logger.From(c).WithError(err).Error("撤销注销申请失败")
+		utils.InternalServerErrorResponse(c, "撤销注销申请失败")
+		return
+	}
+
+	logger.From(c).WithField("user_id", userID).Info("用户撤销注销申请")
+	utils.SuccessResponse(c, user.ToResponse(), "已撤销注销申请")
+}
+
+// recordLogin 记录登录日志，失败不影响登录流程
+func recordLogin(c *gin.Context, userID uint, success bool, message string) {
+	log := models.LoginLog{
+		UserID:    userID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		LoginAt:   time.Now(),
+		Success:   success,
+		Message:   message,
+	}
+	if err := database.WithContext(c.Request.Context()).Create(&log).Error; err != nil {
+		logger.From(c).WithError(err).Error("记录登录日志失败")
+		return
+	}
+	// Success 带有 default:true，false 不会在创建时写入
+	if !success {
+		database.WithContext(c.Request.Context()).Model(&log).Update("success", false)
+	}
 }
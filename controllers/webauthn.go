@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"blog/account"
+	"blog/database"
+	"blog/logger"
+	"blog/metrics"
+	"blog/middleware"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// maxPasskeyBody 浏览器返回的注册或登录数据大小上限
+const maxPasskeyBody = 64 << 10
+
+// BeginPasskeyRegistration 开始注册通行密钥，需要验证密码
+func (uc *UserController) BeginPasskeyRegistration(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var req models.PasskeyRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.From(c).WithError(err).Error("注册通行密钥参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		logger.From(c).WithField("user_id", userID).Warn("注册通行密钥失败：密码错误")
+		utils.BadRequestResponse(c, "密码错误")
+		return
+	}
+
+	creation, err := account.BeginPasskeyRegistration(db, &user)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成通行密钥注册参数失败")
+		utils.InternalServerErrorResponse(c, "注册通行密钥失败")
+		return
+	}
+
+	utils.SuccessResponse(c, creation)
+}
+
+// FinishPasskeyRegistration 提交 navigator.credentials.create 的结果，name 查询参数为通行密钥名称
+func (uc *UserController) FinishPasskeyRegistration(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	name := c.Query("name")
+	if len([]rune(name)) > 100 {
+		utils.BadRequestResponse(c, "名称不能超过100个字符")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPasskeyBody))
+	if err != nil {
+		utils.BadRequestResponse(c, "读取请求失败")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		utils.NotFoundResponse(c, "用户不存在")
+		return
+	}
+
+	credential, err := account.FinishPasskeyRegistration(db, &user, name, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrPasskeySession), errors.Is(err, account.ErrPasskeyInvalid), errors.Is(err, account.ErrPasskeyExists):
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("保存通行密钥失败")
+			utils.InternalServerErrorResponse(c, "注册通行密钥失败")
+		}
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"credential": credential.ID,
+	}).Info("用户注册通行密钥")
+
+	utils.SuccessResponse(c, credential, "通行密钥注册成功")
+}
+
+// ListPasskeys 获取当前用户的通行密钥
+func (uc *UserController) ListPasskeys(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	var credentials []models.WebAuthnCredential
+	if err := database.WithContext(c.Request.Context()).Where("user_id = ?", userID).Order("id").Find(&credentials).Error; err != nil {
+		logger.From(c).WithError(err).Error("查询通行密钥失败")
+		utils.InternalServerErrorResponse(c, "获取通行密钥失败")
+		return
+	}
+
+	utils.SuccessResponse(c, credentials)
+}
+
+// DeletePasskey 删除通行密钥
+func (uc *UserController) DeletePasskey(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		utils.UnauthorizedResponse(c, "未授权访问")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "无效的通行密钥ID")
+		return
+	}
+
+	db := database.WithContext(c.Request.Context())
+	var credential models.WebAuthnCredential
+	if err := db.Where("id = ? AND user_id = ?", id, userID).First(&credential).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFoundResponse(c, "通行密钥不存在")
+		} else {
+			logger.From(c).WithError(err).Error("查询通行密钥失败")
+			utils.InternalServerErrorResponse(c, "删除通行密钥失败")
+		}
+		return
+	}
+
+	if err := db.Delete(&credential).Error; err != nil {
+		logger.From(c).WithError(err).Error("删除通行密钥失败")
+		utils.InternalServerErrorResponse(c, "删除通行密钥失败")
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":    userID,
+		"credential": credential.ID,
+	}).Info("用户删除通行密钥")
+
+	utils.SuccessResponse(c, nil, "通行密钥已删除")
+}
+
+// BeginPasskeyLogin 开始通行密钥登录，username 可选
+func (uc *UserController) BeginPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		logger.From(c).WithError(err).Error("通行密钥登录参数绑定失败")
+		utils.BadRequestResponse(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	assertion, err := account.BeginPasskeyLogin(database.WithContext(c.Request.Context()), req.Username)
+	if err != nil {
+		logger.From(c).WithError(err).Error("生成通行密钥登录参数失败")
+		utils.InternalServerErrorResponse(c, "登录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, assertion)
+}
+
+// FinishPasskeyLogin 提交 navigator.credentials.get 的结果，成功后签发与密码登录相同的令牌
+//
+// 已启用两步验证的账号，如果认证器没有验证用户（PIN、指纹等），仍需输入两步验证码。
+func (uc *UserController) FinishPasskeyLogin(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPasskeyBody))
+	if err != nil {
+		utils.BadRequestResponse(c, "读取请求失败")
+		return
+	}
+
+	result, err := account.FinishPasskeyLogin(database.WithContext(c.Request.Context()), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrPasskeySession), errors.Is(err, account.ErrPasskeyInvalid), errors.Is(err, account.ErrPasskeyCloned):
+			metrics.AuthFailures.WithLabelValues(metrics.AuthPasskey, "invalid").Inc()
+			utils.UnauthorizedResponse(c, err.Error())
+		default:
+			logger.From(c).WithError(err).Error("通行密钥登录失败")
+			utils.InternalServerErrorResponse(c, "登录失败")
+		}
+		return
+	}
+
+	user := result.User
+	if user.Status != 1 {
+		logger.From(c).WithField("user_id", user.ID).Warn("尝试登录被禁用的账户")
+		metrics.AuthFailures.WithLabelValues(metrics.AuthPasskey, "disabled").Inc()
+		recordLogin(c, user.ID, false, "账户已被禁用")
+		utils.UnauthorizedResponse(c, "账户已被禁用")
+		return
+	}
+	if !emailAllowsLogin(c, user) {
+		return
+	}
+
+	if user.MFAEnabled() && !result.UserVerified {
+		beginMFAChallenge(c, user)
+		return
+	}
+
+	logger.From(c).WithFields(logrus.Fields{
+		"user_id":    user.ID,
+		"credential": result.Credential.ID,
+	}).Info("用户使用通行密钥登录")
+
+	completeLogin(c, user)
+}
@@ -1,46 +1,203 @@
 package database
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"blog/config"
+	"blog/logger"
 	"blog/models"
+	"blog/tracing"
+	"blog/utils"
 
+	"github.com/glebarez/sqlite"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var db *gorm.DB
 
+// migrationModels 自动迁移的全部模型
+var migrationModels = []interface{}{
+	&models.User{},
+	&models.Post{},
+	&models.Category{},
+	&models.Tag{},
+	&models.Comment{},
+	&models.PostSlugHistory{},
+	&models.Job{},
+	&models.LoginLog{},
+	&models.PasswordResetToken{},
+	&models.EmailToken{},
+	&models.RecoveryCode{},
+	&models.WebAuthnCredential{},
+	&models.WebAuthnSession{},
+	&models.UserIdentity{},
+	&models.OAuthState{},
+	&models.OAuthClient{},
+	&models.OAuthAuthorizationCode{},
+	&models.OAuthConsent{},
+	&models.OAuthToken{},
+	&models.APIKey{},
+	&models.ChallengeRedemption{},
+}
+
+// migrated 已确认全部表都存在
+var migrated atomic.Bool
+
 // InitDB 初始化数据库连接
 func InitDB() {
-	// 数据库连接配置
-	dsn := "root:password@tcp(localhost:3306)/blog?charset=utf8mb4&parseTime=True&loc=Local"
-
 	var err error
-	db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	db, err = Open(config.Database.Driver, config.Database.ConnString())
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logrus.WithError(err).Fatal("连接数据库失败")
 	}
 
+	// 为已有文章补齐 slug，需在创建唯一索引之前完成
+	if err := backfillPostSlugs(db); err != nil {
+		logrus.WithError(err).Fatal("补齐文章 slug 失败")
+	}
+
+	// 升级前注册的用户视为已验证邮箱，避免被未验证限制拦截
+	grandfatherEmails := db.Migrator().HasTable(&models.User{}) && !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+
 	// 自动迁移数据库结构
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Category{},
-		&models.Tag{},
-		&models.Comment{},
-	)
+	err = db.AutoMigrate(migrationModels...)
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		logrus.WithError(err).Fatal("数据库迁移失败")
+	}
+
+	if grandfatherEmails {
+		if err := db.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL").Error; err != nil {
+			logrus.WithError(err).Fatal("标记已有用户邮箱为已验证失败")
+		}
+	}
+
+	migrated.Store(true)
+	logrus.Info("数据库连接和迁移完成")
+}
+
+// Open 按驱动名称打开数据库连接
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case config.DriverMySQL:
+		dialector = mysql.Open(dsn)
+	case config.DriverSQLite:
+		if dir := filepath.Dir(dsn); dir != "." && !strings.HasPrefix(dsn, "file:") {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, err
+			}
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
 	}
 
-	log.Println("Database connected and migrated successfully")
+	conn, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.NewGormLogger(200 * time.Millisecond),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	return conn, nil
 }
 
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return db
 }
+
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context) error {
+	if db == nil {
+		return errors.New("数据库未初始化")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations 检查全部模型的表都已创建，结果通过后不再重复检查
+func CheckMigrations(ctx context.Context) error {
+	if migrated.Load() {
+		return nil
+	}
+	if db == nil {
+		return errors.New("数据库未初始化")
+	}
+	migrator := db.WithContext(ctx).Migrator()
+	for _, model := range migrationModels {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("数据表 %T 不存在", model)
+		}
+	}
+	migrated.Store(true)
+	return nil
+}
+
+// Close 关闭数据库连接
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// WithContext 获取绑定了 ctx 的数据库实例，处理请求时传入请求的 context，
+// 数据库操作的日志和追踪才能关联到该请求
+func WithContext(ctx context.Context) *gorm.DB {
+	return db.WithContext(ctx)
+}
+
+// backfillPostSlugs 为升级前创建的文章生成 slug
+func backfillPostSlugs(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Post{}) {
+		return nil
+	}
+	if !migrator.HasTable(&models.PostSlugHistory{}) {
+		if err := migrator.CreateTable(&models.PostSlugHistory{}); err != nil {
+			return err
+		}
+	}
+	if !migrator.HasColumn(&models.Post{}, "Slug") {
+		if err := migrator.AddColumn(&models.Post{}, "Slug"); err != nil {
+			return err
+		}
+	}
+
+	var posts []models.Post
+	if err := db.Unscoped().Where("slug IS NULL OR slug = ''").Find(&posts).Error; err != nil {
+		return err
+	}
+	for _, post := range posts {
+		slug, err := models.UniqueSlug(db, utils.Slugify(post.Title), post.ID)
+		if err != nil {
+			return err
+		}
+		if err := db.Unscoped().Model(&post).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	if len(posts) > 0 {
+		logrus.WithField("posts", len(posts)).Info("已为文章补齐 slug")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 事件主题
+const (
+	PostCreated = "post.created" // 文章创建
+	PostUpdated = "post.updated" // 文章更新
+	PostDeleted = "post.deleted" // 文章删除
+
+	CommentCreated = "comment.created" // 评论发表
+	CommentDeleted = "comment.deleted" // 评论删除
+	CommentUpdated = "comment.updated" // 评论修改（如作者注销后转移给占位账号）
+)
+
+// Handler 事件处理函数
+type Handler func(payload interface{})
+
+var (
+	mu       sync.RWMutex
+	handlers = make(map[string][]Handler)
+)
+
+// Subscribe 订阅事件
+func Subscribe(topic string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[topic] = append(handlers[topic], handler)
+}
+
+// Publish 同步发布事件，单个处理函数的 panic 不会影响其他订阅者
+func Publish(topic string, payload interface{}) {
+	mu.RLock()
+	subscribers := append([]Handler(nil), handlers[topic]...)
+	mu.RUnlock()
+
+	for _, handler := range subscribers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logrus.WithFields(logrus.Fields{
+						"topic": topic,
+						"panic": r,
+					}).Error("事件处理失败")
+				}
+			}()
+			handler(payload)
+		}()
+	}
+}
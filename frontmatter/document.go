@@ -0,0 +1,118 @@
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的 front matter 格式
+const (
+	FormatYAML = "yaml" // --- 分隔（Hugo、Jekyll）
+	FormatTOML = "toml" // +++ 分隔（Hugo）
+)
+
+// ErrNoFrontMatter 文件不以 front matter 开头
+var ErrNoFrontMatter = errors.New("文件缺少 front matter")
+
+// Meta 文章 front matter 中与 Post 对应的字段
+type Meta struct {
+	Title       string
+	Slug        string
+	Author      string
+	Tags        []string
+	Category    string
+	Draft       bool
+	PublishedAt *time.Time
+	UpdatedAt   *time.Time
+	Excerpt     string
+	Summary     string
+}
+
+// Document Markdown 文档：front matter 加正文
+type Document struct {
+	Meta   Meta
+	Body   string
+	Format string
+}
+
+// Parse 解析带 front matter 的 Markdown 文档
+func Parse(data []byte) (*Document, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var delimiter, format string
+	switch {
+	case strings.HasPrefix(text, "---\n"):
+		delimiter, format = "---", FormatYAML
+	case strings.HasPrefix(text, "+++\n"):
+		delimiter, format = "+++", FormatTOML
+	default:
+		return nil, ErrNoFrontMatter
+	}
+
+	rest := text[len(delimiter)+1:]
+	end := strings.Index(rest, "\n"+delimiter)
+	if end < 0 {
+		return nil, fmt.Errorf("front matter 缺少结束分隔符 %s", delimiter)
+	}
+	header := rest[:end]
+	body := rest[end+len(delimiter)+1:]
+
+	raw := make(map[string]interface{})
+	var err error
+	if format == FormatYAML {
+		err = yaml.Unmarshal([]byte(header), &raw)
+	} else {
+		err = toml.Unmarshal([]byte(header), &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s front matter 失败: %w", format, err)
+	}
+
+	meta, err := metaFromMap(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Meta: meta, Body: normalizeBody(body), Format: format}, nil
+}
+
+// Render 输出带 front matter 的 Markdown 文档
+func (d *Document) Render() ([]byte, error) {
+	fields := d.Meta.toMap()
+
+	var buf bytes.Buffer
+	switch d.Format {
+	case FormatTOML:
+		header, err := toml.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("+++\n")
+		buf.Write(header)
+		buf.WriteString("+++\n")
+	default:
+		header, err := yaml.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(header)
+		buf.WriteString("---\n")
+	}
+
+	buf.WriteString("\n")
+	buf.WriteString(d.Body)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// normalizeBody 去除正文首尾空行，保证导出后再导入内容不变
+func normalizeBody(body string) string {
+	return strings.TrimRight(strings.TrimLeft(body, "\n"), " \t\n")
+}
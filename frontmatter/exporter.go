@@ -0,0 +1,130 @@
+package frontmatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxArchiveFileSize zip 中单个 Markdown 文件的最大体积
+	maxArchiveFileSize = 10 << 20
+	// MaxArchiveEntries zip 中的最大条目数
+	MaxArchiveEntries = 5000
+	// MaxArchiveSize zip 解压后的最大总体积，防止高压缩比的小文件耗尽内存
+	MaxArchiveSize = 64 << 20
+)
+
+// Export 将文章导出为带 front matter 的 Markdown 文件，userID 为 0 时导出全部文章
+func Export(db *gorm.DB, format string, userID uint) ([]File, error) {
+	if format != FormatYAML && format != FormatTOML {
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+
+	query := db.Preload("User").Preload("Category").Preload("Tags").Order("id")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(posts))
+	for i := range posts {
+		data, err := ToDocument(&posts[i], format).Render()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: posts[i].Slug + ".md", Data: data})
+	}
+	return files, nil
+}
+
+// ToDocument 将文章转换为 Markdown 文档
+func ToDocument(post *models.Post, format string) *Document {
+	updatedAt := post.UpdatedAt
+	meta := Meta{
+		Title:       post.Title,
+		Slug:        post.Slug,
+		Author:      post.User.Username,
+		Draft:       post.Status != 1,
+		PublishedAt: post.PublishedAt,
+		UpdatedAt:   &updatedAt,
+		Excerpt:     post.Excerpt,
+		Summary:     post.Summary,
+	}
+	if post.Category != nil {
+		meta.Category = post.Category.Name
+	}
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	return &Document{Meta: meta, Body: normalizeBody(post.Content), Format: format}
+}
+
+// WriteZip 将文件打包为 zip
+func WriteZip(w io.Writer, files []File) error {
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.Create(file.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(file.Data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// ReadZip 读取 zip 中的全部文件
+//
+// 条目数超过 MaxArchiveEntries 或解压后总体积超过 MaxArchiveSize 时拒绝整个压缩包，
+// 解压体积按实际读出的字节数计算，不信任 zip 头中声明的大小。
+func ReadZip(data []byte) ([]File, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(archive.File) > MaxArchiveEntries {
+		return nil, fmt.Errorf("文件数超过 %d 个", MaxArchiveEntries)
+	}
+
+	var files []File
+	total := 0
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") {
+			continue
+		}
+		limit := maxArchiveFileSize
+		if remaining := MaxArchiveSize - total; remaining < limit {
+			limit = remaining
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, int64(limit)+1))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > limit {
+			if limit < maxArchiveFileSize {
+				return nil, fmt.Errorf("解压后总体积超过 %d MB", MaxArchiveSize>>20)
+			}
+			return nil, fmt.Errorf("文件过大: %s", entry.Name)
+		}
+		total += len(content)
+		files = append(files, File{Name: entry.Name, Data: content})
+	}
+	return files, nil
+}
@@ -0,0 +1,401 @@
+package frontmatter
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"blog/events"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 导入结果
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionFailed    = "failed"
+)
+
+// File 待导入或导出的文件
+type File struct {
+	Name string
+	Data []byte
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	DryRun          bool // 只生成报告，不写入数据库
+	AuthorID        uint // 非零时所有文章归属该用户，且只能更新该用户的文章（API 导入）
+	DefaultAuthorID uint // front matter 未指定作者或作者不存在时使用
+}
+
+// ReportItem 单个文件的导入结果
+type ReportItem struct {
+	File    string   `json:"file"`
+	Slug    string   `json:"slug,omitempty"`
+	Title   string   `json:"title,omitempty"`
+	Action  string   `json:"action"`
+	Changes []string `json:"changes,omitempty"` // 更新时变化的字段
+	Message string   `json:"message,omitempty"`
+}
+
+// Report 导入报告
+type Report struct {
+	DryRun    bool         `json:"dry_run"`
+	Created   int          `json:"created"`
+	Updated   int          `json:"updated"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+	Items     []ReportItem `json:"items"`
+}
+
+// Importer Markdown 导入器
+//
+// 文章以 slug 为唯一标识：slug 已存在时更新，内容一致时不做修改，
+// 因此重复导入同一批文件是幂等的。
+type Importer struct {
+	db   *gorm.DB
+	opts ImportOptions
+}
+
+// NewImporter 创建导入器
+func NewImporter(db *gorm.DB, opts ImportOptions) *Importer {
+	return &Importer{db: db, opts: opts}
+}
+
+var errDryRun = errors.New("dry run")
+
+// Import 导入一批文件，单个文件失败不影响其他文件
+func (im *Importer) Import(files []File) *Report {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	report := &Report{DryRun: im.opts.DryRun}
+	for _, file := range files {
+		if skipFile(file.Name) {
+			continue
+		}
+
+		item, post := im.importFile(file)
+		switch item.Action {
+		case ActionCreated:
+			report.Created++
+		case ActionUpdated:
+			report.Updated++
+		case ActionUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+
+		if post != nil && !im.opts.DryRun {
+			if item.Action == ActionCreated {
+				events.Publish(events.PostCreated, post)
+			} else if item.Action == ActionUpdated {
+				events.Publish(events.PostUpdated, post)
+			}
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dry_run":   report.DryRun,
+		"created":   report.Created,
+		"updated":   report.Updated,
+		"unchanged": report.Unchanged,
+		"failed":    report.Failed,
+	}).Info("Markdown导入完成")
+	return report
+}
+
+func (im *Importer) importFile(file File) (ReportItem, *models.Post) {
+	item := ReportItem{File: file.Name}
+	fail := func(msg string) (ReportItem, *models.Post) {
+		item.Action = ActionFailed
+		item.Message = msg
+		return item, nil
+	}
+
+	doc, err := Parse(file.Data)
+	if err != nil {
+		return fail(err.Error())
+	}
+	item.Title = doc.Meta.Title
+
+	item.Slug = doc.Meta.Slug
+	if item.Slug == "" {
+		item.Slug = slugFromFileName(file.Name)
+	}
+	if !utils.IsValidSlug(item.Slug) {
+		item.Slug = utils.Slugify(item.Slug)
+	}
+
+	var post models.Post
+	err = im.db.Transaction(func(tx *gorm.DB) error {
+		action, changes, err := im.apply(tx, doc, item.Slug, &post)
+		if err != nil {
+			return err
+		}
+		item.Action, item.Changes = action, changes
+		if im.opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return fail(err.Error())
+	}
+	return item, &post
+}
+
+// apply 在事务中创建或更新文章
+func (im *Importer) apply(tx *gorm.DB, doc *Document, slug string, post *models.Post) (string, []string, error) {
+	err := tx.Preload("Category").Preload("Tags").Where("slug = ?", slug).First(post).Error
+	exists := err == nil
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", nil, err
+	}
+
+	if !exists {
+		taken, err := models.SlugTaken(tx, slug, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		if taken {
+			return "", nil, errors.New("slug 已被其他文章占用（已删除的文章或历史 slug）")
+		}
+	}
+
+	authorID, err := im.resolveAuthor(tx, doc.Meta.Author)
+	if err != nil {
+		return "", nil, err
+	}
+	if exists && im.opts.AuthorID != 0 && post.UserID != im.opts.AuthorID {
+		return "", nil, errors.New("slug 对应的文章属于其他作者")
+	}
+
+	category, err := findOrCreateCategory(tx, doc.Meta.Category)
+	if err != nil {
+		return "", nil, err
+	}
+	tags, err := findOrCreateTags(tx, doc.Meta.Tags)
+	if err != nil {
+		return "", nil, err
+	}
+
+	status := 1
+	if doc.Meta.Draft {
+		status = 0
+	}
+	publishedAt := doc.Meta.PublishedAt
+	if publishedAt == nil && exists {
+		publishedAt = post.PublishedAt
+	}
+	if publishedAt == nil && status == 1 {
+		now := time.Now()
+		publishedAt = &now
+	}
+
+	var categoryID *uint
+	if category != nil {
+		categoryID = &category.ID
+	}
+
+	if !exists {
+		*post = models.Post{
+			Title:       doc.Meta.Title,
+			Slug:        slug,
+			Content:     doc.Body,
+			Excerpt:     doc.Meta.Excerpt,
+			Summary:     doc.Meta.Summary,
+			Status:      status,
+			UserID:      authorID,
+			CategoryID:  categoryID,
+			Tags:        tags,
+			PublishedAt: publishedAt,
+		}
+		if err := tx.Create(post).Error; err != nil {
+			return "", nil, err
+		}
+		// status 列默认值为 1，零值不会写入，草稿需单独更新
+		if status == 0 {
+			if err := tx.Model(post).Update("status", 0).Error; err != nil {
+				return "", nil, err
+			}
+		}
+		return ActionCreated, nil, nil
+	}
+
+	changes := diffPost(post, doc, status, publishedAt, categoryID, tags)
+	if authorID != post.UserID && doc.Meta.Author != "" {
+		changes = append(changes, "author")
+		post.UserID = authorID
+	}
+	if len(changes) == 0 {
+		return ActionUnchanged, nil, nil
+	}
+
+	post.Title = doc.Meta.Title
+	post.Content = doc.Body
+	post.Excerpt = doc.Meta.Excerpt
+	post.Summary = doc.Meta.Summary
+	post.Status = status
+	post.PublishedAt = publishedAt
+	post.CategoryID = categoryID
+	post.Category = category
+	// 版本号单独原子加 1，与编辑器的条件更新互斥
+	if err := tx.Omit(clause.Associations, "Version").Save(post).Error; err != nil {
+		return "", nil, err
+	}
+	if err := tx.Model(post).UpdateColumn("version", gorm.Expr("version + ?", 1)).Error; err != nil {
+		return "", nil, err
+	}
+	if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
+		return "", nil, err
+	}
+	return ActionUpdated, changes, nil
+}
+
+// resolveAuthor 按用户名或邮箱匹配作者
+func (im *Importer) resolveAuthor(tx *gorm.DB, author string) (uint, error) {
+	if im.opts.AuthorID != 0 {
+		return im.opts.AuthorID, nil
+	}
+
+	if author != "" {
+		var user models.User
+		err := tx.Where("username = ? OR email = ?", author, author).First(&user).Error
+		if err == nil {
+			return user.ID, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return 0, err
+		}
+	}
+
+	if im.opts.DefaultAuthorID != 0 {
+		return im.opts.DefaultAuthorID, nil
+	}
+	if author != "" {
+		return 0, errors.New("作者不存在: " + author)
+	}
+	return 0, errors.New("未指定作者，且没有设置默认作者")
+}
+
+// diffPost 比较文章与文档，返回变化的字段
+func diffPost(post *models.Post, doc *Document, status int, publishedAt *time.Time, categoryID *uint, tags []models.Tag) []string {
+	var changes []string
+	if post.Title != doc.Meta.Title {
+		changes = append(changes, "title")
+	}
+	if normalizeBody(post.Content) != doc.Body {
+		changes = append(changes, "content")
+	}
+	if post.Excerpt != doc.Meta.Excerpt {
+		changes = append(changes, "excerpt")
+	}
+	if post.Summary != doc.Meta.Summary {
+		changes = append(changes, "summary")
+	}
+	if post.Status != status {
+		changes = append(changes, "status")
+	}
+	if !sameTime(post.PublishedAt, publishedAt) {
+		changes = append(changes, "published_at")
+	}
+	if !sameID(post.CategoryID, categoryID) {
+		changes = append(changes, "category")
+	}
+	if !sameTags(post.Tags, tags) {
+		changes = append(changes, "tags")
+	}
+	return changes
+}
+
+func findOrCreateCategory(tx *gorm.DB, name string) (*models.Category, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var category models.Category
+	if err := tx.Where(models.Category{Name: name}).FirstOrCreate(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var tag models.Tag
+		if err := tx.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	// 数据库时间精度可能低于纳秒，按秒比较
+	return a.Unix() == b.Unix()
+}
+
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTags(a, b []models.Tag) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[uint]bool, len(a))
+	for _, tag := range a {
+		ids[tag.ID] = true
+	}
+	for _, tag := range b {
+		if !ids[tag.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+var jekyllDatePrefix = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}-`)
+
+// slugFromFileName 根据文件名推导 slug：
+// Hugo 页面包 posts/hello/index.md -> hello，Jekyll 2024-01-02-hello.md -> hello
+func slugFromFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if base == "index" {
+		base = path.Base(path.Dir(name))
+	}
+	return jekyllDatePrefix.ReplaceAllString(base, "")
+}
+
+// skipFile 跳过非 Markdown 文件和 Hugo 的列表页（_index.md）
+func skipFile(name string) bool {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	ext := strings.ToLower(path.Ext(base))
+	return strings.HasPrefix(base, "_") || (ext != ".md" && ext != ".markdown")
+}
@@ -0,0 +1,175 @@
+package frontmatter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts front matter 中常见的日期格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// metaFromMap 将 YAML/TOML 解析结果映射为 Meta，兼容 Hugo 与 Jekyll 的常见字段
+func metaFromMap(raw map[string]interface{}) (Meta, error) {
+	var meta Meta
+	var err error
+
+	meta.Title = stringValue(raw["title"])
+	meta.Slug = stringValue(raw["slug"])
+	meta.Excerpt = firstString(raw, "excerpt", "description")
+	meta.Summary = stringValue(raw["summary"])
+
+	// Hugo: authors = ["a"]；Jekyll: author: a
+	if authors := listValue(raw["authors"]); len(authors) > 0 {
+		meta.Author = authors[0]
+	} else {
+		meta.Author = stringValue(raw["author"])
+	}
+
+	// Jekyll 允许以空格分隔的字符串
+	if tags, ok := raw["tags"].(string); ok {
+		meta.Tags = strings.Fields(tags)
+	} else {
+		meta.Tags = listValue(raw["tags"])
+	}
+
+	// Post 只有一个分类，取第一个
+	if categories := listValue(raw["categories"]); len(categories) > 0 {
+		meta.Category = categories[0]
+	} else {
+		meta.Category = stringValue(raw["category"])
+	}
+
+	// Hugo: draft = true；Jekyll: published: false；也接受 status: draft/published
+	if draft, ok := raw["draft"].(bool); ok {
+		meta.Draft = draft
+	}
+	if published, ok := raw["published"].(bool); ok {
+		meta.Draft = !published
+	}
+	switch strings.ToLower(stringValue(raw["status"])) {
+	case "draft", "0":
+		meta.Draft = true
+	case "published", "1":
+		meta.Draft = false
+	}
+
+	if meta.PublishedAt, err = timeValue(raw, "date", "published_at", "publishDate"); err != nil {
+		return meta, err
+	}
+	if meta.UpdatedAt, err = timeValue(raw, "lastmod", "updated_at", "last_modified_at"); err != nil {
+		return meta, err
+	}
+
+	if meta.Title == "" {
+		return meta, fmt.Errorf("front matter 缺少 title")
+	}
+	return meta, nil
+}
+
+// toMap 导出为 Hugo 风格的 front matter 字段
+func (m Meta) toMap() map[string]interface{} {
+	fields := map[string]interface{}{
+		"title": m.Title,
+		"slug":  m.Slug,
+		"draft": m.Draft,
+	}
+	if m.Author != "" {
+		fields["author"] = m.Author
+	}
+	if len(m.Tags) > 0 {
+		fields["tags"] = m.Tags
+	}
+	if m.Category != "" {
+		fields["categories"] = []string{m.Category}
+	}
+	if m.PublishedAt != nil {
+		fields["date"] = m.PublishedAt.Format(time.RFC3339)
+	}
+	if m.UpdatedAt != nil {
+		fields["lastmod"] = m.UpdatedAt.Format(time.RFC3339)
+	}
+	if m.Excerpt != "" {
+		fields["excerpt"] = m.Excerpt
+	}
+	if m.Summary != "" {
+		fields["summary"] = m.Summary
+	}
+	return fields
+}
+
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	default:
+		return strings.TrimSpace(fmt.Sprint(value))
+	}
+}
+
+func firstString(raw map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value := stringValue(raw[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func listValue(v interface{}) []string {
+	switch value := v.(type) {
+	case []interface{}:
+		var list []string
+		for _, item := range value {
+			if s := stringValue(item); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		if value = strings.TrimSpace(value); value != "" {
+			return []string{value}
+		}
+	}
+	return nil
+}
+
+func timeValue(raw map[string]interface{}, keys ...string) (*time.Time, error) {
+	for _, key := range keys {
+		switch value := raw[key].(type) {
+		case nil:
+			continue
+		case time.Time:
+			return &value, nil
+		case string:
+			if value == "" {
+				continue
+			}
+			for _, layout := range dateLayouts {
+				if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+					return &t, nil
+				}
+			}
+			return nil, fmt.Errorf("无法解析日期 %s: %q", key, value)
+		default:
+			// TOML 的本地日期/时间类型
+			if s, ok := value.(fmt.Stringer); ok {
+				for _, layout := range dateLayouts {
+					if t, err := time.ParseInLocation(layout, s.String(), time.Local); err == nil {
+						return &t, nil
+					}
+				}
+			}
+			return nil, fmt.Errorf("无法解析日期 %s: %v", key, value)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,301 @@
+// Package identity 实现第三方账号登录（OAuth2 授权码 + PKCE，支持 OIDC）
+//
+// 只负责与提供方交互并返回第三方账号信息，账号绑定和注册由 account 包处理。
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"blog/config"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// requestTimeout 与提供方交互的超时时间
+const requestTimeout = 10 * time.Second
+
+// ErrUnknownProvider 提供方不存在或未启用
+var ErrUnknownProvider = errors.New("不支持的登录方式")
+
+// Profile 第三方账号信息
+type Profile struct {
+	Subject       string // 提供方的用户唯一标识
+	Email         string
+	EmailVerified bool // 提供方确认邮箱属于该用户
+	Name          string
+	Username      string
+	Avatar        string
+}
+
+// Client 单个提供方的客户端
+type Client struct {
+	cfg config.OAuthProvider
+
+	mu       sync.Mutex
+	provider *oidc.Provider // OIDC 发现结果，首次使用时获取
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*Client)
+)
+
+// Lookup 按名称获取已启用的提供方客户端
+func Lookup(name string) (*Client, error) {
+	cfg, ok := config.OAuth.Provider(name)
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	client, ok := clients[name]
+	if !ok {
+		client = &Client{cfg: cfg}
+		clients[name] = client
+	}
+	return client, nil
+}
+
+// Name 提供方标识
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+// AuthCodeURL 生成跳转到提供方的授权地址
+func (c *Client) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+
+	oc, err := c.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(verifier)}
+	if c.cfg.Issuer != "" {
+		opts = append(opts, oidc.Nonce(nonce))
+	}
+	return oc.AuthCodeURL(state, opts...), nil
+}
+
+// Exchange 用授权码换取令牌并获取第三方账号信息
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (*Profile, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+
+	oc, err := c.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oc.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %w", err)
+	}
+
+	if c.cfg.Issuer != "" {
+		return c.oidcProfile(ctx, token, nonce)
+	}
+	return c.userInfoProfile(ctx, oc.Client(ctx, token))
+}
+
+// context 设置超时，并让 oauth2 和 go-oidc 使用带超时的 HTTP 客户端
+func (c *Client) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	httpClient := &http.Client{Timeout: requestTimeout}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	ctx = oidc.ClientContext(ctx, httpClient)
+	return context.WithTimeout(ctx, requestTimeout)
+}
+
+func (c *Client) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	endpoint := oauth2.Endpoint{AuthURL: c.cfg.AuthURL, TokenURL: c.cfg.TokenURL}
+	if c.cfg.Issuer != "" {
+		provider, err := c.discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = provider.Endpoint()
+	}
+	return &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  config.OAuth.RedirectURL,
+		Scopes:       c.cfg.Scopes,
+	}, nil
+}
+
+// discover 获取 OIDC 发现文档，成功后缓存，失败时下次重试
+func (c *Client) discover(ctx context.Context) (*oidc.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.provider != nil {
+		return c.provider, nil
+	}
+	provider, err := oidc.NewProvider(ctx, c.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("获取 OIDC 配置失败: %w", err)
+	}
+	c.provider = provider
+	return provider, nil
+}
+
+// oidcClaims ID Token 和 UserInfo 中使用的字段
+type oidcClaims struct {
+	Subject           string   `json:"sub"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Picture           string   `json:"picture"`
+}
+
+// oidcProfile 校验 ID Token（签名、签发方、受众、过期时间和 nonce）并读取账号信息
+func (c *Client) oidcProfile(ctx context.Context, token *oauth2.Token, nonce string) (*Profile, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("提供方没有返回 ID Token")
+	}
+
+	provider, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: c.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("ID Token nonce 不匹配")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	// 部分提供方只在 UserInfo 中返回邮箱
+	if claims.Email == "" && provider.UserInfoEndpoint() != "" {
+		info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+		if err != nil {
+			return nil, fmt.Errorf("获取用户信息失败: %w", err)
+		}
+		var extra oidcClaims
+		if err := info.Claims(&extra); err != nil {
+			return nil, err
+		}
+		if extra.Subject == claims.Subject {
+			claims.Email, claims.EmailVerified = extra.Email, extra.EmailVerified
+			if claims.Name == "" {
+				claims.Name = extra.Name
+			}
+			if claims.PreferredUsername == "" {
+				claims.PreferredUsername = extra.PreferredUsername
+			}
+		}
+	}
+
+	return &Profile{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified) && claims.Email != "",
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+		Avatar:        claims.Picture,
+	}, nil
+}
+
+// userInfoProfile 从 OAuth2 用户信息端点读取账号信息，兼容 OIDC 和 GitHub 的字段名
+func (c *Client) userInfoProfile(ctx context.Context, client *http.Client) (*Profile, error) {
+	var info struct {
+		oidcClaims
+		ID        json.Number `json:"id"`
+		Login     string      `json:"login"`
+		AvatarURL string      `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, c.cfg.UserInfoURL, &info); err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+
+	profile := &Profile{
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: bool(info.EmailVerified) && info.Email != "",
+		Name:          info.Name,
+		Username:      info.PreferredUsername,
+		Avatar:        info.Picture,
+	}
+	if profile.Subject == "" {
+		profile.Subject = info.ID.String()
+	}
+	if profile.Username == "" {
+		profile.Username = info.Login
+	}
+	if profile.Avatar == "" {
+		profile.Avatar = info.AvatarURL
+	}
+	if profile.Subject == "" {
+		return nil, errors.New("用户信息缺少唯一标识")
+	}
+
+	// GitHub 的公开邮箱未必经过验证，以邮箱列表中已验证的主邮箱为准
+	if c.cfg.EmailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := getJSON(ctx, client, c.cfg.EmailsURL, &emails); err != nil {
+			return nil, fmt.Errorf("获取邮箱列表失败: %w", err)
+		}
+		profile.Email, profile.EmailVerified = "", false
+		for _, email := range emails {
+			if email.Verified && (email.Primary || profile.Email == "") {
+				profile.Email, profile.EmailVerified = email.Email, true
+			}
+		}
+	}
+	return profile, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回 %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// flexBool 兼容以字符串 "true" 表示的布尔值（部分提供方的 email_verified）
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, _ := strconv.ParseBool(s)
+		*b = flexBool(v)
+		return nil
+	}
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	*b = flexBool(v)
+	return nil
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+// Context 任务执行上下文
+type Context struct {
+	ctx context.Context
+	db  *gorm.DB
+	Job *models.Job
+}
+
+// Context 返回执行器的 context，服务停止时取消
+func (jc *Context) Context() context.Context {
+	return jc.ctx
+}
+
+// Done 服务停止时关闭
+func (jc *Context) Done() <-chan struct{} {
+	return jc.ctx.Done()
+}
+
+// Err 服务停止后返回非 nil
+func (jc *Context) Err() error {
+	return jc.ctx.Err()
+}
+
+// DB 数据库实例
+func (jc *Context) DB() *gorm.DB {
+	return jc.db
+}
+
+// Payload 解析任务参数
+func (jc *Context) Payload(v interface{}) error {
+	return json.Unmarshal([]byte(jc.Job.Payload), v)
+}
+
+// State 解析上次保存的断点，没有断点时不修改 v
+func (jc *Context) State(v interface{}) error {
+	if jc.Job.State == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jc.Job.State), v)
+}
+
+// SetTotal 设置任务总量
+func (jc *Context) SetTotal(total int) error {
+	jc.Job.Total = total
+	return jc.db.Model(jc.Job).Update("total", total).Error
+}
+
+// Checkpoint 保存断点和进度
+//
+// tx 非空时在该事务中保存，使断点与业务数据同时提交，恢复执行时不会重复处理。
+func (jc *Context) Checkpoint(tx *gorm.DB, state interface{}, processed int) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		tx = jc.db
+	}
+	if err := tx.Model(jc.Job).Updates(map[string]interface{}{
+		"state":     string(data),
+		"processed": processed,
+	}).Error; err != nil {
+		return err
+	}
+	jc.Job.State = string(data)
+	jc.Job.Processed = processed
+	return nil
+}
+
+// SetResult 保存任务结果
+func (jc *Context) SetResult(result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	jc.Job.Result = string(data)
+	return jc.db.Model(jc.Job).Update("result", string(data)).Error
+}
@@ -0,0 +1,291 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"blog/metrics"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Handler 任务处理函数
+//
+// 处理函数应定期调用 Context.Checkpoint 保存断点，并在 Context.Done 关闭时尽快返回；
+// 服务重启后任务会以保存的断点重新调用处理函数。
+type Handler func(jc *Context) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]Handler)
+)
+
+// Register 注册任务类型
+func Register(jobType string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = handler
+}
+
+func handlerFor(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
+}
+
+// Runner 后台任务执行器，任务状态持久化在 jobs 表中
+type Runner struct {
+	db      *gorm.DB
+	queue   chan uint
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	stopped chan struct{}
+}
+
+var (
+	defaultRunner *Runner
+	defaultMu     sync.Mutex
+)
+
+// NewRunner 创建执行器，调用 Start 后才会在后台执行队列中的任务
+func NewRunner(db *gorm.DB) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		db:      db,
+		queue:   make(chan uint, 1024),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start 启动默认执行器，并恢复上次未完成的任务
+func Start(db *gorm.DB, workers int) *Runner {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultRunner == nil {
+		defaultRunner = NewRunner(db)
+		defaultRunner.Start(workers)
+	}
+	return defaultRunner
+}
+
+// Start 启动 workers 个后台协程执行队列中的任务
+func (r *Runner) Start(workers int) {
+	r.started = true
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+	go func() {
+		r.wg.Wait()
+		close(r.stopped)
+	}()
+
+	r.resume()
+}
+
+// Default 获取默认执行器
+func Default() *Runner {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultRunner
+}
+
+// Enqueue 使用默认执行器创建任务
+func Enqueue(jobType string, userID uint, payload interface{}) (*models.Job, error) {
+	r := Default()
+	if r == nil {
+		return nil, errors.New("后台任务执行器未启动")
+	}
+	return r.Enqueue(jobType, userID, payload)
+}
+
+// Enqueue 创建任务并加入执行队列
+func (r *Runner) Enqueue(jobType string, userID uint, payload interface{}) (*models.Job, error) {
+	job, err := r.Create(jobType, userID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r.queue <- job.ID:
+	default:
+		// 队列已满时保持 pending，下次启动时恢复
+		logrus.WithField("job_id", job.ID).Warn("任务队列已满，任务将在重启后执行")
+	}
+	return job, nil
+}
+
+// Create 创建任务但不加入队列，可随后调用 Run 在当前协程执行
+func (r *Runner) Create(jobType string, userID uint, payload interface{}) (*models.Job, error) {
+	if _, ok := handlerFor(jobType); !ok {
+		return nil, fmt.Errorf("未知任务类型: %s", jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Type:    jobType,
+		UserID:  userID,
+		Status:  models.JobPending,
+		Payload: string(data),
+	}
+	if err := r.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	metrics.JobsEnqueued.WithLabelValues(jobType).Inc()
+	return job, nil
+}
+
+// Stop 停止执行器：通知运行中的任务保存断点后退出，等待至 ctx 超时
+//
+// 被中断的任务状态重置为 pending，下次启动时从断点继续。
+func (r *Runner) Stop(ctx context.Context) error {
+	r.cancel()
+	if !r.started {
+		return nil
+	}
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resume 重新排队上次未完成的任务
+func (r *Runner) resume() {
+	var ids []uint
+	if err := r.db.Model(&models.Job{}).
+		Where("status IN ?", []string{models.JobPending, models.JobRunning}).
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		logrus.WithError(err).Error("加载未完成任务失败")
+		return
+	}
+
+	for _, id := range ids {
+		select {
+		case r.queue <- id:
+		default:
+			return
+		}
+	}
+	if len(ids) > 0 {
+		logrus.WithField("jobs", len(ids)).Info("恢复未完成的后台任务")
+	}
+}
+
+func (r *Runner) worker() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case id := <-r.queue:
+			r.Run(id)
+		}
+	}
+}
+
+// Run 在当前协程执行任务，已完成或失败的任务直接返回
+func (r *Runner) Run(id uint) {
+	var job models.Job
+	if err := r.db.First(&job, id).Error; err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("加载任务失败")
+		return
+	}
+	if job.Status == models.JobCompleted || job.Status == models.JobFailed {
+		return
+	}
+
+	handler, ok := handlerFor(job.Type)
+	if !ok {
+		r.finish(&job, fmt.Errorf("未知任务类型: %s", job.Type))
+		return
+	}
+
+	now := time.Now()
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	job.Status = models.JobRunning
+	job.Attempts++
+	if err := r.db.Model(&job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"attempts":   job.Attempts,
+		"started_at": job.StartedAt,
+	}).Error; err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("更新任务状态失败")
+		return
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"job_id": job.ID, "type": job.Type})
+	logger.Info("后台任务开始执行")
+
+	running := metrics.JobsRunning.WithLabelValues(job.Type)
+	running.Inc()
+	err := safeRun(handler, &Context{ctx: r.ctx, db: r.db, Job: &job})
+	running.Dec()
+	metrics.JobDuration.WithLabelValues(job.Type).Observe(time.Since(now).Seconds())
+
+	if err != nil && r.ctx.Err() != nil {
+		// 服务停止导致的中断，等待下次启动恢复
+		r.db.Model(&job).Update("status", models.JobPending)
+		metrics.JobsFinished.WithLabelValues(job.Type, "interrupted").Inc()
+		logger.Info("后台任务已中断，将在重启后恢复")
+		return
+	}
+	r.finish(&job, err)
+}
+
+func (r *Runner) finish(job *models.Job, err error) {
+	now := time.Now()
+	status := models.JobCompleted
+	updates := map[string]interface{}{
+		"finished_at": now,
+	}
+	logger := logrus.WithFields(logrus.Fields{"job_id": job.ID, "type": job.Type})
+	if err != nil {
+		status = models.JobFailed
+		updates["error"] = truncate(err.Error(), 1000)
+		logger.WithError(err).Error("后台任务执行失败")
+	} else {
+		logger.Info("后台任务执行完成")
+	}
+	updates["status"] = status
+	metrics.JobsFinished.WithLabelValues(job.Type, status).Inc()
+	if err := r.db.Model(job).Updates(updates).Error; err != nil {
+		logger.WithError(err).Error("更新任务状态失败")
+	}
+}
+
+func safeRun(handler Handler, jc *Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务 panic: %v", r)
+		}
+	}()
+	return handler(jc)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
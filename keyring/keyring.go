@@ -0,0 +1,340 @@
+// Package keyring 管理 JWT 非对称签名密钥，支持轮换和 JWKS 发布
+//
+// 每个密钥以 <kid>.pem（PKCS#8）保存在密钥目录中，最新创建的密钥用于签名。
+// 轮换后旧密钥继续用于验证，直到用它签发的令牌全部过期才被清理，因此轮换不会让用户掉线。
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// createdHeader PEM 头中记录密钥创建时间的字段
+const createdHeader = "Created"
+
+// Key 签名密钥
+type Key struct {
+	ID        string // JWT 头中的 kid
+	Algorithm string // RS256 或 EdDSA
+	CreatedAt time.Time
+	Signer    crypto.Signer
+}
+
+// Public 验证签名用的公钥
+func (k *Key) Public() crypto.PublicKey {
+	return k.Signer.Public()
+}
+
+// JWK 单个公钥（RFC 7517）
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS 公钥集合
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Keyring 密钥目录中仍然有效的密钥
+type Keyring struct {
+	dir       string
+	algorithm string
+	retention time.Duration // 旧密钥在被替换后继续用于验证的时长
+	reload    time.Duration
+
+	mu       sync.RWMutex
+	keys     []*Key // 按创建时间升序，最后一个用于签名
+	loadedAt time.Time
+}
+
+// Open 打开密钥目录，目录中没有该算法的密钥时生成第一个
+//
+// retention 应不短于令牌有效期；reload 为重新读取目录的间隔，用于获取其他实例或命令行轮换的密钥。
+func Open(dir, algorithm string, retention, reload time.Duration) (*Keyring, error) {
+	k := &Keyring{dir: dir, algorithm: algorithm, retention: retention, reload: reload}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if len(k.keys) == 0 {
+		if _, err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// HasKeys 密钥目录中是否已有该算法的密钥
+func HasKeys(dir, algorithm string) (bool, error) {
+	keys, err := readDir(dir, algorithm)
+	return len(keys) > 0, err
+}
+
+// Signing 当前用于签名的密钥
+func (k *Keyring) Signing() *Key {
+	k.refresh()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[len(k.keys)-1]
+}
+
+// Lookup 按 kid 查找验证用的密钥
+func (k *Keyring) Lookup(kid string) (*Key, bool) {
+	k.refresh()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// JWKS 全部有效密钥的公钥
+func (k *Keyring) JWKS() JWKS {
+	k.refresh()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// Rotate 生成新的签名密钥，并删除已超过保留期的旧密钥文件，返回新密钥
+func (k *Keyring) Rotate() (*Key, error) {
+	key, err := generate(k.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := save(k.dir, key); err != nil {
+		return nil, err
+	}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if err := k.prune(); err != nil {
+		logrus.WithError(err).Warn("清理过期的 JWT 签名密钥失败")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"kid":       key.ID,
+		"algorithm": key.Algorithm,
+	}).Info("已生成新的 JWT 签名密钥")
+	return key, nil
+}
+
+// refresh 超过重新读取间隔时重新加载密钥目录，失败时继续使用已加载的密钥
+func (k *Keyring) refresh() {
+	k.mu.RLock()
+	stale := k.reload > 0 && time.Since(k.loadedAt) >= k.reload
+	k.mu.RUnlock()
+	if !stale {
+		return
+	}
+	if err := k.load(); err != nil {
+		logrus.WithError(err).Warn("重新读取 JWT 签名密钥失败")
+	}
+}
+
+// load 读取目录中该算法的全部密钥，忽略已超过保留期的旧密钥
+func (k *Keyring) load() error {
+	keys, err := readDir(k.dir, k.algorithm)
+	if err != nil {
+		return err
+	}
+	keys = active(keys, k.retention, time.Now())
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.loadedAt = time.Now()
+	if len(keys) == 0 && len(k.keys) > 0 {
+		return errors.New("密钥目录中没有可用的签名密钥")
+	}
+	k.keys = keys
+	return nil
+}
+
+// prune 删除已超过保留期的旧密钥文件
+func (k *Keyring) prune() error {
+	keys, err := readDir(k.dir, k.algorithm)
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]bool)
+	for _, key := range active(keys, k.retention, time.Now()) {
+		kept[key.ID] = true
+	}
+	for _, key := range keys {
+		if kept[key.ID] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(k.dir, key.ID+".pem")); err != nil {
+			return err
+		}
+		logrus.WithField("kid", key.ID).Info("已删除过期的 JWT 签名密钥")
+	}
+	return nil
+}
+
+// active 过滤出仍可用于验证的密钥：最新的密钥，以及被替换后未超过保留期的旧密钥
+func active(keys []*Key, retention time.Duration, now time.Time) []*Key {
+	var result []*Key
+	for i, key := range keys {
+		if i == len(keys)-1 || now.Before(keys[i+1].CreatedAt.Add(retention)) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// generate 生成新密钥，kid 由创建时间和随机后缀组成
+func generate(algorithm string) (*Key, error) {
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch algorithm {
+	case "RS256":
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return &Key{
+		ID:        now.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		Algorithm: algorithm,
+		CreatedAt: now,
+		Signer:    signer,
+	}, nil
+}
+
+// save 以 PKCS#8 PEM 格式保存私钥，仅所有者可读
+func save(dir string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Signer)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdHeader: key.CreatedAt.Format(time.RFC3339Nano)},
+		Bytes:   der,
+	}
+	return os.WriteFile(filepath.Join(dir, key.ID+".pem"), pem.EncodeToMemory(block), 0o600)
+}
+
+// readDir 读取目录中指定算法的密钥，按创建时间升序排列
+func readDir(dir, algorithm string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+		key, err := readKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if key.Algorithm == algorithm {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("不是 PEM 格式")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch signer := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.Signer = "RS256", signer
+	case ed25519.PrivateKey:
+		key.Algorithm, key.Signer = "EdDSA", signer
+	default:
+		return nil, errors.New("不支持的密钥类型")
+	}
+
+	if created, err := time.Parse(time.RFC3339, block.Headers[createdHeader]); err == nil {
+		key.CreatedAt = created
+	} else if info, err := os.Stat(path); err == nil {
+		key.CreatedAt = info.ModTime()
+	}
+	return key, nil
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger 把 GORM 日志写入 logrus
+//
+// SQL 只在 debug 级别记录，且不带参数值，避免密码哈希、令牌摘要等进入日志；
+// 查询出错（记录不存在除外）和慢查询在 error、warn 级别记录。
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger 创建 GORM 日志适配器
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold}
+}
+
+// LogMode 实现 gormlogger.Interface，级别由 logrus 控制
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info 实现 gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Infof(msg, args...)
+}
+
+// Warn 实现 gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Warnf(msg, args...)
+}
+
+// Error 实现 gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).Errorf(msg, args...)
+}
+
+// Trace 实现 gormlogger.Interface
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	entry := FromContext(ctx)
+	elapsed := time.Since(begin)
+	slow := l.SlowThreshold > 0 && elapsed > l.SlowThreshold
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	if !failed && !slow && !entry.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	sql, rows := fc()
+	entry = entry.WithFields(logrus.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+	switch {
+	case failed:
+		entry.WithError(err).Error("数据库查询失败")
+	case slow:
+		entry.Warn("数据库慢查询")
+	default:
+		entry.Debug("数据库查询")
+	}
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，日志中的 SQL 不带参数值
+func (l *GormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,62 @@
+// Package logger 配置全局日志，并提供随请求上下文传递的日志记录器
+//
+// 请求日志记录器带有 request_id 等字段，同一请求的所有日志可以按 request_id 关联。
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"blog/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Setup 按配置设置日志级别、输出格式和脱敏
+func Setup(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("无效的日志级别: %s", cfg.Level)
+	}
+
+	switch cfg.Format {
+	case config.LogFormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case config.LogFormatText:
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", cfg.Format)
+	}
+
+	logrus.SetOutput(os.Stdout)
+	logrus.SetLevel(level)
+	logrus.AddHook(&redactHook{redactEmail: cfg.RedactEmail})
+	return nil
+}
+
+type contextKey struct{}
+
+// WithContext 把日志记录器放入上下文
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// FromContext 取出上下文中的日志记录器，没有时返回全局记录器
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// From 当前请求的日志记录器
+func From(c *gin.Context) *logrus.Entry {
+	return FromContext(c.Request.Context())
+}
+
+// AddFields 为当前请求后续的日志添加字段（如认证后的 user_id）
+func AddFields(c *gin.Context, fields logrus.Fields) {
+	c.Request = c.Request.WithContext(WithContext(c.Request.Context(), From(c).WithFields(fields)))
+}
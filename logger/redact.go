@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redacted 替换敏感内容的占位符
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 值总是被替换的字段名，另外以 _token、_secret、_password 结尾的字段同样处理
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"authorization": true,
+	"cookie":        true,
+	"code":          true,
+	"code_verifier": true,
+	"x-api-key":     true,
+}
+
+var (
+	// secretPattern 出现在任意字段或消息中的令牌：JWT、API 密钥、第三方应用令牌
+	secretPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+|\b(?:bk|oat|ort)_[A-Za-z0-9_-]{8,}`)
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+// redactHook 在输出前替换日志中的密钥、令牌，并按配置遮盖邮箱
+type redactHook struct {
+	redactEmail bool
+}
+
+func (h *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.scrub(entry.Message)
+	for key, value := range entry.Data {
+		if sensitiveKey(key) {
+			entry.Data[key] = redacted
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = h.scrub(v)
+		case error:
+			if msg := v.Error(); h.scrub(msg) != msg {
+				entry.Data[key] = h.scrub(msg)
+			}
+		}
+	}
+	return nil
+}
+
+func (h *redactHook) scrub(s string) string {
+	s = secretPattern.ReplaceAllString(s, redacted)
+	if h.redactEmail {
+		s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	}
+	return s
+}
+
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	return sensitiveKeys[key] ||
+		strings.HasSuffix(key, "_token") ||
+		strings.HasSuffix(key, "_secret") ||
+		strings.HasSuffix(key, "_password")
+}
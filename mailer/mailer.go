@@ -0,0 +1,119 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"blog/config"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// Message 邮件内容
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+var (
+	defaultMailer Mailer
+	defaultMu     sync.RWMutex
+)
+
+// Default 获取按配置创建的默认发送器
+func Default() Mailer {
+	defaultMu.RLock()
+	m := defaultMailer
+	defaultMu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultMailer == nil {
+		defaultMailer = New(config.Mail)
+	}
+	return defaultMailer
+}
+
+// SetDefault 替换默认发送器
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMailer = m
+}
+
+// New 根据配置创建发送器
+func New(cfg config.MailConfig) Mailer {
+	if cfg.Driver == config.MailDriverSMTP {
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+			Timeout:  cfg.SMTPTimeout,
+		}
+	}
+	return &OutboxMailer{Dir: cfg.OutboxDir, From: cfg.From}
+}
+
+// Bytes 生成 RFC 5322 格式的邮件
+func (m *Message) Bytes(from string) ([]byte, error) {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return nil, fmt.Errorf("收件人地址无效: %w", err)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("发件人地址无效: %w", err)
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", sender.String())
+	header("To", m.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+randomID()+"@"+domainOf(sender.Address)+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(m.Text, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func domainOf(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
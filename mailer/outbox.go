@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutboxMailer 把邮件写入本地目录（.eml 文件），用于开发和测试
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+// Send 把邮件写入发件箱目录
+func (o *OutboxMailer) Send(msg *Message) error {
+	data, err := msg.Bytes(o.From)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.Dir, 0o700); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), randomID()[:8])
+	tmp := filepath.Join(o.Dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(o.Dir, name))
+}
+
+// Messages 按发送顺序列出发件箱中的邮件文件
+func (o *OutboxMailer) Messages() ([]string, error) {
+	entries, err := os.ReadDir(o.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".eml") && !strings.HasPrefix(entry.Name(), ".") {
+			files = append(files, filepath.Join(o.Dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
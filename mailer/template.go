@@ -0,0 +1,88 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"blog/config"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+// Locales 内置模板支持的语言，第一个为兜底语言
+var Locales = []string{"zh-CN", "en"}
+
+var localeMatcher = language.NewMatcher([]language.Tag{
+	language.MustParse("zh-CN"),
+	language.English,
+})
+
+// ResolveLocale 根据显式指定的语言或 Accept-Language 请求头选择模板语言
+func ResolveLocale(explicit, acceptLanguage string) string {
+	for _, value := range []string{explicit, acceptLanguage} {
+		if value == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(value)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := localeMatcher.Match(tags...)
+		if confidence != language.No {
+			return Locales[index]
+		}
+	}
+	return config.Mail.DefaultLocale
+}
+
+// Render 渲染邮件模板
+//
+// 模板文件为 templates/<语言>/<名称>.txt，需定义 subject 和 body 两个模板。
+// 配置了 MAIL_TEMPLATE_DIR 时优先使用其中的同名文件；缺少对应语言时使用默认语言。
+func Render(name, locale string, data interface{}) (*Message, error) {
+	source, err := loadTemplate(name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件模板 %s 失败: %w", name, err)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
+
+// loadTemplate 按 自定义目录 > 内置模板、指定语言 > 默认语言 的顺序查找模板
+func loadTemplate(name, locale string) (string, error) {
+	for _, loc := range []string{locale, config.Mail.DefaultLocale, Locales[0]} {
+		file := loc + "/" + name + ".txt"
+		if config.Mail.TemplateDir != "" {
+			if data, err := os.ReadFile(filepath.Join(config.Mail.TemplateDir, filepath.FromSlash(file))); err == nil {
+				return string(data), nil
+			}
+		}
+		if data, err := builtinTemplates.ReadFile("templates/" + file); err == nil {
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("邮件模板 %s 不存在", name)
+}
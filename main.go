@@ -1,24 +1,155 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"blog/account"
+	"blog/commands"
+	"blog/config"
+	"blog/controllers"
 	"blog/database"
+	"blog/jobs"
+	"blog/logger"
+	"blog/metrics"
+	"blog/middleware"
 	"blog/routes"
+	"blog/tracing"
+	"blog/utils"
+
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	// 日志配置最先完成，之后的日志都按配置的格式输出
+	if err := logger.Setup(config.Log); err != nil {
+		logrus.Fatalf("日志配置错误: %v", err)
+	}
+
+	// 执行命令行子命令（如 export-static），未指定时启动服务
+	if commands.Run(os.Args[1:]) {
+		return
+	}
+
+	// 链路追踪
+	shutdownTracing, err := tracing.Setup(config.Tracing)
+	if err != nil {
+		logrus.WithError(err).Fatal("链路追踪配置错误")
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Error("发送链路追踪数据失败")
+		}
+	}()
+
+	// 使用非对称算法时提前加载签名密钥，密钥不可用时不启动服务
+	switch {
+	case config.JWT.Asymmetric():
+		if _, err := utils.SigningKeys(); err != nil {
+			logrus.WithError(err).Fatal("加载 JWT 签名密钥失败")
+		}
+	case config.JWT.Algorithm != config.JWTAlgorithmHS256:
+		logrus.Fatalf("不支持的 JWT 签名算法: %s", config.JWT.Algorithm)
+	}
+
 	// 初始化数据库
 	database.InitDB()
 
+	// 监控指标
+	var metricsServer *http.Server
+	if config.Metrics.Enabled {
+		if err := metrics.Init(database.GetDB()); err != nil {
+			logrus.WithError(err).Fatal("初始化监控指标失败")
+		}
+		if config.Metrics.Addr != "" {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			metricsServer = newServer(config.Metrics.Addr, mux)
+			go serve(metricsServer, "监控指标服务")
+		}
+	}
+
+	// 检查人机验证配置
+	if _, err := middleware.ChallengeProvider(); err != nil {
+		logrus.WithError(err).Fatal("人机验证配置错误")
+	}
+
+	// 启动后台任务执行器（恢复上次未完成的任务）
+	runner := jobs.Start(database.GetDB(), config.Jobs.Workers)
+
+	// 定期删除冷静期已结束的注销账号
+	stopPurger := account.StartPurger(database.GetDB(), config.Account.PurgeInterval)
+
 	// 设置路由
 	r := routes.SetupRoutes()
 
 	// 启动服务器
-	port := "8080"
-	log.Printf("服务器启动在端口: %s", port)
+	server := newServer(":"+config.Server.Port, r)
+	go serve(server, "服务器")
+
+	// 收到 SIGINT 或 SIGTERM 后优雅退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	logrus.Info("收到退出信号，开始停止服务")
+
+	// 先让就绪检查失败，等待负载均衡摘除流量后再停止接收请求
+	controllers.BeginShutdown()
+	if config.Server.ShutdownDelay > 0 {
+		time.Sleep(config.Server.ShutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+
+	// 等待处理中的请求完成
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("等待处理中的请求超时")
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			logrus.WithError(err).Error("停止监控指标服务失败")
+		}
+	}
+
+	// 停止后台工作：运行中的任务保存断点后退出，下次启动时继续
+	stopPurger()
+	if err := runner.Stop(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("等待后台任务退出超时")
+	}
+	if err := account.WaitForMail(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("等待邮件发送超时")
+	}
+
+	if err := database.Close(); err != nil {
+		logrus.WithError(err).Error("关闭数据库连接失败")
+	}
+	logrus.Info("服务已停止")
+}
+
+// newServer 创建带超时设置的 HTTP 服务
+func newServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+		ReadTimeout:       config.Server.ReadTimeout,
+		WriteTimeout:      config.Server.WriteTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+	}
+}
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("服务器启动失败: %v", err)
+// serve 启动 HTTP 服务，端口被占用等启动失败时退出进程
+func serve(server *http.Server, name string) {
+	logrus.WithField("addr", server.Addr).Info(name + "启动")
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.WithError(err).Fatal(name + "启动失败")
 	}
 }
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"blog/events"
+
+	"gorm.io/gorm"
+)
+
+// Init 开始统计数据库操作和业务事件，需在数据库初始化之后调用一次
+func Init(db *gorm.DB) error {
+	if err := InstrumentDB(db); err != nil {
+		return err
+	}
+	events.Subscribe(events.PostCreated, func(interface{}) { PostsCreated.Inc() })
+	events.Subscribe(events.CommentCreated, func(interface{}) { CommentsCreated.Inc() })
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package metrics Prometheus 监控指标
+//
+// 指标注册在独立的 Registry 中，通过 Handler 输出；各模块直接调用这里定义的指标记录数据。
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "blog"
+
+// Registry 本服务的指标注册表，包含 Go 运行时和进程指标
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// HTTP 请求
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP 请求数，按路由模板和状态码统计",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "正在处理的 HTTP 请求数",
+	})
+)
+
+// 数据库
+var (
+	DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "数据库操作耗时，按操作类型和表统计",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "数据库操作出错次数（不含记录不存在）",
+	}, []string{"operation", "table"})
+)
+
+// 认证失败的方式
+const (
+	AuthPassword = "password"    // 用户名密码登录
+	AuthMFA      = "mfa"         // 两步验证
+	AuthPasskey  = "passkey"     // 通行密钥登录
+	AuthJWT      = "jwt"         // 登录令牌
+	AuthOAuth    = "oauth_token" // 第三方应用令牌
+	AuthAPIKey   = "api_key"     // API 密钥
+)
+
+// 业务
+var (
+	AuthFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "认证失败次数，按认证方式和原因统计",
+	}, []string{"method", "reason"})
+
+	PostsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "创建的文章数（含导入）",
+	})
+
+	CommentsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "发表的评论数",
+	})
+)
+
+// 缓存
+var CacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_requests_total",
+	Help:      "接口缓存读取次数，result 为 hit、miss 或 error",
+}, []string{"result"})
+
+// 后台任务
+var (
+	JobsEnqueued = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_enqueued_total",
+		Help:      "创建的后台任务数",
+	}, []string{"type"})
+
+	JobsFinished = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_finished_total",
+		Help:      "结束的后台任务数，status 为 completed、failed 或 interrupted",
+	}, []string{"type", "status"})
+
+	JobsRunning = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "jobs_running",
+		Help:      "正在执行的后台任务数",
+	}, []string{"type"})
+
+	JobDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "后台任务单次执行耗时",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 8), // 0.1s 到约 27 分钟
+	}, []string{"type"})
+)
+
+// Handler 输出全部指标
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
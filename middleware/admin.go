@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"blog/account"
+	"blog/database"
+	"blog/logger"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware 管理员权限中间件，需在 AuthMiddleware 之后使用
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetCurrentUserID(c)
+		if !exists {
+			utils.UnauthorizedResponse(c, "未授权访问")
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := database.WithContext(c.Request.Context()).Select("id", "role", "status", "totp_enabled_at").First(&user, userID).Error; err != nil {
+			utils.UnauthorizedResponse(c, "用户不存在")
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin() || user.Status != 1 {
+			logger.From(c).WithField("user_id", userID).Warn("非管理员尝试访问管理接口")
+			utils.ForbiddenResponse(c, "需要管理员权限")
+			c.Abort()
+			return
+		}
+
+		// 按配置要求管理员先启用两步验证
+		if account.MFARequired(&user) && !user.MFAEnabled() {
+			logger.From(c).WithField("user_id", userID).Warn("未启用两步验证的管理员尝试访问管理接口")
+			utils.ForbiddenResponse(c, "请先启用两步验证")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
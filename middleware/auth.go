@@ -3,6 +3,12 @@ package middleware
 import (
 	"strings"
 
+	"blog/account"
+	"blog/database"
+	"blog/logger"
+	"blog/metrics"
+	"blog/models"
+	"blog/oauthserver"
 	"blog/utils"
 
 	"github.com/gin-gonic/gin"
@@ -10,12 +16,21 @@ import (
 )
 
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+//
+// scopes 为第三方应用令牌和 API 密钥访问该接口需要的权限；不传时只接受本站登录令牌。
+func AuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API 密钥也可以通过 X-API-Key 请求头传递
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKey, scopes)
+			return
+		}
+
 		// 从请求头获取Authorization
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			logrus.Warn("请求缺少认证令牌")
+			logger.From(c).Warn("请求缺少认证令牌")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthJWT, "missing").Inc()
 			utils.UnauthorizedResponse(c, "请提供认证令牌")
 			c.Abort()
 			return
@@ -24,7 +39,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 检查Bearer前缀
 		const bearerPrefix = "Bearer "
 		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			logrus.Warn("令牌格式错误")
+			logger.From(c).Warn("令牌格式错误")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthJWT, "malformed").Inc()
 			utils.UnauthorizedResponse(c, "令牌格式错误")
 			c.Abort()
 			return
@@ -33,34 +49,139 @@ func AuthMiddleware() gin.HandlerFunc {
 		// 提取token
 		tokenString := authHeader[len(bearerPrefix):]
 		if tokenString == "" {
-			logrus.Warn("令牌为空")
+			logger.From(c).Warn("令牌为空")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthJWT, "missing").Inc()
 			utils.UnauthorizedResponse(c, "令牌不能为空")
 			c.Abort()
 			return
 		}
 
+		// 第三方应用令牌和 API 密钥
+		if oauthserver.IsAccessToken(tokenString) {
+			authenticateOAuth(c, tokenString, scopes)
+			return
+		}
+		if account.IsAPIKey(tokenString) {
+			authenticateAPIKey(c, tokenString, scopes)
+			return
+		}
+
 		// 验证token
 		valid, claims := utils.ValidateToken(tokenString)
 		if !valid {
-			logrus.WithField("token", tokenString).Warn("令牌验证失败")
+			logger.From(c).Warn("令牌验证失败")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthJWT, "invalid").Inc()
 			utils.UnauthorizedResponse(c, "令牌无效或已过期")
 			c.Abort()
 			return
 		}
 
+		// 检查令牌是否已被撤销（如申请注销账号）
+		if !tokenActive(c, claims) {
+			logger.From(c).WithField("user_id", claims.UserID).Warn("令牌已被撤销")
+			metrics.AuthFailures.WithLabelValues(metrics.AuthJWT, "revoked").Inc()
+			utils.UnauthorizedResponse(c, "令牌已失效，请重新登录")
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 
-		logrus.WithFields(logrus.Fields{
+		// 之后的日志都带上用户信息
+		logger.AddFields(c, logrus.Fields{
 			"user_id":  claims.UserID,
 			"username": claims.Username,
-		}).Info("用户认证成功")
+		})
+		logger.From(c).Debug("用户认证成功")
 
 		c.Next()
 	}
 }
 
+// authenticateOAuth 校验第三方应用令牌及其权限
+func authenticateOAuth(c *gin.Context, tokenString string, scopes []string) {
+	token, user, err := oauthserver.Authenticate(database.WithContext(c.Request.Context()), tokenString)
+	if err != nil {
+		if err != oauthserver.ErrTokenInvalid {
+			logger.From(c).WithError(err).Error("校验第三方应用令牌失败")
+		}
+		metrics.AuthFailures.WithLabelValues(metrics.AuthOAuth, "invalid").Inc()
+		utils.UnauthorizedResponse(c, "令牌无效或已过期")
+		c.Abort()
+		return
+	}
+
+	if !requireScopes(c, metrics.AuthOAuth, "第三方应用", token.HasScope, scopes) {
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("oauth_client_id", token.ClientID)
+
+	logger.AddFields(c, logrus.Fields{
+		"user_id":   user.ID,
+		"client_id": token.ClientID,
+	})
+	logger.From(c).Debug("第三方应用认证成功")
+
+	c.Next()
+}
+
+// authenticateAPIKey 校验 API 密钥及其权限
+func authenticateAPIKey(c *gin.Context, raw string, scopes []string) {
+	key, user, err := account.AuthenticateAPIKey(database.WithContext(c.Request.Context()), raw)
+	if err != nil {
+		if err != account.ErrAPIKeyInvalid {
+			logger.From(c).WithError(err).Error("校验 API 密钥失败")
+		}
+		metrics.AuthFailures.WithLabelValues(metrics.AuthAPIKey, "invalid").Inc()
+		utils.UnauthorizedResponse(c, "API 密钥无效或已过期")
+		c.Abort()
+		return
+	}
+
+	if !requireScopes(c, metrics.AuthAPIKey, "API 密钥", key.HasScope, scopes) {
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("api_key_id", key.ID)
+
+	logger.AddFields(c, logrus.Fields{
+		"user_id": user.ID,
+		"api_key": key.Prefix,
+	})
+	logger.From(c).Debug("API 密钥认证成功")
+
+	c.Next()
+}
+
+// requireScopes 检查第三方应用令牌或 API 密钥是否具有接口要求的全部权限
+//
+// 接口没有声明权限时只允许本站登录令牌访问。
+func requireScopes(c *gin.Context, method, kind string, hasScope func(string) bool, scopes []string) bool {
+	if len(scopes) == 0 {
+		logger.From(c).WithField("path", c.FullPath()).Warn(kind + "访问未开放的接口")
+		metrics.AuthFailures.WithLabelValues(method, "forbidden").Inc()
+		utils.ForbiddenResponse(c, kind+"无权访问该接口")
+		c.Abort()
+		return false
+	}
+	for _, scope := range scopes {
+		if !hasScope(scope) {
+			metrics.AuthFailures.WithLabelValues(method, "insufficient_scope").Inc()
+			utils.ForbiddenResponse(c, kind+"缺少权限: "+scope)
+			c.Abort()
+			return false
+		}
+	}
+	return true
+}
+
 // OptionalAuthMiddleware 可选认证中间件（不强制要求认证）
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -71,7 +192,7 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 				tokenString := authHeader[len(bearerPrefix):]
 				if tokenString != "" {
 					valid, claims := utils.ValidateToken(tokenString)
-					if valid {
+					if valid && tokenActive(c, claims) {
 						c.Set("user_id", claims.UserID)
 						c.Set("username", claims.Username)
 					}
@@ -82,6 +203,15 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// tokenActive 检查令牌对应的用户仍然存在且令牌版本未被撤销
+func tokenActive(c *gin.Context, claims *utils.Claims) bool {
+	var user models.User
+	if err := database.WithContext(c.Request.Context()).Select("id", "token_version").First(&user, claims.UserID).Error; err != nil {
+		return false
+	}
+	return user.TokenVersion == claims.Version
+}
+
 // GetCurrentUserID 从上下文获取当前用户ID
 func GetCurrentUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get("user_id")
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"blog/challenge"
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"blog/models"
+	"blog/ratelimit"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ChallengeHeader 客户端提交人机验证答案的请求头
+const ChallengeHeader = "X-Challenge-Response"
+
+// ChallengeReputation 可疑 IP 判断，为空时使用 CHALLENGE_SUSPICIOUS_IPS，可在启动前替换为第三方信誉服务
+var ChallengeReputation challenge.Reputation
+
+var (
+	challengeOnce     sync.Once
+	challengeProvider challenge.Provider
+	challengeErr      error
+)
+
+// ChallengeProvider 按配置创建的人机验证方式，首次调用时初始化
+func ChallengeProvider() (challenge.Provider, error) {
+	challengeOnce.Do(func() {
+		if ChallengeReputation == nil {
+			networks, err := challenge.ParseNetworks(config.Challenge.SuspiciousIPs)
+			if err != nil {
+				challengeErr = err
+				return
+			}
+			ChallengeReputation = networks
+		}
+		challengeProvider, challengeErr = challenge.New(config.Challenge, database.GetDB())
+	})
+	return challengeProvider, challengeErr
+}
+
+// RequireChallenge 按配置要求完成人机验证，评论等需要用户信息的操作需在 AuthMiddleware 之后使用
+//
+// 风险模式下，来自可疑 IP、新注册账号或超过免验证频率的请求需要在 X-Challenge-Response 头中提交答案，
+// 未提交或答案错误时返回 428，客户端通过 GET /api/v1/challenge 获取题目后重试。
+func RequireChallenge(action string) gin.HandlerFunc {
+	rule := config.Challenge.FreeRate(action)
+	policy := ratelimit.Policy{Name: "challenge_" + action, Limit: rule.Limit, Period: rule.Period}
+	return func(c *gin.Context) {
+		mode := config.Challenge.Mode(action)
+		if mode == config.ChallengeOff {
+			c.Next()
+			return
+		}
+
+		provider, err := ChallengeProvider()
+		if err != nil {
+			logger.From(c).WithError(err).Error("初始化人机验证失败")
+			utils.InternalServerErrorResponse(c, "人机验证配置错误")
+			c.Abort()
+			return
+		}
+
+		reason := "always"
+		if mode != config.ChallengeAlways {
+			if reason = challengeRisk(c, policy); reason == "" {
+				c.Next()
+				return
+			}
+		}
+
+		response := c.GetHeader(ChallengeHeader)
+		if response == "" {
+			utils.ErrorResponse(c, http.StatusPreconditionRequired, "请先完成人机验证")
+			c.Abort()
+			return
+		}
+		if err := provider.Verify(c.Request.Context(), action, response, c.ClientIP()); err != nil {
+			if errors.Is(err, challenge.ErrInvalid) {
+				logger.From(c).WithFields(logrus.Fields{
+					"action": action,
+					"reason": reason,
+					"ip":     c.ClientIP(),
+				}).Warn("人机验证未通过")
+				utils.ErrorResponse(c, http.StatusPreconditionRequired, "人机验证未通过，请重试")
+			} else {
+				logger.From(c).WithError(err).Error("人机验证失败")
+				utils.ErrorResponse(c, http.StatusServiceUnavailable, "人机验证服务暂不可用，请稍后再试")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// challengeRisk 检查风险信号，返回触发验证的原因，没有风险时返回空字符串
+func challengeRisk(c *gin.Context, policy ratelimit.Policy) string {
+	if ChallengeReputation != nil && ChallengeReputation.Suspicious(c.Request.Context(), c.ClientIP()) {
+		return "suspicious_ip"
+	}
+
+	if userID, exists := GetCurrentUserID(c); exists && config.Challenge.NewAccountAge > 0 {
+		var user models.User
+		if err := database.WithContext(c.Request.Context()).Select("id", "created_at").First(&user, userID).Error; err == nil &&
+			time.Since(user.CreatedAt) < config.Challenge.NewAccountAge {
+			return "new_account"
+		}
+	}
+
+	if policy.Enabled() {
+		result, err := RateLimitStore.Take(c.Request.Context(), KeyByUser(c), policy)
+		if err != nil {
+			logger.From(c).WithError(err).Error("读取限流计数失败")
+		} else if !result.Allowed {
+			return "rate"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"blog/logger"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader 请求 ID 头，上游（如网关）传入时沿用，否则生成新的
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern 允许沿用的请求 ID，防止把任意内容写入日志和响应头
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID 为请求分配 ID，写入响应头，并创建带 request_id 的请求日志记录器
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		entry := logrus.WithField("request_id", id)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), entry))
+
+		c.Next()
+	}
+}
+
+// GetRequestID 当前请求的 ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// AccessLog 访问日志，在 RequestID 之后使用
+//
+// 只记录路径不记录查询参数，避免授权码等参数进入日志。
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		entry := logger.From(c).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      c.FullPath(),
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"bytes":      c.Writer.Size(),
+			"ip":         c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+		})
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("请求完成")
+		case status >= http.StatusBadRequest:
+			entry.Warn("请求完成")
+		default:
+			entry.Info("请求完成")
+		}
+	}
+}
+
+// Recovery 捕获处理请求时的 panic，记录到请求日志并返回 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err interface{}) {
+		logger.From(c).WithFields(logrus.Fields{
+			"panic": err,
+			"stack": string(debug.Stack()),
+		}).Error("处理请求时发生异常")
+		utils.InternalServerErrorResponse(c, "服务器内部错误")
+		c.Abort()
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/metrics"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 统计请求数和耗时
+//
+// 按路由模板（如 /api/v1/posts/:id）而不是实际路径统计，未匹配路由的请求记为 unmatched，避免标签数量无限增长。
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsAuth 校验访问 /metrics 的 Bearer 令牌
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			utils.UnauthorizedResponse(c, "无效的监控令牌")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
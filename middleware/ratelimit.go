@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blog/config"
+	"blog/logger"
+	"blog/ratelimit"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitStore 限流计数存储，多实例部署时在启动前替换为共享存储
+var RateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+// RateLimitKey 从请求中取得限流键
+type RateLimitKey func(c *gin.Context) string
+
+// KeyByIP 按客户端 IP 限流
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按 API 密钥或用户限流，未认证的请求按 IP，需在 AuthMiddleware 之后使用
+func KeyByUser(c *gin.Context) string {
+	if keyID, exists := c.Get("api_key_id"); exists {
+		return fmt.Sprintf("key:%v", keyID)
+	}
+	if userID, exists := GetCurrentUserID(c); exists {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return KeyByIP(c)
+}
+
+// RateLimit 令牌桶限流中间件，超出限制时返回 429
+//
+// name 为策略名称，同一名称的路由共享计数；规则的 Limit 为 0 时不限流。
+func RateLimit(name string, rule config.RateLimitRule, key RateLimitKey) gin.HandlerFunc {
+	policy := ratelimit.Policy{Name: name, Limit: rule.Limit, Period: rule.Period}
+	return func(c *gin.Context) {
+		if !config.RateLimit.Enabled || !policy.Enabled() {
+			c.Next()
+			return
+		}
+
+		result, err := RateLimitStore.Take(c.Request.Context(), key(c), policy)
+		if err != nil {
+			// 存储不可用时放行，避免限流故障导致整站不可用
+			logger.From(c).WithError(err).WithField("policy", name).Error("读取限流计数失败")
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+			logger.From(c).WithFields(logrus.Fields{
+				"policy": name,
+				"key":    key(c),
+				"path":   c.FullPath(),
+			}).Warn("请求超出频率限制")
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds 向上取整的秒数
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
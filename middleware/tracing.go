@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"blog/logger"
+	"blog/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 为每个请求创建 server span，在 RequestID 之后使用
+//
+// 请求头带有 W3C traceparent 时作为上游 span 的子 span；span 写入请求的 context，
+// 之后的数据库操作通过 database.WithContext 关联到该 span。日志中带有 trace_id，便于从日志跳转到链路。
+func Tracing() gin.HandlerFunc {
+	tracer := tracing.Tracer()
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 按路由模板命名，避免每个文章 ID 都成为不同的 span 名
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.ClientAddress(c.ClientIP()),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+				attribute.String("request.id", GetRequestID(c)),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		if sc := span.SpanContext(); sc.IsValid() {
+			logger.AddFields(c, logrus.Fields{"trace_id": sc.TraceID().String()})
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID, exists := GetCurrentUserID(c); exists {
+			span.SetAttributes(attribute.Int64("user.id", int64(userID)))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"blog/models"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequireVerifiedEmail 按配置禁止未验证邮箱的用户执行操作，需在 AuthMiddleware 之后使用
+func RequireVerifiedEmail(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.EmailVerification.Denies(action) {
+			c.Next()
+			return
+		}
+
+		userID, exists := GetCurrentUserID(c)
+		if !exists {
+			utils.UnauthorizedResponse(c, "未授权访问")
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := database.WithContext(c.Request.Context()).Select("id", "email_verified_at").First(&user, userID).Error; err != nil {
+			utils.UnauthorizedResponse(c, "用户不存在")
+			c.Abort()
+			return
+		}
+
+		if !user.IsEmailVerified() {
+			logger.From(c).WithFields(logrus.Fields{
+				"user_id": userID,
+				"action":  action,
+			}).Warn("未验证邮箱的用户被拒绝")
+			utils.ForbiddenResponse(c, "请先验证邮箱")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
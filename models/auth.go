@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -49,3 +50,204 @@ func (LoginLog) TableName() string {
 func (TokenBlacklist) TableName() string {
 	return "token_blacklist"
 }
+
+// PasswordResetToken 找回密码令牌，只保存令牌的 SHA-256 摘要
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// 邮件令牌用途
+const (
+	EmailTokenVerify    = "verify"     // 验证注册邮箱
+	EmailTokenChangeOld = "change_old" // 更换邮箱时由原邮箱确认
+	EmailTokenChangeNew = "change_new" // 更换邮箱时验证新邮箱
+)
+
+// EmailToken 邮箱验证令牌，只保存令牌的 SHA-256 摘要
+//
+// 更换邮箱时同时生成 change_old 和 change_new 两个令牌，Email 均为新邮箱，两者都确认后才生效。
+type EmailToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Purpose   string     `json:"purpose" gorm:"not null;size:20"`
+	Email     string     `json:"email" gorm:"not null;size:100"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (EmailToken) TableName() string {
+	return "email_tokens"
+}
+
+// RecoveryCode 两步验证恢复码，只保存恢复码的 SHA-256 摘要，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// WebAuthnCredential 用户注册的通行密钥，二进制字段以 base64url 保存
+type WebAuthnCredential struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	Name            string     `json:"name" gorm:"size:100"`
+	CredentialID    string     `json:"-" gorm:"not null;uniqueIndex;size:255"`
+	PublicKey       string     `json:"-" gorm:"type:text;not null"`
+	AttestationType string     `json:"-" gorm:"size:32"`
+	Transports      string     `json:"transports" gorm:"size:100"` // 逗号分隔，如 usb,nfc,internal
+	AAGUID          string     `json:"aaguid" gorm:"size:36"`      // 认证器型号
+	Flags           uint8      `json:"-" gorm:"not null;default:0"`
+	SignCount       uint32     `json:"-" gorm:"not null;default:0"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthn 挑战用途
+const (
+	WebAuthnRegister = "register" // 注册通行密钥
+	WebAuthnLogin    = "login"    // 使用通行密钥登录
+)
+
+// WebAuthnSession 注册或登录过程中保存的挑战，完成或过期后删除
+type WebAuthnSession struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id" gorm:"index"` // 不指定用户的通行密钥登录时为空
+	Purpose   string    `json:"purpose" gorm:"not null;size:20"`
+	Challenge string    `json:"-" gorm:"not null;uniqueIndex;size:128"`
+	Data      string    `json:"-" gorm:"type:text;not null"` // webauthn.SessionData 的 JSON
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (WebAuthnSession) TableName() string {
+	return "webauthn_sessions"
+}
+
+// UserIdentity 用户绑定的第三方账号，同一提供方的同一账号只能绑定一个用户
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"not null;size:50;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string     `json:"-" gorm:"not null;size:255;uniqueIndex:idx_identity_provider_subject"` // 提供方的用户唯一标识
+	Email       string     `json:"email" gorm:"size:100"`
+	Name        string     `json:"name" gorm:"size:100"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState 第三方登录过程中保存的 state、PKCE 校验码、nonce 和浏览器绑定，回调后删除
+type OAuthState struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StateHash   string    `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	Provider    string    `json:"provider" gorm:"not null;size:50"`
+	Verifier    string    `json:"-" gorm:"not null;size:128"`
+	Nonce       string    `json:"-" gorm:"not null;size:64"`
+	BindingHash string    `json:"-" gorm:"not null;default:'';size:64"` // 发起登录的浏览器 Cookie 摘要，回调时校验
+	UserID      *uint     `json:"user_id" gorm:"index"`                 // 已登录用户绑定第三方账号时设置
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
+
+// APIKey 用户创建的 API 密钥，用于 CI 等自动化场景
+//
+// 密钥形如 bk_<前缀>_<密文>，前缀明文保存用于查找和展示，密文只保存 SHA-256 摘要。
+type APIKey struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	Name         string     `json:"name" gorm:"not null;size:100"`
+	Prefix       string     `json:"prefix" gorm:"not null;uniqueIndex;size:16"`
+	SecretHash   string     `json:"-" gorm:"not null;size:64"`
+	Scopes       string     `json:"-" gorm:"not null;size:255"` // 空格分隔
+	TokenVersion uint       `json:"-" gorm:"not null;default:0"`
+	ExpiresAt    *time.Time `json:"expires_at"` // 为空表示永不过期
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope 密钥是否包含指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Fields(k.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyResponse API 密钥响应结构
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"` // 完整密钥，只在创建时返回一次
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ToResponse 转换为响应结构
+func (k *APIKey) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     strings.Fields(k.Scopes),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// ChallengeRedemption 已使用的工作量证明题目，防止同一答案重复提交，过期后删除
+type ChallengeRedemption struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Hash      string    `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
+
+// TableName 指定表名
+func (ChallengeRedemption) TableName() string {
+	return "challenge_redemptions"
+}
@@ -24,6 +24,9 @@ type Comment struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// AuthorEmail 导入的访客评论者填写的邮箱，仅作记录，不对外展示
+	AuthorEmail string `json:"-" gorm:"size:100"`
 }
 
 // CommentResponse 评论响应结构
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 后台任务状态
+const (
+	JobPending   = "pending"   // 等待执行（包括服务重启后待恢复的任务）
+	JobRunning   = "running"   // 执行中
+	JobCompleted = "completed" // 已完成
+	JobFailed    = "failed"    // 执行失败
+)
+
+// Job 后台任务模型
+type Job struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Type       string     `json:"type" gorm:"not null;size:50;index"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Status     string     `json:"status" gorm:"not null;size:20;index"`
+	Payload    string     `json:"-" gorm:"type:text"` // 任务参数（JSON）
+	State      string     `json:"-" gorm:"type:text"` // 断点状态（JSON），用于恢复执行
+	Result     string     `json:"-" gorm:"type:text"` // 执行结果（JSON）
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Error      string     `json:"error" gorm:"size:1000"`
+	Attempts   int        `json:"attempts"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// JobResponse 任务响应结构
+type JobResponse struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Percent    float64         `json:"percent"`
+	Error      string          `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	StartedAt  *time.Time      `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// ToResponse 转换为响应格式
+func (j *Job) ToResponse() JobResponse {
+	var percent float64
+	if j.Total > 0 {
+		percent = float64(j.Processed) * 100 / float64(j.Total)
+	} else if j.Status == JobCompleted {
+		percent = 100
+	}
+
+	var result json.RawMessage
+	if j.Result != "" {
+		result = json.RawMessage(j.Result)
+	}
+
+	return JobResponse{
+		ID:         j.ID,
+		Type:       j.Type,
+		Status:     j.Status,
+		Total:      j.Total,
+		Processed:  j.Processed,
+		Percent:    percent,
+		Error:      j.Error,
+		Result:     result,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+		CreatedAt:  j.CreatedAt,
+		UpdatedAt:  j.UpdatedAt,
+	}
+}
+
+// TableName 指定表名
+func (Job) TableName() string {
+	return "jobs"
+}
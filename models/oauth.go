@@ -0,0 +1,125 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// OAuthClient 在本站注册的第三方应用
+//
+// 公开客户端（移动应用、桌面编辑器）没有密钥，只能依靠 PKCE 保护授权码。
+type OAuthClient struct {
+	ID           uint      `json:"-" gorm:"primaryKey"`
+	ClientID     string    `json:"client_id" gorm:"not null;uniqueIndex;size:64"`
+	SecretHash   string    `json:"-" gorm:"size:64"`              // 客户端密钥的 SHA-256 摘要，公开客户端为空
+	UserID       uint      `json:"user_id" gorm:"not null;index"` // 注册该应用的用户
+	Name         string    `json:"name" gorm:"not null;size:100"`
+	RedirectURIs string    `json:"-" gorm:"type:text;not null"` // 换行分隔
+	Scopes       string    `json:"-" gorm:"not null;size:255"`  // 允许申请的权限，空格分隔
+	Public       bool      `json:"public" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// OAuthClientResponse 第三方应用响应结构
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"` // 只在注册时返回一次
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RedirectURIList 已登记的回调地址
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+// ScopeList 允许申请的权限
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// ToResponse 转换为响应结构
+func (c *OAuthClient) ToResponse() OAuthClientResponse {
+	return OAuthClientResponse{
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectURIList(),
+		Scopes:       c.ScopeList(),
+		Public:       c.Public,
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// OAuthAuthorizationCode 授权码，只保存摘要，换取令牌后删除
+type OAuthAuthorizationCode struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CodeHash      string    `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	ClientID      string    `json:"client_id" gorm:"not null;size:64;index"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	RedirectURI   string    `json:"redirect_uri" gorm:"type:text;not null"`
+	Scope         string    `json:"scope" gorm:"not null;size:255"`
+	CodeChallenge string    `json:"-" gorm:"not null;size:128"` // PKCE S256 校验值
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (OAuthAuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+// OAuthConsent 用户已同意授予第三方应用的权限，再次授权相同权限时无需重复确认
+type OAuthConsent struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_oauth_consent_user_client"`
+	ClientID  string    `json:"client_id" gorm:"not null;size:64;uniqueIndex:idx_oauth_consent_user_client"`
+	Scope     string    `json:"scope" gorm:"not null;size:255"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// OAuthToken 签发给第三方应用的访问令牌和刷新令牌，只保存摘要
+//
+// 刷新时旧记录被撤销并签发新记录；TokenVersion 与用户不一致（修改密码、申请注销等）时令牌失效。
+type OAuthToken struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	AccessHash       string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	RefreshHash      *string    `json:"-" gorm:"uniqueIndex;size:64"`
+	ClientID         string     `json:"client_id" gorm:"not null;size:64;index"`
+	UserID           uint       `json:"user_id" gorm:"not null;index"`
+	Scope            string     `json:"scope" gorm:"not null;size:255"`
+	TokenVersion     uint       `json:"-" gorm:"not null;default:0"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (OAuthToken) TableName() string {
+	return "oauth_tokens"
+}
+
+// HasScope 令牌是否包含指定权限
+func (t *OAuthToken) HasScope(scope string) bool {
+	for _, s := range strings.Fields(t.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"blog/utils"
+
 	"gorm.io/gorm"
 )
 
@@ -10,6 +12,7 @@ import (
 type Post struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	Title        string         `json:"title" gorm:"not null;size:200"`
+	Slug         string         `json:"slug" gorm:"size:200;uniqueIndex"`
 	Content      string         `json:"content" gorm:"type:text"`
 	Summary      string         `json:"summary" gorm:"size:500"`
 	Excerpt      string         `json:"excerpt" gorm:"size:500"`
@@ -18,6 +21,7 @@ type Post struct {
 	CommentCount int            `json:"comment_count" gorm:"default:0"`
 	LikeCount    int            `json:"like_count" gorm:"default:0"`
 	IsTop        int            `json:"is_top" gorm:"default:0;comment:1-置顶 0-普通"`
+	Version      uint           `json:"version" gorm:"not null;default:1;comment:内容版本号，每次修改加 1"`
 	UserID       uint           `json:"user_id" gorm:"not null;index"`
 	User         User           `json:"user" gorm:"foreignKey:UserID"`
 	CategoryID   *uint          `json:"category_id" gorm:"index"`
@@ -34,6 +38,7 @@ type Post struct {
 type PostResponse struct {
 	ID           uint       `json:"id"`
 	Title        string     `json:"title"`
+	Slug         string     `json:"slug"`
 	Content      string     `json:"content"`
 	Summary      string     `json:"summary"`
 	Excerpt      string     `json:"excerpt"`
@@ -42,6 +47,7 @@ type PostResponse struct {
 	CommentCount int        `json:"comment_count"`
 	LikeCount    int        `json:"like_count"`
 	IsTop        int        `json:"is_top"`
+	Version      uint       `json:"version"`
 	UserID       uint       `json:"user_id"`
 	Username     string     `json:"username"`
 	CategoryID   *uint      `json:"category_id"`
@@ -67,6 +73,7 @@ func (p *Post) ToResponse() PostResponse {
 	return PostResponse{
 		ID:           p.ID,
 		Title:        p.Title,
+		Slug:         p.Slug,
 		Content:      p.Content,
 		Summary:      p.Summary,
 		Excerpt:      p.Excerpt,
@@ -75,6 +82,7 @@ func (p *Post) ToResponse() PostResponse {
 		CommentCount: p.CommentCount,
 		LikeCount:    p.LikeCount,
 		IsTop:        p.IsTop,
+		Version:      p.Version,
 		UserID:       p.UserID,
 		Username:     p.User.Username,
 		CategoryID:   p.CategoryID,
@@ -86,7 +94,28 @@ func (p *Post) ToResponse() PostResponse {
 	}
 }
 
+// ResourceVersion 文章内容版本号，用于生成 ETag 和条件更新
+func (r PostResponse) ResourceVersion() uint {
+	return r.Version
+}
+
 // TableName 指定表名
 func (Post) TableName() string {
 	return "posts"
 }
+
+// BeforeCreate 创建前钩子 - 未指定 slug 时根据标题生成
+func (p *Post) BeforeCreate(tx *gorm.DB) error {
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	if p.Slug != "" {
+		return nil
+	}
+	slug, err := UniqueSlug(tx.Session(&gorm.Session{NewDB: true}), utils.Slugify(p.Title), p.ID)
+	if err != nil {
+		return err
+	}
+	p.Slug = slug
+	return nil
+}
@@ -1,9 +1,11 @@
 package models
 
+import "time"
+
 // RegisterRequest 注册请求结构
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50" validate:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6" validate:"required,min=6"`
+	Password string `json:"password" binding:"required" validate:"required"` // 长度等要求见密码策略
 	Email    string `json:"email" binding:"required,email" validate:"required,email"`
 	Nickname string `json:"nickname" binding:"max=50" validate:"max=50"`
 }
@@ -16,14 +18,108 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应结构
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token            string       `json:"token"`
+	User             UserResponse `json:"user"`
+	MFASetupRequired bool         `json:"mfa_setup_required,omitempty"` // 账号被要求启用两步验证但尚未启用
+}
+
+// MFAChallengeResponse 需要两步验证时的登录响应
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`  // 仅用于 /auth/login/mfa，不能访问其他接口
+	ExpiresIn   int    `json:"expires_in"` // 有效期（秒）
+}
+
+// MFALoginRequest 两步验证登录请求结构
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"` // 认证器验证码或恢复码
+}
+
+// MFASetupRequest 开始绑定认证器请求结构
+type MFASetupRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// MFACodeRequest 两步验证码请求结构
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// DisableMFARequest 关闭两步验证请求结构
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// TOTPSetupResponse 绑定认证器响应，otpauth_uri 可生成二维码供认证器扫描
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse 恢复码响应，恢复码只在生成时返回一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatusResponse 两步验证状态
+type MFAStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+	Required               bool       `json:"required"` // 当前账号是否被要求启用
+}
+
+// PasskeyRegisterRequest 注册通行密钥请求结构
+type PasskeyRegisterRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// PasskeyLoginRequest 通行密钥登录请求结构
+type PasskeyLoginRequest struct {
+	Username string `json:"username" binding:"max=100"` // 用户名或邮箱，为空时由认证器选择账号
 }
 
 // ChangePasswordRequest 修改密码请求结构
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ForgotPasswordRequest 找回密码请求结构
+type ForgotPasswordRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Locale string `json:"locale" binding:"max=20"` // 邮件语言，为空时根据 Accept-Language 选择
+}
+
+// ResetPasswordRequest 重置密码请求结构
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ResendVerificationRequest 重新发送验证邮件请求结构
+type ResendVerificationRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Locale string `json:"locale" binding:"max=20"`
+}
+
+// VerifyEmailRequest 邮箱验证请求结构
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ChangeEmailRequest 更换邮箱请求结构
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email,max=100"`
+	Password string `json:"password" binding:"required"`
+	Locale   string `json:"locale" binding:"max=20"`
+}
+
+// DeleteAccountRequest 注销账号请求结构
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
 }
 
 // UpdateProfileRequest 更新个人信息请求结构
@@ -36,6 +132,7 @@ type UpdateProfileRequest struct {
 // CreatePostRequest 创建文章请求结构
 type CreatePostRequest struct {
 	Title   string `json:"title" binding:"required,max=255"`
+	Slug    string `json:"slug" binding:"max=200"`
 	Content string `json:"content" binding:"required"`
 	Excerpt string `json:"excerpt" binding:"max=500"`
 }
@@ -43,6 +140,7 @@ type CreatePostRequest struct {
 // UpdatePostRequest 更新文章请求结构
 type UpdatePostRequest struct {
 	Title   string `json:"title" binding:"max=255"`
+	Slug    string `json:"slug" binding:"max=200"`
 	Content string `json:"content"`
 	Excerpt string `json:"excerpt" binding:"max=500"`
 }
@@ -51,3 +149,74 @@ type UpdatePostRequest struct {
 type CreateCommentRequest struct {
 	Content string `json:"content" binding:"required,max=1000"`
 }
+
+// OAuthCallbackRequest 第三方登录回调请求结构，前端页面原样提交提供方返回的 code 和 state
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// OAuthProviderResponse 可用的第三方登录方式
+type OAuthProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// RegisterOAuthClientRequest 注册第三方应用请求结构
+type RegisterOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,max=10,dive,required,max=500"`
+	Scopes       []string `json:"scopes"` // 为空时允许申请全部权限
+	Public       bool     `json:"public"` // 移动应用等无法保管密钥的客户端
+}
+
+// OAuthAuthorizeRequest 授权请求参数（RFC 6749 第 4.1.1 节，PKCE 为必填）
+//
+// 前端授权确认页面从地址栏读取参数，查询授权信息和提交确认时原样传入。
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// OAuthConsentRequest 用户确认或拒绝授权
+type OAuthConsentRequest struct {
+	OAuthAuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// OAuthScopeResponse 权限说明
+type OAuthScopeResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// OAuthAuthorizeResponse 授权确认页面需要展示的信息
+//
+// RedirectTo 不为空时前端应直接跳转：授权请求有误（错误已附加在回调地址上）或用户已完成确认。
+type OAuthAuthorizeResponse struct {
+	Client          *OAuthClientResponse `json:"client,omitempty"`
+	Scopes          []OAuthScopeResponse `json:"scopes,omitempty"`
+	ConsentRequired bool                 `json:"consent_required"` // 为 false 表示用户已同意过这些权限，可直接确认
+	RedirectTo      string               `json:"redirect_to,omitempty"`
+}
+
+// OAuthAuthorizationResponse 用户已授权的第三方应用
+type OAuthAuthorizationResponse struct {
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateAPIKeyRequest 创建 API 密钥请求结构
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"` // 0 表示永不过期
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"blog/config"
+	"blog/utils"
+
+	"gorm.io/gorm"
+)
+
+// PostSlugHistory 文章历史 slug，用于旧链接 301 跳转
+type PostSlugHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Slug      string    `json:"slug" gorm:"not null;size:200;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (PostSlugHistory) TableName() string {
+	return "post_slug_histories"
+}
+
+// SlugTaken 检查 slug 是否已被其他文章占用（包括历史 slug 和已软删除的文章）
+func SlugTaken(tx *gorm.DB, slug string, postID uint) (bool, error) {
+	var count int64
+	if err := tx.Unscoped().Model(&Post{}).
+		Where("slug = ? AND id <> ?", slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := tx.Model(&PostSlugHistory{}).
+		Where("slug = ? AND post_id <> ?", slug, postID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UniqueSlug 在 base 后追加序号直到不与其他文章冲突
+//
+// 追加序号前先截短 base，保证结果不超过 slug 最大长度。
+func UniqueSlug(tx *gorm.DB, base string, postID uint) (string, error) {
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := SlugTaken(tx, slug, postID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		slug = utils.TruncateSlug(base, config.Slug.MaxLength-len(suffix)) + suffix
+	}
+}
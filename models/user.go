@@ -18,15 +18,44 @@ type User struct {
 	Avatar    string         `json:"avatar" gorm:"type:varchar(255)"`
 	Bio       string         `json:"bio" gorm:"type:text"`
 	Status    int8           `json:"status" gorm:"type:tinyint;default:1;index"` // 1-正常，0-禁用
+	Role      string         `json:"role" gorm:"type:varchar(20);default:user;not null"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	TokenVersion        uint       `json:"-" gorm:"not null;default:0"`        // 递增后此前签发的令牌全部失效
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at" gorm:"index"` // 申请注销后的删除时间，为空表示未申请
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`                  // 邮箱验证时间，为空表示未验证
+	TOTPSecret          string     `json:"-" gorm:"size:64"`                   // Base32 密钥，TOTPEnabledAt 为空时表示尚未完成绑定
+	TOTPEnabledAt       *time.Time `json:"-"`                                  // 两步验证启用时间，为空表示未启用
+	TOTPLastStep        int64      `json:"-" gorm:"not null;default:0"`        // 最近一次使用的时间步，防止验证码重放
+
 	// 关联关系
 	Posts    []Post    `json:"posts,omitempty" gorm:"foreignKey:UserID"`
 	Comments []Comment `json:"comments,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// 用户角色
+const (
+	RoleUser  = "user"  // 普通用户
+	RoleAdmin = "admin" // 管理员
+)
+
+// IsEmailVerified 邮箱是否已验证
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// MFAEnabled 是否已启用两步验证
+func (u *User) MFAEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // TableName 指定表名
 func (User) TableName() string {
 	return "users"
@@ -58,8 +87,13 @@ type UserResponse struct {
 	Avatar    string    `json:"avatar"`
 	Bio       string    `json:"bio"`
 	Status    int8      `json:"status"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"` // 已申请注销时返回删除时间
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	MFAEnabled          bool       `json:"mfa_enabled"`
 }
 
 // ToResponse 转换为响应格式
@@ -72,7 +106,12 @@ func (u *User) ToResponse() UserResponse {
 		Avatar:    u.Avatar,
 		Bio:       u.Bio,
 		Status:    u.Status,
+		Role:      u.Role,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		EmailVerifiedAt:     u.EmailVerifiedAt,
+		MFAEnabled:          u.MFAEnabled(),
 	}
 }
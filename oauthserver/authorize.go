@@ -0,0 +1,216 @@
+package oauthserver
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Authorization 校验通过的授权请求
+type Authorization struct {
+	Client        *models.OAuthClient
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	CodeChallenge string
+}
+
+// ValidateAuthorize 校验授权请求
+//
+// client_id 或 redirect_uri 无效时不能跳转回第三方应用，此时返回的 Authorization 为空；
+// 其他错误同时返回 Authorization，应通过 ErrorRedirect 把错误带回第三方应用。
+func ValidateAuthorize(db *gorm.DB, req *models.OAuthAuthorizeRequest) (*Authorization, error) {
+	var client models.OAuthClient
+	if err := db.Where("client_id = ?", req.ClientID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newError(ErrCodeInvalidClient, "客户端不存在")
+		}
+		return nil, err
+	}
+
+	redirectURI := req.RedirectURI
+	registered := client.RedirectURIList()
+	if redirectURI == "" && len(registered) == 1 {
+		redirectURI = registered[0]
+	}
+	if !contains(registered, redirectURI) {
+		return nil, newError(ErrCodeInvalidRequest, "回调地址未登记")
+	}
+
+	auth := &Authorization{
+		Client:        &client,
+		RedirectURI:   redirectURI,
+		State:         req.State,
+		CodeChallenge: req.CodeChallenge,
+	}
+	if req.ResponseType != "code" {
+		return auth, newError(ErrCodeUnsupportedResponseType, "只支持授权码模式（response_type=code）")
+	}
+	if req.CodeChallengeMethod != "S256" || len(req.CodeChallenge) != 43 {
+		return auth, newError(ErrCodeInvalidRequest, "必须使用 PKCE（code_challenge_method=S256）")
+	}
+
+	scopes, err := resolveScopes(&client, req.Scope)
+	if err != nil {
+		return auth, err
+	}
+	auth.Scopes = scopes
+	return auth, nil
+}
+
+// ErrorRedirect 把错误附加到回调地址
+func (a *Authorization) ErrorRedirect(err *Error) string {
+	params := url.Values{"error": {err.Code}, "error_description": {err.Description}}
+	if a.State != "" {
+		params.Set("state", a.State)
+	}
+	return appendQuery(a.RedirectURI, params)
+}
+
+// ConsentGranted 用户是否已同意过本次申请的全部权限
+func ConsentGranted(db *gorm.DB, userID uint, auth *Authorization) (bool, error) {
+	var consent models.OAuthConsent
+	err := db.Where("user_id = ? AND client_id = ?", userID, auth.Client.ClientID).First(&consent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	granted := strings.Fields(consent.Scope)
+	for _, scope := range auth.Scopes {
+		if !contains(granted, scope) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Approve 用户同意授权：记录授权的权限并签发授权码，返回带授权码的回调地址
+func Approve(db *gorm.DB, userID uint, auth *Authorization) (string, error) {
+	code, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+			logrus.WithError(err).Warn("清理过期的授权码失败")
+		}
+		if err := tx.Create(&models.OAuthAuthorizationCode{
+			CodeHash:      hashToken(code),
+			ClientID:      auth.Client.ClientID,
+			UserID:        userID,
+			RedirectURI:   auth.RedirectURI,
+			Scope:         strings.Join(auth.Scopes, " "),
+			CodeChallenge: auth.CodeChallenge,
+			ExpiresAt:     time.Now().Add(config.OAuthServer.CodeTTL),
+		}).Error; err != nil {
+			return err
+		}
+		return saveConsent(tx, userID, auth)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"client_id": auth.Client.ClientID,
+		"scope":     strings.Join(auth.Scopes, " "),
+	}).Info("用户授权第三方应用")
+
+	params := url.Values{"code": {code}}
+	if auth.State != "" {
+		params.Set("state", auth.State)
+	}
+	return appendQuery(auth.RedirectURI, params), nil
+}
+
+// RevokeConsent 用户取消对第三方应用的授权，同时撤销已签发的令牌
+func RevokeConsent(db *gorm.DB, userID uint, clientID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&models.OAuthConsent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+			return err
+		}
+		return revokeAll(tx, userID, clientID)
+	})
+}
+
+// saveConsent 合并保存用户已同意的权限
+func saveConsent(tx *gorm.DB, userID uint, auth *Authorization) error {
+	var consent models.OAuthConsent
+	err := tx.Where("user_id = ? AND client_id = ?", userID, auth.Client.ClientID).First(&consent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&models.OAuthConsent{
+			UserID:   userID,
+			ClientID: auth.Client.ClientID,
+			Scope:    strings.Join(auth.Scopes, " "),
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	granted := strings.Fields(consent.Scope)
+	for _, scope := range auth.Scopes {
+		if !contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+	consent.Scope = strings.Join(granted, " ")
+	return tx.Save(&consent).Error
+}
+
+// resolveScopes 解析申请的权限，未指定时使用客户端允许的全部权限
+func resolveScopes(client *models.OAuthClient, raw string) ([]string, error) {
+	allowed := client.ScopeList()
+	requested := strings.Fields(raw)
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	var scopes []string
+	for _, scope := range requested {
+		if !contains(allowed, scope) {
+			return nil, newError(ErrCodeInvalidScope, "不支持的权限: "+scope)
+		}
+		if !contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// appendQuery 在地址上追加查询参数，保留原有参数
+func appendQuery(base string, params url.Values) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package oauthserver
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRedirectURI 回调地址不符合要求
+	ErrInvalidRedirectURI = errors.New("回调地址无效")
+	// ErrUnknownScope 申请了不存在的权限
+	ErrUnknownScope = errors.New("不支持的权限")
+)
+
+// RegisterClient 注册第三方应用，返回应用和客户端密钥（公开客户端没有密钥）
+//
+// 密钥只在注册时返回一次，入库的是摘要。
+func RegisterClient(db *gorm.DB, userID uint, req *models.RegisterOAuthClientRequest) (*models.OAuthClient, string, error) {
+	for _, uri := range req.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, "", err
+		}
+	}
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = allScopes()
+	}
+	for _, scope := range scopes {
+		if _, ok := LookupScope(scope); !ok {
+			return nil, "", fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	client := &models.OAuthClient{
+		ClientID:     hex.EncodeToString(id),
+		UserID:       userID,
+		Name:         req.Name,
+		RedirectURIs: strings.Join(req.RedirectURIs, "\n"),
+		Scopes:       strings.Join(scopes, " "),
+		Public:       req.Public,
+	}
+
+	var secret string
+	if !req.Public {
+		var err error
+		if secret, err = newToken(); err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = hashToken(secret)
+	}
+
+	if err := db.Create(client).Error; err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// DeleteClient 删除第三方应用及其授权码、令牌和用户授权记录
+func DeleteClient(db *gorm.DB, client *models.OAuthClient) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", client.ClientID).Delete(&models.OAuthToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", client.ClientID).Delete(&models.OAuthAuthorizationCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", client.ClientID).Delete(&models.OAuthConsent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(client).Error
+	})
+}
+
+// AuthenticateClient 校验客户端身份，公开客户端只需提供 client_id
+func AuthenticateClient(db *gorm.DB, clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, newError(ErrCodeInvalidClient, "缺少 client_id")
+	}
+
+	var client models.OAuthClient
+	if err := db.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newError(ErrCodeInvalidClient, "客户端不存在")
+		}
+		return nil, err
+	}
+
+	if !client.Public {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+			return nil, newError(ErrCodeInvalidClient, "客户端认证失败")
+		}
+	}
+	return &client, nil
+}
+
+// validateRedirectURI 回调地址必须是 https、本机 http 或反向域名形式的自定义协议（RFC 8252），且不能带片段
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidRedirectURI, raw)
+	}
+
+	switch u.Scheme {
+	case "https":
+		if u.Host == "" {
+			return fmt.Errorf("%w: %s", ErrInvalidRedirectURI, raw)
+		}
+	case "http":
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+		default:
+			return fmt.Errorf("%w: http 只允许本机地址: %s", ErrInvalidRedirectURI, raw)
+		}
+	default:
+		if !strings.Contains(u.Scheme, ".") {
+			return fmt.Errorf("%w: 自定义协议需使用反向域名形式: %s", ErrInvalidRedirectURI, raw)
+		}
+	}
+	return nil
+}
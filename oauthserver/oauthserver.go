@@ -0,0 +1,113 @@
+// Package oauthserver 让本站作为 OAuth2 授权服务器，供第三方编辑器和移动应用代表用户访问接口
+//
+// 只支持授权码模式，且所有客户端都必须使用 PKCE（S256）。访问令牌和刷新令牌是随机字符串，
+// 只以摘要入库，因此可以随时撤销和查询状态。
+package oauthserver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// 第三方应用可以申请的权限
+const (
+	ScopePostsWrite    = "posts:write"    // 发布、修改和删除文章
+	ScopeCommentsWrite = "comments:write" // 发表和删除评论
+	ScopeProfileRead   = "profile:read"   // 读取个人信息
+)
+
+// Scope 权限及其说明
+type Scope struct {
+	Name        string
+	Description string
+}
+
+// Scopes 全部权限，按授权确认页面的展示顺序排列
+var Scopes = []Scope{
+	{Name: ScopeProfileRead, Description: "读取你的个人信息（用户名、昵称、邮箱）"},
+	{Name: ScopePostsWrite, Description: "以你的身份发布、修改和删除文章"},
+	{Name: ScopeCommentsWrite, Description: "以你的身份发表和删除评论"},
+}
+
+// LookupScope 按名称查找权限
+func LookupScope(name string) (Scope, bool) {
+	for _, scope := range Scopes {
+		if scope.Name == name {
+			return scope, true
+		}
+	}
+	return Scope{}, false
+}
+
+// allScopes 全部权限名称
+func allScopes() []string {
+	names := make([]string, 0, len(Scopes))
+	for _, scope := range Scopes {
+		names = append(names, scope.Name)
+	}
+	return names
+}
+
+// OAuth2 标准错误码（RFC 6749 第 4.1.2.1 节和第 5.2 节）
+const (
+	ErrCodeInvalidRequest          = "invalid_request"
+	ErrCodeInvalidClient           = "invalid_client"
+	ErrCodeInvalidGrant            = "invalid_grant"
+	ErrCodeUnauthorizedClient      = "unauthorized_client"
+	ErrCodeUnsupportedGrantType    = "unsupported_grant_type"
+	ErrCodeUnsupportedResponseType = "unsupported_response_type"
+	ErrCodeInvalidScope            = "invalid_scope"
+	ErrCodeAccessDenied            = "access_denied"
+)
+
+// Error OAuth2 协议错误，按标准格式返回给第三方应用
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// Status 令牌端点返回的 HTTP 状态码
+func (e *Error) Status() int {
+	if e.Code == ErrCodeInvalidClient {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
+
+func newError(code, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// 令牌前缀，用于区分第三方应用令牌和本站登录令牌
+const (
+	AccessTokenPrefix  = "oat_"
+	RefreshTokenPrefix = "ort_"
+)
+
+// IsAccessToken 是否为第三方应用访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// newToken 生成 32 字节随机令牌
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken 令牌只以 SHA-256 摘要形式入库
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
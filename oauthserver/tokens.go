@@ -0,0 +1,320 @@
+package oauthserver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrTokenInvalid 访问令牌不存在、已过期或已撤销
+var ErrTokenInvalid = errors.New("令牌无效或已过期")
+
+// TokenResponse 令牌端点响应（RFC 6749 第 5.1 节）
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// Introspection 令牌状态（RFC 7662 第 2.2 节）
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+// ExchangeCode 用授权码换取令牌，授权码只能使用一次
+func ExchangeCode(db *gorm.DB, client *models.OAuthClient, code, redirectURI, verifier string) (*TokenResponse, error) {
+	var record models.OAuthAuthorizationCode
+	if err := db.Where("code_hash = ? AND expires_at > ?", hashToken(code), time.Now()).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newError(ErrCodeInvalidGrant, "授权码无效或已过期")
+		}
+		return nil, err
+	}
+	result := db.Delete(&models.OAuthAuthorizationCode{}, record.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, newError(ErrCodeInvalidGrant, "授权码无效或已过期")
+	}
+
+	if record.ClientID != client.ClientID {
+		return nil, newError(ErrCodeInvalidGrant, "授权码不属于该客户端")
+	}
+	if redirectURI != "" && redirectURI != record.RedirectURI {
+		return nil, newError(ErrCodeInvalidGrant, "回调地址与授权请求不一致")
+	}
+	if !verifyPKCE(verifier, record.CodeChallenge) {
+		return nil, newError(ErrCodeInvalidGrant, "code_verifier 校验失败")
+	}
+
+	user, err := activeUser(db, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return issue(db, client.ClientID, user, record.Scope)
+}
+
+// Refresh 用刷新令牌换取新令牌，旧的刷新令牌随即失效
+//
+// 已轮换的刷新令牌再次出现说明可能被盗用，此时撤销该应用代表该用户的全部令牌。
+func Refresh(db *gorm.DB, client *models.OAuthClient, refreshToken, scope string) (*TokenResponse, error) {
+	var record models.OAuthToken
+	if err := db.Where("refresh_hash = ?", hashToken(refreshToken)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newError(ErrCodeInvalidGrant, "刷新令牌无效")
+		}
+		return nil, err
+	}
+	if record.ClientID != client.ClientID {
+		return nil, newError(ErrCodeInvalidGrant, "刷新令牌不属于该客户端")
+	}
+
+	if record.RevokedAt != nil {
+		logrus.WithFields(logrus.Fields{
+			"user_id":   record.UserID,
+			"client_id": record.ClientID,
+		}).Warn("已失效的刷新令牌被重复使用，撤销该应用的全部令牌")
+		if err := revokeAll(db, record.UserID, record.ClientID); err != nil {
+			return nil, err
+		}
+		return nil, newError(ErrCodeInvalidGrant, "刷新令牌已失效")
+	}
+	if record.RefreshExpiresAt == nil || record.RefreshExpiresAt.Before(time.Now()) {
+		return nil, newError(ErrCodeInvalidGrant, "刷新令牌已过期")
+	}
+
+	granted := strings.Fields(record.Scope)
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, s := range requested {
+			if !contains(granted, s) {
+				return nil, newError(ErrCodeInvalidScope, "不能申请超出原授权的权限: "+s)
+			}
+		}
+		granted = requested
+	}
+
+	user, err := activeUser(db, record.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != record.TokenVersion {
+		return nil, newError(ErrCodeInvalidGrant, "授权已失效，请重新授权")
+	}
+
+	result := db.Model(&models.OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", record.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, newError(ErrCodeInvalidGrant, "刷新令牌已失效")
+	}
+
+	return issue(db, client.ClientID, user, strings.Join(granted, " "))
+}
+
+// Authenticate 校验访问令牌，返回令牌记录和对应用户
+func Authenticate(db *gorm.DB, accessToken string) (*models.OAuthToken, *models.User, error) {
+	var record models.OAuthToken
+	if err := db.Where("access_hash = ?", hashToken(accessToken)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTokenInvalid
+		}
+		return nil, nil, err
+	}
+	if record.RevokedAt != nil || record.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrTokenInvalid
+	}
+
+	var user models.User
+	if err := db.Select("id", "username", "status", "token_version").First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTokenInvalid
+		}
+		return nil, nil, err
+	}
+	if user.Status != 1 || user.TokenVersion != record.TokenVersion {
+		return nil, nil, ErrTokenInvalid
+	}
+	return &record, &user, nil
+}
+
+// Revoke 撤销令牌（RFC 7009），访问令牌和刷新令牌都可以撤销
+//
+// 令牌不存在或不属于该客户端时同样视为成功，不向调用方透露令牌是否有效。
+func Revoke(db *gorm.DB, client *models.OAuthClient, token string) error {
+	record, err := findToken(db, token)
+	if err != nil || record == nil || record.ClientID != client.ClientID {
+		return err
+	}
+	return db.Model(&models.OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", record.ID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Introspect 查询令牌状态（RFC 7662），只能查询签发给本客户端的令牌
+func Introspect(db *gorm.DB, client *models.OAuthClient, token string) (*Introspection, error) {
+	record, err := findToken(db, token)
+	if err != nil {
+		return nil, err
+	}
+	inactive := &Introspection{Active: false}
+	if record == nil || record.ClientID != client.ClientID || record.RevokedAt != nil {
+		return inactive, nil
+	}
+
+	isRefresh := strings.HasPrefix(token, RefreshTokenPrefix)
+	expiresAt := record.ExpiresAt
+	if isRefresh {
+		if record.RefreshExpiresAt == nil {
+			return inactive, nil
+		}
+		expiresAt = *record.RefreshExpiresAt
+	}
+	if expiresAt.Before(time.Now()) {
+		return inactive, nil
+	}
+
+	var user models.User
+	if err := db.Select("id", "username", "status", "token_version").First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+	if user.Status != 1 || user.TokenVersion != record.TokenVersion {
+		return inactive, nil
+	}
+
+	result := &Introspection{
+		Active:    true,
+		Scope:     record.Scope,
+		ClientID:  record.ClientID,
+		Username:  user.Username,
+		TokenType: "Bearer",
+		Exp:       expiresAt.Unix(),
+		Iat:       record.CreatedAt.Unix(),
+		Sub:       strconv.FormatUint(uint64(user.ID), 10),
+		Iss:       config.OAuthServer.Issuer,
+	}
+	if isRefresh {
+		result.TokenType = "refresh_token"
+	}
+	return result, nil
+}
+
+// findToken 按访问令牌或刷新令牌查找记录，不存在时返回 nil
+func findToken(db *gorm.DB, token string) (*models.OAuthToken, error) {
+	column := "access_hash"
+	if strings.HasPrefix(token, RefreshTokenPrefix) {
+		column = "refresh_hash"
+	} else if !IsAccessToken(token) {
+		return nil, nil
+	}
+
+	var record models.OAuthToken
+	if err := db.Where(column+" = ?", hashToken(token)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// issue 签发访问令牌和刷新令牌，同时清理已过期的令牌记录
+func issue(db *gorm.DB, clientID string, user *models.User, scope string) (*TokenResponse, error) {
+	accessToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	accessToken = AccessTokenPrefix + accessToken
+	refreshToken = RefreshTokenPrefix + refreshToken
+
+	now := time.Now()
+	if err := db.Where("expires_at < ? AND (refresh_expires_at IS NULL OR refresh_expires_at < ?)", now, now).
+		Delete(&models.OAuthToken{}).Error; err != nil {
+		logrus.WithError(err).Warn("清理过期的第三方应用令牌失败")
+	}
+
+	refreshHash := hashToken(refreshToken)
+	refreshExpiresAt := now.Add(config.OAuthServer.RefreshTokenTTL)
+	if err := db.Create(&models.OAuthToken{
+		AccessHash:       hashToken(accessToken),
+		RefreshHash:      &refreshHash,
+		ClientID:         clientID,
+		UserID:           user.ID,
+		Scope:            scope,
+		TokenVersion:     user.TokenVersion,
+		ExpiresAt:        now.Add(config.OAuthServer.AccessTokenTTL),
+		RefreshExpiresAt: &refreshExpiresAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.OAuthServer.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}, nil
+}
+
+// revokeAll 撤销应用代表用户持有的全部令牌
+func revokeAll(db *gorm.DB, userID uint, clientID string) error {
+	return db.Model(&models.OAuthToken{}).
+		Where("user_id = ? AND client_id = ? AND revoked_at IS NULL", userID, clientID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// activeUser 获取仍可正常使用的用户
+func activeUser(db *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newError(ErrCodeInvalidGrant, "用户不存在")
+		}
+		return nil, err
+	}
+	if user.Status != 1 {
+		return nil, newError(ErrCodeInvalidGrant, "账户已被禁用")
+	}
+	return &user, nil
+}
+
+// verifyPKCE 校验 code_verifier（RFC 7636 第 4.6 节，S256）
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,129 @@
+// Package ratelimit 实现令牌桶限流
+//
+// 每个限流键对应一个容量为 Limit 的令牌桶，每 Period 匀速补满；请求消耗一个令牌，桶空时拒绝。
+// 计数保存在 Store 中，单实例使用内存存储，多实例部署时实现 Store 接口接入共享存储。
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy 限流策略
+type Policy struct {
+	Name   string        // 策略名称，作为限流键的前缀，不同策略互不影响
+	Limit  int           // 令牌桶容量，即允许的突发请求数
+	Period time.Duration // 令牌桶从空到满的时长
+}
+
+// Enabled 策略是否生效，Limit 或 Period 不大于 0 时不限流
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// rate 每秒补充的令牌数
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result 一次请求的限流结果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // 剩余可用的请求数
+	Reset      time.Duration // 令牌桶补满所需时间
+	RetryAfter time.Duration // 被拒绝时，距离下一个令牌可用的时间
+}
+
+// Store 限流计数存储
+//
+// 多实例部署时需要所有实例共享同一存储（如 Redis），Take 必须原子地完成“补充令牌并消耗一个”。
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Bucket 令牌桶状态，供 Store 实现保存
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take 按经过的时间补充令牌并尝试消耗一个，返回更新后的状态和结果
+//
+// 共享存储的实现可以读出 Bucket、调用 Take 后在同一事务中写回。
+func (b Bucket) Take(policy Policy, now time.Time) (Bucket, Result) {
+	capacity := float64(policy.Limit)
+	tokens := capacity
+	if !b.Updated.IsZero() {
+		tokens = math.Min(capacity, b.Tokens+now.Sub(b.Updated).Seconds()*policy.rate())
+	}
+
+	result := Result{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / policy.rate())
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / policy.rate())
+	return Bucket{Tokens: tokens, Updated: now}, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// sweepInterval 内存存储清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 进程内存储，只适用于单实例部署
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	period time.Duration
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket), lastSweep: time.Now()}
+}
+
+// Take 实现 Store
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	now := time.Now()
+	key = policy.Name + ":" + key
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{period: policy.Period}
+		s.buckets[key] = b
+	}
+	var result Result
+	b.Bucket, result = b.Take(policy, now)
+	return result, nil
+}
+
+// sweep 删除已经补满的令牌桶，补满的桶与不存在的桶等价
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.Updated) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -1,8 +1,18 @@
 package routes
 
 import (
+	"os"
+	"path/filepath"
+
+	"blog/cache"
+	"blog/config"
 	"blog/controllers"
+	"blog/database"
+	"blog/metrics"
 	"blog/middleware"
+	"blog/oauthserver"
+	"blog/seo"
+	"blog/web"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -16,73 +26,221 @@ func SetupRoutes() *gin.Engine {
 	// 创建gin引擎
 	r := gin.New()
 
-	// 使用中间件
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	// 使用中间件：请求 ID 需在最前面，之后的日志都带有 request_id
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.AccessLog())
+	if config.Metrics.Enabled {
+		r.Use(middleware.Metrics())
+	}
+	r.Use(middleware.Recovery())
+
+	// 只信任配置的反向代理传来的客户端 IP，避免伪造 X-Forwarded-For 绕过限流
+	if err := r.SetTrustedProxies(config.RateLimit.TrustedProxies); err != nil {
+		logrus.WithError(err).Error("可信代理配置无效")
+	}
 
 	// 创建控制器实例
 	userController := controllers.NewUserController()
-	postController := controllers.NewPostController()
-	commentController := controllers.NewCommentController()
+	responseCache := cache.Init(config.Cache)
+	postController := controllers.NewPostController(responseCache)
+	commentController := controllers.NewCommentController(responseCache)
+	markdownController := controllers.NewMarkdownController()
+	jobController := controllers.NewJobController()
+	importController := controllers.NewImportController()
+	backupController := controllers.NewBackupController()
+	mfaController := controllers.NewMFAController()
+	oauthController := controllers.NewOAuthController()
+	challengeController := controllers.NewChallengeController()
+	healthController := controllers.NewHealthController()
+	seoController := controllers.NewSEOController(seo.InitSitemap(database.GetDB()))
+
+	// 搜索引擎相关路由
+	r.GET("/robots.txt", seoController.Robots)          // robots.txt
+	r.GET("/sitemap.xml", seoController.Sitemap)        // 站点地图（或索引）
+	r.GET("/sitemaps/:name", seoController.SitemapPart) // 站点地图分片
+	r.GET("/feed.xml", seoController.Feed)              // RSS订阅
+
+	// 监控指标：配置了单独的管理地址时由 main 在该地址提供，否则需要令牌才能访问
+	if config.Metrics.Enabled && config.Metrics.Addr == "" {
+		if config.Metrics.Token != "" {
+			r.GET("/metrics", middleware.MetricsAuth(config.Metrics.Token), gin.WrapH(metrics.Handler()))
+		} else {
+			logrus.Warn("未配置 METRICS_ADDR 或 METRICS_TOKEN，不提供 /metrics")
+		}
+	}
+
+	// 存活和就绪检查
+	r.GET("/livez", healthController.Livez)
+	r.GET("/readyz", healthController.Readyz)
+
+	// 授权服务器元数据
+	r.GET("/.well-known/oauth-authorization-server", oauthController.Metadata)
+	r.GET("/.well-known/jwks.json", oauthController.JWKS)
+
+	// 限流策略
+	authLimit := middleware.RateLimit("auth", config.RateLimit.Auth, middleware.KeyByIP)
+	registerLimit := middleware.RateLimit("register", config.RateLimit.Register, middleware.KeyByIP)
+	tokenLimit := middleware.RateLimit("oauth_token", config.RateLimit.OAuthToken, middleware.KeyByIP)
+	postLimit := middleware.RateLimit("post", config.RateLimit.Post, middleware.KeyByUser)
+	commentLimit := middleware.RateLimit("comment", config.RateLimit.Comment, middleware.KeyByUser)
+
+	// 出现风险信号时要求人机验证
+	registerChallenge := middleware.RequireChallenge(config.ActionRegister)
+	commentChallenge := middleware.RequireChallenge(config.ActionComment)
+
+	// 第三方账号绑定回调需要识别提交者，但登录回调没有令牌
+	userAuth := middleware.OptionalAuthMiddleware()
 
 	// API版本分组
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.RateLimit("api", config.RateLimit.API, middleware.KeyByIP))
 
 	// 认证相关路由（无需认证）
 	auth := v1.Group("/auth")
 	{
-		auth.POST("/register", userController.Register) // 用户注册
-		auth.POST("/login", userController.Login)       // 用户登录
+		auth.POST("/register", registerLimit, registerChallenge, userController.Register) // 用户注册
+		auth.POST("/login", authLimit, userController.Login)                              // 用户登录
+		auth.POST("/login/mfa", authLimit, mfaController.VerifyLogin)                     // 两步验证完成登录
+		auth.POST("/passkey/begin", authLimit, userController.BeginPasskeyLogin)          // 开始通行密钥登录
+		auth.POST("/passkey/finish", authLimit, userController.FinishPasskeyLogin)        // 完成通行密钥登录
+		auth.GET("/oauth/providers", userController.OAuthProviders)                       // 可用的第三方登录方式
+		auth.GET("/oauth/:provider", userController.BeginOAuthLogin)                      // 跳转到第三方登录
+		auth.POST("/oauth/callback", authLimit, userAuth, userController.OAuthCallback)   // 完成第三方登录或绑定
+		auth.POST("/password/forgot", authLimit, userController.ForgotPassword)           // 发送找回密码邮件
+		auth.POST("/password/reset", authLimit, userController.ResetPassword)             // 重置密码
+		auth.POST("/email/resend", authLimit, userController.ResendVerification)          // 重新发送验证邮件
+		auth.POST("/email/verify", authLimit, userController.VerifyEmail)                 // 确认邮件中的验证链接
+	}
+
+	// 人机验证题目（注册、评论被要求验证时使用）
+	v1.GET("/challenge", challengeController.Issue)
+
+	// 授权服务器（第三方应用代表用户访问接口）
+	oauth := v1.Group("/oauth")
+	{
+		oauth.POST("/token", tokenLimit, oauthController.Token)           // 换取或刷新令牌
+		oauth.POST("/revoke", tokenLimit, oauthController.Revoke)         // 撤销令牌
+		oauth.POST("/introspect", tokenLimit, oauthController.Introspect) // 查询令牌状态
+
+		// 授权确认页面和应用管理（需要认证）
+		oauth.GET("/authorize", middleware.AuthMiddleware(), oauthController.AuthorizeInfo)            // 查询授权信息
+		oauth.POST("/authorize", middleware.AuthMiddleware(), oauthController.Authorize)               // 确认或拒绝授权
+		oauth.GET("/clients", middleware.AuthMiddleware(), oauthController.ListClients)                // 我注册的应用
+		oauth.POST("/clients", middleware.AuthMiddleware(), oauthController.RegisterClient)            // 注册应用
+		oauth.DELETE("/clients/:client_id", middleware.AuthMiddleware(), oauthController.DeleteClient) // 删除应用
 	}
 
+	// 获取个人信息（同时接受带 profile:read 权限的第三方应用令牌）
+	v1.GET("/user/profile", middleware.AuthMiddleware(oauthserver.ScopeProfileRead), userController.GetProfile)
+
 	// 用户相关路由（需要认证）
 	user := v1.Group("/user")
 	user.Use(middleware.AuthMiddleware()) // 应用认证中间件
 	{
-		user.GET("/profile", userController.GetProfile)      // 获取个人信息
-		user.PUT("/profile", userController.UpdateProfile)   // 更新个人信息
-		user.PUT("/password", userController.ChangePassword) // 修改密码
+		user.PUT("/profile", userController.UpdateProfile)           // 更新个人信息
+		user.PUT("/password", userController.ChangePassword)         // 修改密码
+		user.PUT("/email", userController.ChangeEmail)               // 申请更换邮箱
+		user.POST("/export", userController.ExportData)              // 导出个人数据
+		user.GET("/export/:id", userController.DownloadExport)       // 下载个人数据
+		user.DELETE("", userController.DeleteAccount)                // 申请注销账号
+		user.POST("/deletion/cancel", userController.CancelDeletion) // 撤销注销申请
+
+		user.GET("/mfa", mfaController.GetStatus)                               // 两步验证状态
+		user.POST("/mfa/totp", mfaController.SetupTOTP)                         // 生成认证器密钥
+		user.POST("/mfa/totp/enable", mfaController.EnableTOTP)                 // 验证并启用两步验证
+		user.DELETE("/mfa", mfaController.DisableMFA)                           // 关闭两步验证
+		user.POST("/mfa/recovery-codes", mfaController.RegenerateRecoveryCodes) // 重新生成恢复码
+
+		user.GET("/passkeys", userController.ListPasskeys)                               // 通行密钥列表
+		user.POST("/passkeys/register/begin", userController.BeginPasskeyRegistration)   // 开始注册通行密钥
+		user.POST("/passkeys/register/finish", userController.FinishPasskeyRegistration) // 完成注册通行密钥
+		user.DELETE("/passkeys/:id", userController.DeletePasskey)                       // 删除通行密钥
+
+		user.GET("/identities", userController.ListIdentities)          // 已绑定的第三方账号
+		user.POST("/identities/:provider", userController.LinkIdentity) // 绑定第三方账号
+		user.DELETE("/identities/:id", userController.UnlinkIdentity)   // 解除第三方账号绑定
+
+		user.GET("/api-keys", userController.ListAPIKeys)         // API 密钥列表
+		user.POST("/api-keys", userController.CreateAPIKey)       // 创建 API 密钥
+		user.DELETE("/api-keys/:id", userController.DeleteAPIKey) // 吊销 API 密钥
+
+		user.GET("/authorizations", oauthController.ListAuthorizations)                // 已授权的第三方应用
+		user.DELETE("/authorizations/:client_id", oauthController.RevokeAuthorization) // 取消授权
 	}
 
+	// 未验证邮箱的用户按配置禁止发布文章
+	requirePost := middleware.RequireVerifiedEmail(config.ActionPost)
+
 	// 文章相关路由
 	posts := v1.Group("/posts")
 	{
 		// 公共接口（无需认证）
-		posts.GET("", postController.GetPosts)    // 获取文章列表
-		posts.GET("/:id", postController.GetPost) // 获取文章详情
-
-		// 需要认证的接口
-		posts.Use(middleware.AuthMiddleware())
-		posts.POST("", postController.CreatePost)       // 创建文章
-		posts.PUT("/:id", postController.UpdatePost)    // 更新文章
-		posts.DELETE("/:id", postController.DeletePost) // 删除文章
+		posts.GET("", postController.GetPosts)                    // 获取文章列表
+		posts.GET("/:id", postController.GetPost)                 // 获取文章详情
+		posts.GET("/by-slug/:slug", postController.GetPostBySlug) // 通过slug获取文章详情
+
+		// 需要认证的接口（同时接受带 posts:write 权限的第三方应用令牌）
+		posts.Use(middleware.AuthMiddleware(oauthserver.ScopePostsWrite))
+		posts.POST("", postLimit, requirePost, postController.CreatePost)             // 创建文章
+		posts.PUT("/:id", requirePost, postController.UpdatePost)                     // 更新文章
+		posts.DELETE("/:id", postController.DeletePost)                               // 删除文章
+		posts.POST("/import", postLimit, requirePost, markdownController.ImportPosts) // 导入Markdown文章
+		posts.GET("/export", markdownController.ExportPosts)                          // 导出Markdown文章
 	}
 
 	// 评论相关路由
-	comments := v1.Group("/posts/:post_id/comments")
+	comments := v1.Group("/posts/:id/comments")
 	{
 		// 公共接口（无需认证）
 		comments.GET("", commentController.GetComments) // 获取评论列表
 
-		// 需要认证的接口
-		comments.Use(middleware.AuthMiddleware())
-		comments.POST("", commentController.CreateComment) // 创建评论
+		// 需要认证的接口（同时接受带 comments:write 权限的第三方应用令牌）
+		comments.Use(middleware.AuthMiddleware(oauthserver.ScopeCommentsWrite))
+		comments.POST("", commentLimit, commentChallenge, middleware.RequireVerifiedEmail(config.ActionComment), commentController.CreateComment) // 创建评论
 	}
 
 	// 评论管理路由（需要认证）
 	commentManage := v1.Group("/comments")
-	commentManage.Use(middleware.AuthMiddleware())
+	commentManage.Use(middleware.AuthMiddleware(oauthserver.ScopeCommentsWrite))
 	{
 		commentManage.DELETE("/:id", commentController.DeleteComment) // 删除评论
 	}
 
-	// 健康检查接口
-	v1.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"message": "博客系统运行正常",
-		})
-	})
+	// 后台任务进度（需要认证）
+	jobsGroup := v1.Group("/jobs")
+	jobsGroup.Use(middleware.AuthMiddleware())
+	{
+		jobsGroup.GET("/:id", jobController.GetJob) // 查询任务进度
+	}
+
+	// 管理接口（需要管理员权限）
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.POST("/import/wordpress", importController.ImportWordPress) // 导入WordPress WXR
+		admin.POST("/backups", backupController.CreateBackup)             // 创建备份
+		admin.GET("/backups", backupController.ListBackups)               // 备份列表
+		admin.GET("/backups/:name", backupController.DownloadBackup)      // 下载备份
+		admin.POST("/restore", backupController.RestoreBackup)            // 从备份恢复
+	}
+
+	// 健康检查接口（与 /readyz 相同）
+	v1.GET("/health", healthController.Readyz)
+
+	// 导入的媒体文件
+	if info, err := os.Stat(config.Storage.MediaDir); err == nil && info.IsDir() {
+		r.Static(config.Storage.MediaURL, config.Storage.MediaDir)
+	}
+
+	// 前台页面（与 /api/v1 共用同一个 gin 引擎）
+	renderer, err := web.NewRenderer(filepath.Join(config.Web.ThemeDir, config.Web.Theme), config.Web.Debug)
+	if err != nil {
+		logrus.WithError(err).Error("加载主题失败，前台页面未启用")
+	} else {
+		web.NewHandler(renderer).Register(r)
+		seo.DefaultSitemap().EnablePages() // 页面可访问后站点地图才输出这些地址
+	}
 
 	logrus.Info("路由配置完成")
 	return r
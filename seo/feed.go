@@ -0,0 +1,80 @@
+package seo
+
+import (
+	"encoding/xml"
+	"time"
+
+	"blog/config"
+	"blog/models"
+
+	"gorm.io/gorm"
+)
+
+// FeedSize RSS 中包含的最新文章数
+const FeedSize = 20
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	Author      string `xml:"author,omitempty"`
+	PubDate     string `xml:"pubDate,omitempty"`
+}
+
+// RSSFeed 生成最新已发布文章的 RSS 2.0 订阅
+func RSSFeed(db *gorm.DB) ([]byte, error) {
+	var posts []models.Post
+	if err := db.Preload("User").
+		Where("status = ?", 1).
+		Order("published_at DESC").
+		Limit(FeedSize).
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	channel := rssChannel{
+		Title:       config.Site.Name,
+		Link:        AbsoluteURL("/"),
+		Description: config.Site.Name,
+	}
+
+	var latest time.Time
+	for i := range posts {
+		post := &posts[i]
+		link := AbsoluteURL(PostPath(post))
+		item := rssItem{
+			Title:       post.Title,
+			Link:        link,
+			GUID:        link,
+			Description: post.Excerpt,
+			Author:      post.User.Username,
+		}
+		if post.PublishedAt != nil {
+			item.PubDate = post.PublishedAt.Format(time.RFC1123Z)
+		}
+		if post.UpdatedAt.After(latest) {
+			latest = post.UpdatedAt
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	if !latest.IsZero() {
+		channel.LastBuildDate = latest.Format(time.RFC1123Z)
+	}
+
+	return marshalXML(rss{Version: "2.0", Channel: channel})
+}
@@ -0,0 +1,33 @@
+package seo
+
+import (
+	"os"
+	"strings"
+
+	"blog/config"
+)
+
+// RobotsTxt 生成 robots.txt 内容
+//
+// 配置了 ROBOTS_FILE 时直接返回文件内容，否则根据允许/禁止路径生成，
+// 并附带站点地图地址。
+func RobotsTxt() (string, error) {
+	if config.Site.RobotsFile != "" {
+		data, err := os.ReadFile(config.Site.RobotsFile)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, path := range config.Site.RobotsAllow {
+		b.WriteString("Allow: " + path + "\n")
+	}
+	for _, path := range config.Site.RobotsDeny {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("\nSitemap: " + AbsoluteURL("/sitemap.xml") + "\n")
+	return b.String(), nil
+}
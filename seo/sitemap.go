@@ -0,0 +1,318 @@
+package seo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"blog/events"
+	"blog/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MaxURLsPerSitemap 单个 sitemap 文件允许的最大 URL 数量（协议限制）
+const MaxURLsPerSitemap = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// urlEntry sitemap 中的一条 URL
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	Xmlns    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+// postEntry 已发布文章在 sitemap 中的信息
+type postEntry struct {
+	path      string
+	updatedAt time.Time
+	archives  []string // 文章所属的分类、标签、作者归档页路径
+}
+
+// Sitemap 站点地图生成器
+//
+// 首次访问时从数据库全量加载已发布文章，之后通过文章事件增量维护，
+// 渲染结果缓存到下次变更为止。
+type Sitemap struct {
+	db *gorm.DB
+
+	mu     sync.Mutex
+	pages  bool // 前台页面是否已挂载，未挂载时文章、归档页都无法访问
+	loaded bool
+	posts  map[uint]postEntry
+	files  [][]byte // 渲染后的分片，files[0] 为 /sitemap.xml
+}
+
+// NewSitemap 创建站点地图生成器
+func NewSitemap(db *gorm.DB) *Sitemap {
+	return &Sitemap{db: db, posts: make(map[uint]postEntry)}
+}
+
+var (
+	defaultSitemap     *Sitemap
+	defaultSitemapOnce sync.Once
+)
+
+// InitSitemap 初始化默认站点地图并订阅文章事件
+func InitSitemap(db *gorm.DB) *Sitemap {
+	defaultSitemapOnce.Do(func() {
+		defaultSitemap = NewSitemap(db)
+		for _, topic := range []string{events.PostCreated, events.PostUpdated, events.PostDeleted} {
+			events.Subscribe(topic, defaultSitemap.handlePostEvent)
+		}
+	})
+	return defaultSitemap
+}
+
+// DefaultSitemap 获取默认站点地图
+func DefaultSitemap() *Sitemap {
+	return defaultSitemap
+}
+
+// handlePostEvent 处理文章事件，增量更新
+func (s *Sitemap) handlePostEvent(payload interface{}) {
+	post, ok := payload.(*models.Post)
+	if !ok {
+		return
+	}
+	if err := s.Refresh(post.ID); err != nil {
+		logrus.WithError(err).WithField("post_id", post.ID).Warn("增量更新站点地图失败")
+	}
+}
+
+// Refresh 重新读取单篇文章并更新其 sitemap 条目
+func (s *Sitemap) Refresh(postID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 尚未加载时无需处理，首次访问会全量加载
+	if !s.loaded {
+		return nil
+	}
+
+	var post models.Post
+	err := s.db.Preload("User").Preload("Category").Preload("Tags").First(&post, postID).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		delete(s.posts, postID)
+	case err != nil:
+		return err
+	case post.Status != 1:
+		delete(s.posts, postID)
+	default:
+		s.posts[postID] = newPostEntry(&post)
+	}
+
+	s.files = nil
+	return nil
+}
+
+// EnablePages 前台页面挂载后调用，之后站点地图才包含首页、归档页和文章页
+func (s *Sitemap) EnablePages() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages = true
+	s.files = nil
+}
+
+// Invalidate 丢弃所有缓存，下次访问时全量重建
+func (s *Sitemap) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loaded = false
+	s.posts = make(map[uint]postEntry)
+	s.files = nil
+}
+
+// File 获取第 n 个 sitemap 文件，0 表示 /sitemap.xml
+func (s *Sitemap) File(n int) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, false, err
+	}
+	if s.files == nil {
+		if err := s.render(); err != nil {
+			return nil, false, err
+		}
+	}
+	if n < 0 || n >= len(s.files) {
+		return nil, false, nil
+	}
+	return s.files[n], true, nil
+}
+
+// load 全量加载已发布文章
+func (s *Sitemap) load() error {
+	if s.loaded {
+		return nil
+	}
+
+	var posts []models.Post
+	err := s.db.Preload("User").Preload("Category").Preload("Tags").
+		Where("status = ?", 1).
+		FindInBatches(&posts, 1000, func(tx *gorm.DB, batch int) error {
+			for i := range posts {
+				s.posts[posts[i].ID] = newPostEntry(&posts[i])
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	s.loaded = true
+	s.files = nil
+	logrus.WithField("posts", len(s.posts)).Info("站点地图加载完成")
+	return nil
+}
+
+func newPostEntry(post *models.Post) postEntry {
+	entry := postEntry{
+		path:      PostPath(post),
+		updatedAt: post.UpdatedAt,
+	}
+	if post.Category != nil {
+		entry.archives = append(entry.archives, CategoryPath(post.Category))
+	}
+	for i := range post.Tags {
+		entry.archives = append(entry.archives, TagPath(&post.Tags[i]))
+	}
+	if post.User.ID != 0 {
+		entry.archives = append(entry.archives, AuthorPath(&post.User))
+	}
+	return entry
+}
+
+// entries 汇总所有 URL：首页、归档页、文章页
+//
+// 前台页面未挂载时返回空列表，不输出无法访问的地址
+func (s *Sitemap) entries() []urlEntry {
+	if !s.pages {
+		return nil
+	}
+
+	var latest time.Time
+	archives := make(map[string]time.Time)
+
+	ids := make([]uint, 0, len(s.posts))
+	for id, post := range s.posts {
+		ids = append(ids, id)
+		if post.updatedAt.After(latest) {
+			latest = post.updatedAt
+		}
+		for _, path := range post.archives {
+			if post.updatedAt.After(archives[path]) {
+				archives[path] = post.updatedAt
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	archivePaths := make([]string, 0, len(archives))
+	for path := range archives {
+		archivePaths = append(archivePaths, path)
+	}
+	sort.Strings(archivePaths)
+
+	list := make([]urlEntry, 0, 1+len(archivePaths)+len(ids))
+	list = append(list, urlEntry{Loc: AbsoluteURL("/"), LastMod: formatLastMod(latest)})
+	for _, path := range archivePaths {
+		list = append(list, urlEntry{Loc: AbsoluteURL(path), LastMod: formatLastMod(archives[path])})
+	}
+	for _, id := range ids {
+		post := s.posts[id]
+		list = append(list, urlEntry{Loc: AbsoluteURL(post.path), LastMod: formatLastMod(post.updatedAt)})
+	}
+	return list
+}
+
+// render 渲染 sitemap，URL 数超过上限时拆分为多个文件并生成索引
+func (s *Sitemap) render() error {
+	list := s.entries()
+
+	if len(list) <= MaxURLsPerSitemap {
+		data, err := marshalXML(urlSet{Xmlns: sitemapNamespace, URLs: list})
+		if err != nil {
+			return err
+		}
+		s.files = [][]byte{data}
+		return nil
+	}
+
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	files := [][]byte{nil}
+	for start := 0; start < len(list); start += MaxURLsPerSitemap {
+		end := start + MaxURLsPerSitemap
+		if end > len(list) {
+			end = len(list)
+		}
+		chunk := list[start:end]
+
+		data, err := marshalXML(urlSet{Xmlns: sitemapNamespace, URLs: chunk})
+		if err != nil {
+			return err
+		}
+		files = append(files, data)
+
+		var lastMod string
+		for _, entry := range chunk {
+			if entry.LastMod > lastMod {
+				lastMod = entry.LastMod
+			}
+		}
+		index.Sitemaps = append(index.Sitemaps, urlEntry{
+			Loc:     AbsoluteURL(SitemapPartPath(len(files) - 1)),
+			LastMod: lastMod,
+		})
+	}
+
+	data, err := marshalXML(index)
+	if err != nil {
+		return err
+	}
+	files[0] = data
+	s.files = files
+	return nil
+}
+
+// SitemapPartPath 第 n 个分片的访问路径
+func SitemapPartPath(n int) string {
+	return fmt.Sprintf("/sitemaps/sitemap-%d.xml", n)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,37 @@
+package seo
+
+import (
+	"fmt"
+	"net/url"
+
+	"blog/config"
+	"blog/models"
+)
+
+// PostPath 文章页面路径，优先使用 slug
+func PostPath(post *models.Post) string {
+	if post.Slug != "" {
+		return "/posts/" + url.PathEscape(post.Slug)
+	}
+	return fmt.Sprintf("/posts/%d", post.ID)
+}
+
+// CategoryPath 分类归档页面路径
+func CategoryPath(category *models.Category) string {
+	return fmt.Sprintf("/categories/%d", category.ID)
+}
+
+// TagPath 标签归档页面路径
+func TagPath(tag *models.Tag) string {
+	return "/tags/" + url.PathEscape(tag.Name)
+}
+
+// AuthorPath 作者归档页面路径
+func AuthorPath(user *models.User) string {
+	return "/authors/" + url.PathEscape(user.Username)
+}
+
+// AbsoluteURL 将站内路径转换为绝对地址
+func AbsoluteURL(path string) string {
+	return config.Site.URL + path
+}
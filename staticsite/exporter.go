@@ -0,0 +1,352 @@
+package staticsite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/models"
+	"blog/seo"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Exporter 静态站点导出器
+//
+// 页面通过进程内请求前台路由渲染，与线上页面完全一致；
+// 站内链接改写为相对链接，分页的 ?page=N 映射为 page/N/ 目录。
+type Exporter struct {
+	db       *gorm.DB
+	handler  http.Handler
+	outDir   string
+	themeDir string
+	force    bool
+}
+
+// Report 导出结果统计
+type Report struct {
+	Written int
+	Skipped int
+	Removed int
+}
+
+// page 待导出的页面
+type page struct {
+	path    string // 站内路径，可带 ?page=N
+	version string // 来源数据版本，未变化时跳过
+}
+
+// NewExporter 创建导出器，handler 为挂载了前台路由的 gin 引擎
+func NewExporter(db *gorm.DB, handler http.Handler, outDir string, force bool) *Exporter {
+	return &Exporter{
+		db:       db,
+		handler:  handler,
+		outDir:   filepath.Clean(outDir),
+		themeDir: filepath.Join(config.Web.ThemeDir, config.Web.Theme),
+		force:    force,
+	}
+}
+
+// Export 执行导出
+func (e *Exporter) Export() (*Report, error) {
+	if err := os.MkdirAll(e.outDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	old, err := loadManifest(e.outDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取导出清单失败: %w", err)
+	}
+
+	build, err := e.buildFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	if old.Build != build {
+		e.force = true
+	}
+
+	pages, err := e.collectPages()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	manifest := &Manifest{Build: build, GeneratedAt: time.Now(), Pages: make(map[string]ManifestEntry)}
+
+	for _, p := range pages {
+		file := outputFile(p.path, false)
+		if prev, ok := old.Pages[p.path]; ok && !e.force && prev.Version == p.version && e.exists(prev.File) {
+			manifest.Pages[p.path] = prev
+			report.Skipped++
+			continue
+		}
+
+		data, err := e.fetch(p.path)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(filepath.Join(e.outDir, filepath.FromSlash(file)), data); err != nil {
+			return nil, err
+		}
+		manifest.Pages[p.path] = ManifestEntry{File: file, Version: p.version, SHA256: digest(data)}
+		report.Written++
+	}
+
+	if err := e.copyStatic(old, manifest, report); err != nil {
+		return nil, err
+	}
+
+	// 删除已不存在的页面（如已删除或撤回的文章）
+	for sitePath, entry := range old.Pages {
+		if _, ok := manifest.Pages[sitePath]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(e.outDir, filepath.FromSlash(entry.File))); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		removeEmptyDirs(e.outDir, filepath.Dir(filepath.Join(e.outDir, filepath.FromSlash(entry.File))))
+		report.Removed++
+	}
+
+	if err := manifest.save(e.outDir); err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"out":     e.outDir,
+		"written": report.Written,
+		"skipped": report.Skipped,
+		"removed": report.Removed,
+	}).Info("静态站点导出完成")
+	return report, nil
+}
+
+// collectPages 收集需要导出的全部页面及其版本
+func (e *Exporter) collectPages() ([]page, error) {
+	var posts []models.Post
+	if err := e.db.Preload("User").Preload("Category").Preload("Tags").
+		Where("status = ?", 1).
+		Order("id").
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	// 评论显示在文章页，评论变化也需要重建文章页
+	var comments []models.Comment
+	if err := e.db.Select("id", "post_id", "updated_at").
+		Where("status = ?", 1).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	commentVersions := make(map[uint][]string)
+	for _, comment := range comments {
+		commentVersions[comment.PostID] = append(commentVersions[comment.PostID],
+			fmt.Sprintf("%d@%d", comment.ID, comment.UpdatedAt.UnixNano()))
+	}
+
+	var pages []page
+	listings := map[string][]*models.Post{"/": nil}
+	for i := range posts {
+		post := &posts[i]
+		version := versionOf(postVersion(post), strings.Join(commentVersions[post.ID], ","))
+		pages = append(pages, page{path: seo.PostPath(post), version: version})
+
+		listings["/"] = append(listings["/"], post)
+		listings[seo.AuthorPath(&post.User)] = append(listings[seo.AuthorPath(&post.User)], post)
+		if post.Category != nil {
+			listings[seo.CategoryPath(post.Category)] = append(listings[seo.CategoryPath(post.Category)], post)
+		}
+		for j := range post.Tags {
+			listings[seo.TagPath(&post.Tags[j])] = append(listings[seo.TagPath(&post.Tags[j])], post)
+		}
+	}
+
+	// 列表页：同一列表中任一文章变化即重建该列表的所有分页
+	pageSize := config.Web.PageSize
+	for basePath, list := range listings {
+		parts := make([]string, 0, len(list))
+		for _, post := range list {
+			parts = append(parts, postVersion(post))
+		}
+		version := versionOf(parts...)
+
+		totalPages := (len(list) + pageSize - 1) / pageSize
+		if totalPages == 0 {
+			totalPages = 1
+		}
+		for n := 1; n <= totalPages; n++ {
+			p := basePath
+			if n > 1 {
+				p = fmt.Sprintf("%s?page=%d", basePath, n)
+			}
+			pages = append(pages, page{path: p, version: version})
+		}
+	}
+
+	// 订阅、站点地图与 robots.txt
+	all := make([]string, 0, len(posts))
+	for i := range posts {
+		all = append(all, postVersion(&posts[i]))
+	}
+	siteVersion := versionOf(all...)
+	pages = append(pages,
+		page{path: "/feed.xml", version: siteVersion},
+		page{path: "/sitemap.xml", version: siteVersion},
+		page{path: "/robots.txt", version: siteVersion},
+		page{path: "/404.html", version: ""},
+	)
+
+	// URL 数（首页、归档页、文章页）超过单个 sitemap 上限时导出分片
+	if urlCount := len(listings) + len(posts); urlCount > seo.MaxURLsPerSitemap {
+		for n := 1; n <= (urlCount+seo.MaxURLsPerSitemap-1)/seo.MaxURLsPerSitemap; n++ {
+			pages = append(pages, page{path: seo.SitemapPartPath(n), version: siteVersion})
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].path < pages[j].path })
+	return pages, nil
+}
+
+// fetch 通过进程内请求渲染页面，HTML 页面改写为相对链接
+func (e *Exporter) fetch(sitePath string) ([]byte, error) {
+	requestPath, expected := sitePath, http.StatusOK
+	if sitePath == "/404.html" {
+		// 请求一个不存在的路径以获得主题的 404 页面
+		requestPath, expected = "/__static_export_not_found__", http.StatusNotFound
+	}
+
+	recorder := httptest.NewRecorder()
+	e.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, requestPath, nil))
+	if recorder.Code != expected {
+		return nil, fmt.Errorf("导出 %s 失败: HTTP %d", sitePath, recorder.Code)
+	}
+
+	data := recorder.Body.Bytes()
+	if strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") {
+		data = relativizeLinks(data, outputFile(sitePath, false))
+	}
+	return data, nil
+}
+
+// copyStatic 复制主题静态资源，内容未变化的文件跳过
+func (e *Exporter) copyStatic(old, manifest *Manifest, report *Report) error {
+	staticDir := filepath.Join(e.themeDir, "static")
+	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(staticDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(staticDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		sitePath := "/static/" + filepath.ToSlash(rel)
+		file := strings.TrimPrefix(sitePath, "/")
+		sum := digest(data)
+		if prev, ok := old.Pages[sitePath]; ok && prev.SHA256 == sum && e.exists(prev.File) {
+			manifest.Pages[sitePath] = prev
+			report.Skipped++
+			return nil
+		}
+
+		if err := writeFile(filepath.Join(e.outDir, filepath.FromSlash(file)), data); err != nil {
+			return err
+		}
+		manifest.Pages[sitePath] = ManifestEntry{File: file, Version: sum, SHA256: sum}
+		report.Written++
+		return nil
+	})
+}
+
+// buildFingerprint 计算主题模板与站点配置的指纹
+func (e *Exporter) buildFingerprint() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%d\n", config.Site.URL, config.Site.Name, config.Web.PageSize)
+
+	err := filepath.WalkDir(e.themeDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(p, filepath.Join(e.themeDir, "static")) {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "%s\n", p)
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("读取主题失败: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (e *Exporter) exists(file string) bool {
+	_, err := os.Stat(filepath.Join(e.outDir, filepath.FromSlash(file)))
+	return err == nil
+}
+
+func postVersion(post *models.Post) string {
+	return fmt.Sprintf("%d@%d", post.ID, post.UpdatedAt.UnixNano())
+}
+
+func versionOf(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFile 原子写入文件
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// removeEmptyDirs 自下而上删除空目录，直到导出根目录
+func removeEmptyDirs(root, dir string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,66 @@
+package staticsite
+
+import (
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// outputFile 将站内路径映射为导出文件（相对导出目录）
+//
+//	/                 -> index.html
+//	/?page=2          -> page/2/index.html
+//	/posts/hello      -> posts/hello/index.html
+//	/tags/go?page=3   -> tags/go/page/3/index.html
+//	/feed.xml         -> feed.xml
+//
+// escaped 为 true 时保留 URL 转义，用于生成页面内链接；否则返回解码后的文件系统路径。
+func outputFile(sitePath string, escaped bool) string {
+	u, err := url.Parse(sitePath)
+	if err != nil {
+		return strings.TrimPrefix(sitePath, "/")
+	}
+
+	p := u.EscapedPath()
+	if !escaped {
+		p = u.Path
+	}
+	p = strings.Trim(p, "/")
+
+	// 带扩展名的路径视为文件
+	if path.Ext(p) != "" {
+		return p
+	}
+
+	if page := u.Query().Get("page"); page != "" && page != "1" {
+		p = path.Join(p, "page", page)
+	}
+	return strings.TrimPrefix(path.Join(p, "index.html"), "/")
+}
+
+var linkPattern = regexp.MustCompile(`(href|src)="(/[^"]*)"`)
+
+// relativizeLinks 将页面中以 / 开头的站内链接改写为相对链接，
+// 使导出结果可以部署在任意子目录或直接通过 file:// 浏览。
+func relativizeLinks(page []byte, file string) []byte {
+	prefix := strings.Repeat("../", strings.Count(file, "/"))
+
+	return linkPattern.ReplaceAllFunc(page, func(match []byte) []byte {
+		parts := linkPattern.FindSubmatch(match)
+		attr, link := string(parts[1]), html.UnescapeString(string(parts[2]))
+
+		// 协议相对地址（//cdn.example.com）保持不变
+		if strings.HasPrefix(link, "//") {
+			return match
+		}
+
+		fragment := ""
+		if i := strings.IndexByte(link, '#'); i >= 0 {
+			link, fragment = link[:i], link[i:]
+		}
+
+		return []byte(attr + `="` + html.EscapeString(prefix+outputFile(link, true)+fragment) + `"`)
+	})
+}
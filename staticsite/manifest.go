@@ -0,0 +1,54 @@
+package staticsite
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFile 清单文件名，位于导出目录根部
+const ManifestFile = ".manifest.json"
+
+// Manifest 导出清单，记录每个页面的来源版本和内容摘要，用于增量导出
+type Manifest struct {
+	Build       string                   `json:"build"` // 主题与站点配置的指纹，变化时全部重建
+	GeneratedAt time.Time                `json:"generated_at"`
+	Pages       map[string]ManifestEntry `json:"pages"` // 键为站内路径
+}
+
+// ManifestEntry 单个导出文件的记录
+type ManifestEntry struct {
+	File    string `json:"file"`    // 相对导出目录的文件路径
+	Version string `json:"version"` // 来源数据版本
+	SHA256  string `json:"sha256"`  // 文件内容摘要
+}
+
+// loadManifest 读取清单，不存在时返回空清单
+func loadManifest(dir string) (*Manifest, error) {
+	manifest := &Manifest{Pages: make(map[string]ManifestEntry)}
+
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Pages == nil {
+		manifest.Pages = make(map[string]ManifestEntry)
+	}
+	return manifest, nil
+}
+
+// save 写入清单
+func (m *Manifest) save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, ManifestFile), data)
+}
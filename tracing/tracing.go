@@ -0,0 +1,77 @@
+// Package tracing OpenTelemetry 链路追踪
+//
+// Setup 设置全局 TracerProvider 和 W3C trace-context 传播方式；HTTP 请求的 span 由 middleware.Tracing 创建，
+// 数据库操作的 span 由 GormPlugin 创建，两者通过请求的 context 关联，因此数据库操作需使用 WithContext 传入请求的 context。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"blog/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "blog"
+
+// Tracer 本服务使用的 Tracer，未启用追踪时创建的 span 不会记录
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup 按配置设置链路追踪，返回的函数在退出前调用，发送尚未导出的 span
+func Setup(cfg config.TracingConfig) (func(context.Context) error, error) {
+	// 无论是否导出，都沿用上游传来的 traceparent，便于下游继续关联
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var option sdktrace.TracerProviderOption
+	switch cfg.Exporter {
+	case config.TracingNone, "":
+		return func(context.Context) error { return nil }, nil
+	case config.TracingOTLP:
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("创建 OTLP 导出器失败: %w", err)
+		}
+		option = sdktrace.WithBatcher(exporter)
+	case config.TracingStdout:
+		// 在创建时读取 os.Stdout（导出器默认使用包初始化时的值），便于测试重定向输出
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("创建标准输出导出器失败: %w", err)
+		}
+		// 同步输出，请求结束时即可看到完整的 span
+		option = sdktrace.WithSyncer(exporter)
+	default:
+		return nil, fmt.Errorf("不支持的链路追踪导出方式: %s", cfg.Exporter)
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建资源信息失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		option,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,152 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"blog/config"
+	"blog/database"
+	"blog/middleware"
+	"blog/models"
+	"blog/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// exportedSpan 标准输出导出器输出的 span 中用到的字段
+type exportedSpan struct {
+	Name        string
+	SpanKind    trace.SpanKind
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		TraceID string
+		SpanID  string
+	}
+}
+
+// setupStdout 按 OTEL_TRACES_EXPORTER=stdout 的配置启用追踪，导出的 span 写入临时文件
+//
+// 标准输出导出器在创建时绑定 os.Stdout，因此只在 Setup 期间替换。
+func setupStdout(t *testing.T) (read func() []exportedSpan) {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "spans-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = out
+	shutdown, err := tracing.Setup(config.TracingConfig{
+		Exporter:    config.TracingStdout,
+		ServiceName: "blog-test",
+		SampleRatio: 1,
+	})
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("启用链路追踪失败: %v", err)
+	}
+	t.Cleanup(func() {
+		shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		out.Close()
+	})
+
+	return func() []exportedSpan {
+		t.Helper()
+		if _, err := out.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		var spans []exportedSpan
+		decoder := json.NewDecoder(out)
+		for {
+			var span exportedSpan
+			err := decoder.Decode(&span)
+			if errors.Is(err, io.EOF) {
+				return spans
+			}
+			if err != nil {
+				t.Fatalf("解析导出的 span 失败: %v", err)
+			}
+			spans = append(spans, span)
+		}
+	}
+}
+
+func TestRequestSpanWithGormChild(t *testing.T) {
+	read := setupStdout(t)
+
+	db, err := database.Open(config.DriverSQLite, "file:tracing?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		t.Cleanup(func() { sqlDB.Close() })
+	}
+	// 没有请求 context 的迁移不产生 span
+	if err := db.AutoMigrate(&models.Post{}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Tracing())
+	r.GET("/api/v1/posts/:id", func(c *gin.Context) {
+		var post models.Post
+		db.WithContext(c.Request.Context()).Where("id = ?", c.Param("id")).Limit(1).Find(&post)
+		c.Status(http.StatusOK)
+	})
+
+	const (
+		upstreamTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+		upstreamSpan  = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/posts/1", nil)
+	req.Header.Set("traceparent", "00-"+upstreamTrace+"-"+upstreamSpan+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var server, query *exportedSpan
+	spans := read()
+	for i := range spans {
+		switch {
+		case spans[i].SpanKind == trace.SpanKindServer:
+			server = &spans[i]
+		case strings.HasPrefix(spans[i].Name, "gorm.query"):
+			query = &spans[i]
+		}
+	}
+	if server == nil || query == nil {
+		t.Fatalf("应导出 server span 和 GORM span，实际 %+v", spans)
+	}
+
+	if server.Name != "GET /api/v1/posts/:id" {
+		t.Errorf("server span 名称 = %q", server.Name)
+	}
+	if server.SpanContext.TraceID != upstreamTrace || server.Parent.SpanID != upstreamSpan {
+		t.Errorf("server span 应沿用上游 traceparent: trace=%s parent=%s", server.SpanContext.TraceID, server.Parent.SpanID)
+	}
+	if query.SpanKind != trace.SpanKindClient || query.Name != "gorm.query posts" {
+		t.Errorf("GORM span = %q kind=%v", query.Name, query.SpanKind)
+	}
+	if query.SpanContext.TraceID != server.SpanContext.TraceID {
+		t.Errorf("GORM span 的 trace ID = %s，应与请求相同 %s", query.SpanContext.TraceID, server.SpanContext.TraceID)
+	}
+	if query.Parent.SpanID != server.SpanContext.SpanID {
+		t.Errorf("GORM span 的父 span = %s，应为 server span %s", query.Parent.SpanID, server.SpanContext.SpanID)
+	}
+	if len(spans) != 2 {
+		t.Errorf("应只导出 2 个 span，实际 %d 个", len(spans))
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// ETag 按响应内容生成强 ETag
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ETagMatch 检查 If-None-Match 或 If-Match 请求头是否包含 etag
+//
+// 请求头可以是逗号分隔的多个值或 *；比较时忽略弱 ETag 前缀 W/。
+func ETagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionETag 生成带版本号的强 ETag，格式为 "v<版本号>-<内容摘要>"
+//
+// 内容摘要使浏览数等统计字段变化时 ETag 随之变化，If-None-Match 能取到最新内容；
+// If-Match 只比较版本号，统计字段的变化不会导致条件更新失败。
+func VersionETag(version uint, body []byte) string {
+	return `"v` + strconv.FormatUint(uint64(version), 10) + "-" + strings.Trim(ETag(body), `"`) + `"`
+}
+
+// VersionMatch 检查 If-Match 请求头是否匹配版本号：为 * 或任一 ETag 的版本号相同
+func VersionMatch(header string, version uint) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		// If-Match 使用强比较，弱 ETag 不匹配
+		raw, ok := strings.CutPrefix(candidate, `"v`)
+		if !ok {
+			continue
+		}
+		digits, _, _ := strings.Cut(strings.TrimSuffix(raw, `"`), "-")
+		if v, err := strconv.ParseUint(digits, 10, 64); err == nil && uint(v) == version {
+			return true
+		}
+	}
+	return false
+}
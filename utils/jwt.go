@@ -2,60 +2,106 @@ package utils
 
 import (
 	"errors"
+	"sync"
 	"time"
 
+	"blog/config"
+	"blog/keyring"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // JWT配置
 var (
-	JWTSecret     = []byte("your-secret-key-change-in-production") // 生产环境请修改为更安全的密钥
-	TokenDuration = time.Hour * 24 * 7                             // Token有效期7天
+	JWTSecret     = []byte(config.GetEnv("JWT_SECRET", "your-secret-key-change-in-production")) // 生产环境请通过 JWT_SECRET 设置更安全的密钥
+	TokenDuration = time.Hour * 24 * 7                                                          // Token有效期7天
+)
+
+var (
+	keysOnce sync.Once
+	keys     *keyring.Keyring
+	keysErr  error
 )
 
+// SigningKeys 非对称签名使用的密钥环，首次调用时打开密钥目录
+//
+// 旧密钥保留到用它签发的令牌全部过期，再加上其他实例发现新密钥所需的时间。
+func SigningKeys() (*keyring.Keyring, error) {
+	keysOnce.Do(func() {
+		retention := TokenDuration + config.JWT.ReloadInterval
+		keys, keysErr = keyring.Open(config.JWT.KeysDir, config.JWT.Algorithm, retention, config.JWT.ReloadInterval)
+	})
+	return keys, keysErr
+}
+
+// JWKS 验证登录令牌用的公钥集合，使用 HS256 时为空
+func JWKS() (keyring.JWKS, error) {
+	if !config.JWT.Asymmetric() {
+		return keyring.JWKS{Keys: []keyring.JWK{}}, nil
+	}
+	ring, err := SigningKeys()
+	if err != nil {
+		return keyring.JWKS{}, err
+	}
+	return ring.JWKS(), nil
+}
+
+// ScopeMFA 密码验证通过、等待两步验证的临时令牌
+const ScopeMFA = "mfa"
+
 // Claims JWT声明结构
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Version  uint   `json:"ver"`             // 签发时用户的令牌版本，用户令牌版本递增后失效
+	Scope    string `json:"scope,omitempty"` // 为空表示完整的访问令牌
 	jwt.RegisteredClaims
 }
 
 // GenerateToken 生成JWT Token
-func GenerateToken(userID uint, username string) (string, error) {
+func GenerateToken(userID uint, username string, version uint) (string, error) {
+	return signToken(userID, username, version, "", TokenDuration)
+}
+
+// GenerateMFAToken 生成两步验证用的临时令牌，只能用于完成登录
+func GenerateMFAToken(userID uint, username string, version uint, ttl time.Duration) (string, error) {
+	return signToken(userID, username, version, ScopeMFA, ttl)
+}
+
+func signToken(userID uint, username string, version uint, scope string, ttl time.Duration) (string, error) {
 	// 创建声明
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
+		Version:  version,
+		Scope:    scope,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenDuration)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "blog-system",
 		},
 	}
 
-	// 创建Token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if !config.JWT.Asymmetric() {
+		// 创建并签名Token
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(JWTSecret)
+	}
 
-	// 签名Token
-	tokenString, err := token.SignedString(JWTSecret)
+	ring, err := SigningKeys()
 	if err != nil {
 		return "", err
 	}
-
-	return tokenString, nil
+	key := ring.Signing()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Signer)
 }
 
 // ParseToken 解析JWT Token
 func ParseToken(tokenString string) (*Claims, error) {
 	// 解析Token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return JWTSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey, jwt.WithValidMethods(validMethods()))
 
 	if err != nil {
 		return nil, err
@@ -69,11 +115,59 @@ func ParseToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// ValidateToken 验证Token有效性
+// validMethods 接受的签名算法
+//
+// 使用非对称算法时，只有显式设置了 JWT_SECRET 才继续接受 HS256 令牌，
+// 以免默认密钥被用来伪造令牌。
+func validMethods() []string {
+	if !config.JWT.Asymmetric() {
+		return []string{config.JWTAlgorithmHS256}
+	}
+	methods := []string{config.JWT.Algorithm}
+	if config.JWT.Secret != "" {
+		methods = append(methods, config.JWTAlgorithmHS256)
+	}
+	return methods
+}
+
+// verificationKey 按签名算法和 kid 返回验证用的密钥
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return JWTSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing kid")
+	}
+	ring, err := SigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := ring.Lookup(kid)
+	if !ok || key.Algorithm != token.Method.Alg() {
+		return nil, errors.New("unknown signing key")
+	}
+	return key.Public(), nil
+}
+
+// ValidateToken 验证Token有效性，只接受完整的访问令牌
 func ValidateToken(tokenString string) (bool, *Claims) {
 	claims, err := ParseToken(tokenString)
-	if err != nil {
+	if err != nil || claims.Scope != "" {
 		return false, nil
 	}
 	return true, claims
 }
+
+// ParseMFAToken 解析两步验证临时令牌
+func ParseMFAToken(tokenString string) (*Claims, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != ScopeMFA {
+		return nil, errors.New("invalid token scope")
+	}
+	return claims, nil
+}
@@ -1,23 +1,102 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"blog/config"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword 对密码进行加密
+const (
+	argon2Prefix  = "$argon2id$"
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// argon2Params Argon2id 参数，编码在哈希中，调整配置后旧哈希仍可验证
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func currentArgon2Params() argon2Params {
+	return argon2Params{
+		memory:      config.Password.Argon2Memory,
+		iterations:  config.Password.Argon2Iterations,
+		parallelism: config.Password.Argon2Parallelism,
+	}
+}
+
+// HashPassword 使用 Argon2id 对密码进行哈希
+//
+// 结果为 PHC 字符串格式：$argon2id$v=19$m=19456,t=2,p=1$<盐>$<哈希>
 func HashPassword(password string) (string, error) {
-	// 使用bcrypt对密码进行哈希加密
-	// cost 参数控制加密强度，推荐值为12-14
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return string(hashedBytes), nil
+
+	p := currentArgon2Params()
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-// CheckPassword 验证密码
+// CheckPassword 验证密码，同时支持 Argon2id 和旧的 bcrypt 哈希
 func CheckPassword(password, hashedPassword string) bool {
-	// 比较密码和哈希值
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+	if !strings.HasPrefix(hashedPassword, argon2Prefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		return err == nil
+	}
+
+	p, salt, key, err := decodeArgon2(hashedPassword)
+	if err != nil {
+		return false
+	}
+	actual := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1
+}
+
+// PasswordNeedsRehash 哈希是否需要用当前参数重新生成（bcrypt 哈希或 Argon2id 参数已调整）
+func PasswordNeedsRehash(hashedPassword string) bool {
+	p, _, _, err := decodeArgon2(hashedPassword)
+	return err != nil || p != currentArgon2Params()
+}
+
+// decodeArgon2 解析 PHC 格式的 Argon2id 哈希
+func decodeArgon2(encoded string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("不是 Argon2id 哈希")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("不支持的 Argon2 版本: %s", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("Argon2 参数无效: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	return p, salt, key, nil
 }
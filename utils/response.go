@@ -3,6 +3,8 @@ package utils
 import (
 	"net/http"
 
+	"blog/logger"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -27,11 +29,11 @@ func SuccessResponse(c *gin.Context, data interface{}, message ...string) {
 		Data:    data,
 	}
 
-	logrus.WithFields(logrus.Fields{
+	logger.From(c).WithFields(logrus.Fields{
 		"method": c.Request.Method,
 		"path":   c.Request.URL.Path,
 		"status": 200,
-	}).Info("请求处理成功")
+	}).Debug("请求处理成功")
 
 	c.JSON(http.StatusOK, response)
 }
@@ -43,12 +45,17 @@ func ErrorResponse(c *gin.Context, code int, message string) {
 		Message: message,
 	}
 
-	logrus.WithFields(logrus.Fields{
+	entry := logger.From(c).WithFields(logrus.Fields{
 		"method": c.Request.Method,
 		"path":   c.Request.URL.Path,
 		"status": code,
 		"error":  message,
-	}).Error("请求处理失败")
+	})
+	if code >= http.StatusInternalServerError {
+		entry.Error("请求处理失败")
+	} else {
+		entry.Warn("请求处理失败")
+	}
 
 	c.JSON(code, response)
 }
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"blog/config"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// IsValidSlug 检查 slug 格式：小写字母、数字，以单个连字符分隔
+func IsValidSlug(slug string) bool {
+	return len(slug) <= config.Slug.MaxLength && slugPattern.MatchString(slug)
+}
+
+// Slugify 根据标题生成 slug
+//
+// 拉丁字母去除变音符号后转为小写，汉字按配置转写为拼音，其余字符视为分隔符。
+// 转写结果为空时返回配置前缀加随机后缀。
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+	var han []rune
+
+	flushWord := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	flushHan := func() {
+		if len(han) == 0 {
+			return
+		}
+		if config.Slug.Transliterate == "pinyin" {
+			words = append(words, pinyin.LazyPinyin(string(han), pinyin.NewArgs())...)
+		}
+		han = han[:0]
+	}
+
+	// NFD 分解后丢弃组合符号，é -> e
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushHan()
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flushHan()
+			flushWord()
+		}
+	}
+	flushHan()
+	flushWord()
+
+	slug := TruncateSlug(strings.Join(words, "-"), config.Slug.MaxLength)
+	if slug == "" {
+		return FallbackSlug()
+	}
+	return slug
+}
+
+// FallbackSlug 生成前缀加随机后缀的 slug
+func FallbackSlug() string {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return config.Slug.FallbackPrefix + "-" + hex.EncodeToString(buf)
+}
+
+// TruncateSlug 按连字符边界截断到不超过 max 个字符
+func TruncateSlug(slug string, max int) string {
+	if len(slug) <= max {
+		return slug
+	}
+	slug = slug[:max]
+	if i := strings.LastIndexByte(slug, '-'); i > 0 {
+		slug = slug[:i]
+	}
+	return strings.Trim(slug, "-")
+}
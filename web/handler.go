@@ -0,0 +1,283 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"blog/config"
+	"blog/database"
+	"blog/logger"
+	"blog/models"
+	"blog/seo"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Archive 归档页信息
+type Archive struct {
+	Kind string // category、tag、author
+	Name string
+}
+
+// Pagination 列表分页信息
+type Pagination struct {
+	Page       int
+	TotalPages int
+	PrevURL    string
+	NextURL    string
+}
+
+// PageData 模板数据
+type PageData struct {
+	Site       config.SiteConfig
+	Meta       Meta
+	Posts      []models.Post
+	Post       *models.Post
+	Comments   []models.Comment
+	Archive    *Archive
+	Pagination *Pagination
+}
+
+// Handler 前台页面处理器
+type Handler struct {
+	renderer *Renderer
+}
+
+// NewHandler 创建前台页面处理器
+func NewHandler(renderer *Renderer) *Handler {
+	return &Handler{renderer: renderer}
+}
+
+// Register 在 gin 引擎上注册前台页面路由
+func (h *Handler) Register(r *gin.Engine) {
+	r.Static("/static", h.renderer.StaticDir())
+	r.GET("/", h.Home)
+	r.GET("/posts/:slug", h.Post)
+	r.GET("/categories/:id", h.Category)
+	r.GET("/tags/:name", h.Tag)
+	r.GET("/authors/:username", h.Author)
+	r.NoRoute(h.NotFound)
+}
+
+// Home 首页（文章列表）
+func (h *Handler) Home(c *gin.Context) {
+	db := database.WithContext(c.Request.Context()).Where("posts.status = ?", 1)
+	h.renderList(c, db, siteMeta("", "/"), nil, "/")
+}
+
+// Category 分类归档页
+func (h *Handler) Category(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.NotFound(c)
+		return
+	}
+
+	var category models.Category
+	if !h.find(c, database.WithContext(c.Request.Context()).Where("id = ?", uint(id)), &category) {
+		return
+	}
+
+	db := database.WithContext(c.Request.Context()).Where("posts.status = ? AND posts.category_id = ?", 1, category.ID)
+	meta := siteMeta("分类："+category.Name, seo.CategoryPath(&category))
+	meta.Description = category.Description
+	h.renderList(c, db, meta, &Archive{Kind: "category", Name: category.Name}, seo.CategoryPath(&category))
+}
+
+// Tag 标签归档页
+func (h *Handler) Tag(c *gin.Context) {
+	var tag models.Tag
+	if !h.find(c, database.WithContext(c.Request.Context()).Where("name = ?", c.Param("name")), &tag) {
+		return
+	}
+
+	db := database.WithContext(c.Request.Context()).
+		Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+		Where("posts.status = ? AND post_tags.tag_id = ?", 1, tag.ID)
+	meta := siteMeta("标签："+tag.Name, seo.TagPath(&tag))
+	h.renderList(c, db, meta, &Archive{Kind: "tag", Name: tag.Name}, seo.TagPath(&tag))
+}
+
+// Author 作者归档页
+func (h *Handler) Author(c *gin.Context) {
+	var user models.User
+	if !h.find(c, database.WithContext(c.Request.Context()).Where("username = ?", c.Param("username")), &user) {
+		return
+	}
+
+	name := user.Nickname
+	if name == "" {
+		name = user.Username
+	}
+
+	db := database.WithContext(c.Request.Context()).Where("posts.status = ? AND posts.user_id = ?", 1, user.ID)
+	meta := siteMeta("作者："+name, seo.AuthorPath(&user))
+	meta.Description = user.Bio
+	meta.Image = user.Avatar
+	h.renderList(c, db, meta, &Archive{Kind: "author", Name: name}, seo.AuthorPath(&user))
+}
+
+// Post 文章详情页，支持 slug、数字ID及历史 slug（后两者 301 跳转）
+func (h *Handler) Post(c *gin.Context) {
+	db := database.WithContext(c.Request.Context())
+	slug := c.Param("slug")
+
+	var post models.Post
+	err := db.Preload("User").Preload("Category").Preload("Tags").
+		Where("slug = ?", slug).First(&post).Error
+	if err == gorm.ErrRecordNotFound {
+		h.redirectPost(c, slug)
+		return
+	}
+	if err != nil {
+		h.serverError(c, err)
+		return
+	}
+	if post.Status != 1 {
+		h.NotFound(c)
+		return
+	}
+
+	var comments []models.Comment
+	if err := db.Preload("User").
+		Where("post_id = ? AND status = ?", post.ID, 1).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		h.serverError(c, err)
+		return
+	}
+
+	h.render(c, http.StatusOK, "post.html", &PageData{
+		Meta:     postMeta(&post),
+		Post:     &post,
+		Comments: comments,
+	})
+}
+
+// redirectPost 将数字ID或历史 slug 跳转到文章当前地址
+func (h *Handler) redirectPost(c *gin.Context, slug string) {
+	db := database.WithContext(c.Request.Context())
+
+	var postID uint
+	if id, err := strconv.ParseUint(slug, 10, 32); err == nil {
+		postID = uint(id)
+	} else {
+		var history models.PostSlugHistory
+		if err := db.Where("slug = ?", slug).First(&history).Error; err != nil {
+			h.NotFound(c)
+			return
+		}
+		postID = history.PostID
+	}
+
+	var post models.Post
+	if err := db.First(&post, postID).Error; err != nil || post.Status != 1 {
+		h.NotFound(c)
+		return
+	}
+	c.Redirect(http.StatusMovedPermanently, seo.PostPath(&post))
+}
+
+// NotFound 404 页面，API 路径返回 JSON
+func (h *Handler) NotFound(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		utils.NotFoundResponse(c, "接口不存在")
+		return
+	}
+	h.render(c, http.StatusNotFound, "404.html", &PageData{Meta: siteMeta("页面不存在", c.Request.URL.Path)})
+}
+
+// find 查询归档对象，不存在时输出 404
+func (h *Handler) find(c *gin.Context, query *gorm.DB, dest interface{}) bool {
+	if err := query.First(dest).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			h.NotFound(c)
+		} else {
+			h.serverError(c, err)
+		}
+		return false
+	}
+	return true
+}
+
+// renderList 分页查询文章并渲染列表页
+func (h *Handler) renderList(c *gin.Context, query *gorm.DB, meta Meta, archive *Archive, basePath string) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize := config.Web.PageSize
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Model(&models.Post{}).Count(&total).Error; err != nil {
+		h.serverError(c, err)
+		return
+	}
+
+	var posts []models.Post
+	if err := query.Session(&gorm.Session{}).
+		Preload("User").Preload("Category").Preload("Tags").
+		Order("posts.is_top DESC, posts.published_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&posts).Error; err != nil {
+		h.serverError(c, err)
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if page > 1 && page > totalPages {
+		h.NotFound(c)
+		return
+	}
+
+	pagination := &Pagination{Page: page, TotalPages: totalPages}
+	if page > 1 {
+		pagination.PrevURL = pageURL(basePath, page-1)
+	}
+	if page < totalPages {
+		pagination.NextURL = pageURL(basePath, page+1)
+	}
+	if page > 1 {
+		meta.Title = fmt.Sprintf("%s（第%d页）", meta.Title, page)
+		meta.URL = seo.AbsoluteURL(pageURL(basePath, page))
+	}
+
+	h.render(c, http.StatusOK, "list.html", &PageData{
+		Meta:       meta,
+		Posts:      posts,
+		Archive:    archive,
+		Pagination: pagination,
+	})
+}
+
+func pageURL(basePath string, page int) string {
+	if page <= 1 {
+		return basePath
+	}
+	return basePath + "?" + url.Values{"page": {strconv.Itoa(page)}}.Encode()
+}
+
+// render 渲染模板到缓冲区后再输出，避免渲染失败时输出半个页面
+func (h *Handler) render(c *gin.Context, status int, page string, data *PageData) {
+	data.Site = config.Site
+
+	var buf bytes.Buffer
+	if err := h.renderer.Render(&buf, page, data); err != nil {
+		logger.From(c).WithError(err).WithField("page", page).Error("渲染页面失败")
+		c.String(http.StatusInternalServerError, "页面渲染失败")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *Handler) serverError(c *gin.Context, err error) {
+	logger.From(c).WithError(err).WithField("path", c.Request.URL.Path).Error("前台页面查询失败")
+	c.String(http.StatusInternalServerError, "服务器内部错误")
+}
@@ -0,0 +1,73 @@
+package web
+
+import (
+	"time"
+
+	"blog/config"
+	"blog/models"
+	"blog/seo"
+)
+
+// Meta 页面元信息，用于 <title>、canonical 以及 Open Graph / Twitter 卡片
+type Meta struct {
+	Title         string
+	Description   string
+	URL           string // 规范地址（绝对地址）
+	Image         string
+	Type          string // Open Graph 类型：website 或 article
+	Author        string
+	PublishedTime *time.Time
+	ModifiedTime  *time.Time
+	Tags          []string
+}
+
+// TwitterCard Twitter 卡片类型，有配图时使用大图卡片
+func (m Meta) TwitterCard() string {
+	if m.Image != "" {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
+// siteMeta 站点级页面的元信息
+func siteMeta(title, path string) Meta {
+	if title == "" {
+		title = config.Site.Name
+	} else {
+		title = title + " - " + config.Site.Name
+	}
+	return Meta{
+		Title: title,
+		URL:   seo.AbsoluteURL(path),
+		Type:  "website",
+	}
+}
+
+// postMeta 文章页面的元信息
+func postMeta(post *models.Post) Meta {
+	description := post.Excerpt
+	if description == "" {
+		description = post.Summary
+	}
+
+	author := post.User.Nickname
+	if author == "" {
+		author = post.User.Username
+	}
+
+	modified := post.UpdatedAt
+	meta := Meta{
+		Title:         post.Title + " - " + config.Site.Name,
+		Description:   description,
+		URL:           seo.AbsoluteURL(seo.PostPath(post)),
+		Image:         post.User.Avatar,
+		Type:          "article",
+		Author:        author,
+		PublishedTime: post.PublishedAt,
+		ModifiedTime:  &modified,
+	}
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	return meta
+}
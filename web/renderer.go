@@ -0,0 +1,137 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"blog/seo"
+)
+
+// Renderer 主题模板渲染器
+//
+// 主题目录结构：
+//
+//	<theme>/layouts/*.html   布局模板，需定义 "base"
+//	<theme>/partials/*.html  公共片段
+//	<theme>/*.html           页面模板，定义 "content" 等区块
+//	<theme>/static/          静态资源
+//
+// 每个页面模板与全部布局、片段组合为独立的模板集，避免区块定义互相覆盖。
+type Renderer struct {
+	dir   string
+	debug bool
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// NewRenderer 创建渲染器并加载主题，debug 为 true 时每次渲染都重新加载
+func NewRenderer(dir string, debug bool) (*Renderer, error) {
+	r := &Renderer{dir: dir, debug: debug}
+	if err := r.Load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// StaticDir 主题静态资源目录
+func (r *Renderer) StaticDir() string {
+	return filepath.Join(r.dir, "static")
+}
+
+// Load 解析主题下的全部模板
+func (r *Renderer) Load() error {
+	shared, err := globFiles(filepath.Join(r.dir, "layouts"), filepath.Join(r.dir, "partials"))
+	if err != nil {
+		return err
+	}
+	pages, err := filepath.Glob(filepath.Join(r.dir, "*.html"))
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("主题 %s 中没有页面模板", r.dir)
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		files := append(append([]string(nil), shared...), page)
+		tmpl, err := template.New(filepath.Base(page)).Funcs(templateFuncs).ParseFiles(files...)
+		if err != nil {
+			return err
+		}
+		templates[filepath.Base(page)] = tmpl
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.mu.Unlock()
+	return nil
+}
+
+// Render 渲染页面模板
+func (r *Renderer) Render(w io.Writer, page string, data interface{}) error {
+	if r.debug {
+		if err := r.Load(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.RLock()
+	tmpl, ok := r.templates[page]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("页面模板 %s 不存在", page)
+	}
+	return tmpl.ExecuteTemplate(w, "base", data)
+}
+
+func globFiles(dirs ...string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// templateFuncs 模板可用的辅助函数
+var templateFuncs = template.FuncMap{
+	"postPath":     seo.PostPath,
+	"categoryPath": seo.CategoryPath,
+	"tagPath":      seo.TagPath,
+	"authorPath":   seo.AuthorPath,
+	"absURL":       seo.AbsoluteURL,
+	"date": func(t interface{}) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format("2006-01-02")
+		case *time.Time:
+			if v != nil {
+				return v.Format("2006-01-02")
+			}
+		}
+		return ""
+	},
+	"paragraphs": func(text string) []string {
+		var list []string
+		for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				list = append(list, p)
+			}
+		}
+		return list
+	},
+}
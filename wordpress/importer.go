@@ -0,0 +1,570 @@
+package wordpress
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"blog/config"
+	"blog/events"
+	"blog/jobs"
+	"blog/models"
+	"blog/utils"
+
+	"gorm.io/gorm"
+)
+
+// JobType WordPress 导入任务类型
+const JobType = "wordpress_import"
+
+func init() {
+	jobs.Register(JobType, runImport)
+}
+
+// Payload 导入任务参数
+type Payload struct {
+	File     string `json:"file"`      // WXR 文件路径
+	MediaURL string `json:"media_url"` // wp-content/uploads 替换后的地址前缀
+}
+
+// Stats 导入统计
+type Stats struct {
+	Users       int `json:"users"`
+	Categories  int `json:"categories"`
+	Tags        int `json:"tags"`
+	Posts       int `json:"posts"`
+	Comments    int `json:"comments"`
+	Attachments int `json:"attachments"` // 附件数量，文件需手动复制到媒体目录
+	Skipped     int `json:"skipped"`     // 跳过的页面、修订版、回收站内容及垃圾评论
+	Rewritten   int `json:"rewritten"`   // 改写了链接的文章数
+}
+
+// state 断点状态，每处理完一个条目与业务数据在同一事务中保存
+type state struct {
+	Phase      string          `json:"phase"` // setup、items、links
+	Next       int             `json:"next"`  // 下一个待处理的条目序号
+	Users      map[string]uint `json:"users"` // WordPress 登录名或 email:<邮箱> -> 用户ID
+	Categories map[string]uint `json:"categories"`
+	Tags       map[string]uint `json:"tags"`
+	Posts      map[int]uint    `json:"posts"`    // WordPress 文章ID -> 文章ID
+	Comments   map[int]uint    `json:"comments"` // WordPress 评论ID -> 评论ID
+	Stats      Stats           `json:"stats"`
+}
+
+// 导入阶段
+const (
+	phaseSetup = "setup"
+	phaseItems = "items"
+	phaseLinks = "links"
+)
+
+// runImport 执行导入任务，可从断点恢复
+func runImport(jc *jobs.Context) error {
+	var payload Payload
+	if err := jc.Payload(&payload); err != nil {
+		return err
+	}
+	if payload.MediaURL == "" {
+		payload.MediaURL = config.Storage.MediaURL
+	}
+
+	f, err := os.Open(payload.File)
+	if err != nil {
+		return fmt.Errorf("打开导入文件失败: %w", err)
+	}
+	defer f.Close()
+
+	channel, err := parseWXR(f)
+	if err != nil {
+		return fmt.Errorf("解析 WXR 文件失败: %w", err)
+	}
+
+	st := state{
+		Phase:      phaseSetup,
+		Users:      make(map[string]uint),
+		Categories: make(map[string]uint),
+		Tags:       make(map[string]uint),
+		Posts:      make(map[int]uint),
+		Comments:   make(map[int]uint),
+	}
+	if err := jc.State(&st); err != nil {
+		return err
+	}
+
+	im := &importer{jc: jc, channel: channel, payload: payload, st: &st}
+	return im.run()
+}
+
+type importer struct {
+	jc      *jobs.Context
+	channel *wxrChannel
+	payload Payload
+	st      *state
+}
+
+func (im *importer) run() error {
+	db := im.jc.DB()
+	items := im.channel.Items
+	postItems := 0
+	for i := range items {
+		if items[i].PostType == "post" {
+			postItems++
+		}
+	}
+	if err := im.jc.SetTotal(len(items) + postItems); err != nil {
+		return err
+	}
+
+	// 作者、分类、标签
+	if im.st.Phase == phaseSetup {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := im.importTaxonomies(tx); err != nil {
+				return err
+			}
+			for _, author := range im.channel.Authors {
+				if _, err := im.authorUser(tx, author.Login); err != nil {
+					return err
+				}
+			}
+			im.st.Phase = phaseItems
+			return im.jc.Checkpoint(tx, im.st, 0)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 文章与评论
+	for im.st.Phase == phaseItems && im.st.Next < len(items) {
+		if err := im.jc.Err(); err != nil {
+			return err
+		}
+
+		var created *models.Post
+		err := db.Transaction(func(tx *gorm.DB) error {
+			post, err := im.importItem(tx, &items[im.st.Next])
+			if err != nil {
+				return fmt.Errorf("导入条目 %q 失败: %w", items[im.st.Next].Title, err)
+			}
+			created = post
+			im.st.Next++
+			if im.st.Next == len(items) {
+				im.st.Phase, im.st.Next = phaseLinks, 0
+			}
+			return im.jc.Checkpoint(tx, im.st, im.processed(len(items)))
+		})
+		if err != nil {
+			return err
+		}
+		if created != nil {
+			events.Publish(events.PostCreated, created)
+		}
+	}
+	if im.st.Phase == phaseItems {
+		im.st.Phase = phaseLinks
+	}
+
+	// 改写站内链接和附件地址（需要所有文章导入后才能确定新地址）
+	if im.st.Phase == phaseLinks {
+		if err := im.rewriteAll(); err != nil {
+			return err
+		}
+	}
+
+	return im.jc.SetResult(im.st.Stats)
+}
+
+// processed 当前进度：条目阶段按条目计，链接阶段在条目总数基础上累加
+func (im *importer) processed(itemCount int) int {
+	if im.st.Phase == phaseLinks {
+		return itemCount + im.st.Next
+	}
+	return im.st.Next
+}
+
+// importTaxonomies 导入分类和标签（按名称去重）
+func (im *importer) importTaxonomies(tx *gorm.DB) error {
+	for _, c := range im.channel.Categories {
+		if _, err := im.category(tx, c.Nicename, c.Name, c.Description); err != nil {
+			return err
+		}
+	}
+	for _, t := range im.channel.Tags {
+		if _, err := im.tag(tx, t.Slug, t.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (im *importer) category(tx *gorm.DB, nicename, name, description string) (uint, error) {
+	key := nicename
+	if key == "" {
+		key = name
+	}
+	if id, ok := im.st.Categories[key]; ok {
+		return id, nil
+	}
+
+	var category models.Category
+	result := tx.Where(models.Category{Name: name}).
+		Attrs(models.Category{Description: description}).
+		FirstOrCreate(&category)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		im.st.Stats.Categories++
+	}
+	im.st.Categories[key] = category.ID
+	return category.ID, nil
+}
+
+func (im *importer) tag(tx *gorm.DB, slug, name string) (uint, error) {
+	key := slug
+	if key == "" {
+		key = name
+	}
+	if id, ok := im.st.Tags[key]; ok {
+		return id, nil
+	}
+
+	var tag models.Tag
+	result := tx.Where(models.Tag{Name: name}).FirstOrCreate(&tag)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		im.st.Stats.Tags++
+	}
+	im.st.Tags[key] = tag.ID
+	return tag.ID, nil
+}
+
+// importItem 导入单个条目，返回新建的文章（跳过时返回 nil）
+func (im *importer) importItem(tx *gorm.DB, item *wxrItem) (*models.Post, error) {
+	switch item.PostType {
+	case "post":
+	case "attachment":
+		im.st.Stats.Attachments++
+		return nil, nil
+	default:
+		// 页面、导航菜单、修订版等
+		im.st.Stats.Skipped++
+		return nil, nil
+	}
+
+	var status int
+	switch item.Status {
+	case "publish":
+		status = 1
+	case "draft", "pending", "private", "future":
+		status = 0
+	default:
+		// trash、auto-draft、inherit
+		im.st.Stats.Skipped++
+		return nil, nil
+	}
+
+	slug := item.PostName
+	if decoded, err := url.PathUnescape(slug); err == nil {
+		slug = decoded
+	}
+	if !utils.IsValidSlug(slug) {
+		slug = utils.Slugify(firstNonEmpty(slug, item.Title))
+	}
+
+	// 重复导入同一文件时按 slug 和标题识别已导入的文章
+	var existing models.Post
+	if err := tx.Where("slug = ? AND title = ?", slug, item.Title).First(&existing).Error; err == nil {
+		im.st.Posts[item.PostID] = existing.ID
+		im.st.Stats.Skipped++
+		return nil, nil
+	}
+
+	slug, err := models.UniqueSlug(tx, slug, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	authorID, err := im.authorUser(tx, item.Creator)
+	if err != nil {
+		return nil, err
+	}
+
+	post := models.Post{
+		Title:       item.Title,
+		Slug:        slug,
+		Content:     item.content(),
+		Excerpt:     truncateRunes(item.excerpt(), 500),
+		Status:      status,
+		UserID:      authorID,
+		PublishedAt: item.publishedAt(),
+	}
+	if post.PublishedAt != nil {
+		post.CreatedAt = *post.PublishedAt
+	}
+
+	for _, c := range item.Categories {
+		switch c.Domain {
+		case "category":
+			if post.CategoryID != nil {
+				continue
+			}
+			id, err := im.category(tx, c.Nicename, c.Name, "")
+			if err != nil {
+				return nil, err
+			}
+			post.CategoryID = &id
+		case "post_tag":
+			id, err := im.tag(tx, c.Nicename, c.Name)
+			if err != nil {
+				return nil, err
+			}
+			post.Tags = append(post.Tags, models.Tag{ID: id})
+		}
+	}
+
+	if err := tx.Omit("Tags.*").Create(&post).Error; err != nil {
+		return nil, err
+	}
+	// status 列默认值为 1，零值不会写入，草稿需单独更新
+	if status == 0 {
+		if err := tx.Model(&post).Update("status", 0).Error; err != nil {
+			return nil, err
+		}
+	}
+	im.st.Posts[item.PostID] = post.ID
+	im.st.Stats.Posts++
+
+	approved, err := im.importComments(tx, &post, item.Comments)
+	if err != nil {
+		return nil, err
+	}
+	if approved > 0 {
+		if err := tx.Model(&post).UpdateColumn("comment_count", approved).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &post, nil
+}
+
+// importComments 导入评论并保留回复关系，返回已审核评论数
+func (im *importer) importComments(tx *gorm.DB, post *models.Post, comments []wxrComment) (int, error) {
+	// 父评论ID总是小于子评论，按ID排序保证先创建父评论
+	sorted := append([]wxrComment(nil), comments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	approved := 0
+	for i := range sorted {
+		wc := &sorted[i]
+
+		var status int
+		switch wc.Approved {
+		case "1":
+			status = 1
+		case "0":
+			status = 0
+		default:
+			// spam、trash
+			im.st.Stats.Skipped++
+			continue
+		}
+		if wc.Type == "pingback" || wc.Type == "trackback" {
+			im.st.Stats.Skipped++
+			continue
+		}
+
+		userID, err := im.commentUser(tx, wc)
+		if err != nil {
+			return 0, err
+		}
+
+		comment := models.Comment{
+			Content:     wc.Content,
+			PostID:      post.ID,
+			UserID:      userID,
+			Status:      status,
+			IPAddress:   wc.AuthorIP,
+			AuthorEmail: wc.AuthorEmail,
+		}
+		if parentID, ok := im.st.Comments[wc.Parent]; ok && wc.Parent != 0 {
+			comment.ParentID = &parentID
+		}
+		if t := wc.createdAt(); t != nil {
+			comment.CreatedAt = *t
+			comment.UpdatedAt = *t
+		}
+
+		if err := tx.Create(&comment).Error; err != nil {
+			return 0, err
+		}
+		if status == 0 {
+			if err := tx.Model(&comment).Update("status", 0).Error; err != nil {
+				return 0, err
+			}
+		} else {
+			approved++
+		}
+		im.st.Comments[wc.ID] = comment.ID
+		im.st.Stats.Comments++
+	}
+	return approved, nil
+}
+
+// authorUser 将 WordPress 作者映射为用户：按邮箱、用户名匹配已有用户，否则新建
+func (im *importer) authorUser(tx *gorm.DB, login string) (uint, error) {
+	if id, ok := im.st.Users[login]; ok {
+		return id, nil
+	}
+
+	var author *wxrAuthor
+	for i := range im.channel.Authors {
+		if im.channel.Authors[i].Login == login {
+			author = &im.channel.Authors[i]
+			break
+		}
+	}
+	if author == nil {
+		author = &wxrAuthor{Login: login}
+	}
+	if author.Login == "" {
+		return 0, errors.New("文章缺少作者")
+	}
+
+	id, err := im.findOrCreateUser(tx, author.Login, author.Email, author.DisplayName, 1)
+	if err != nil {
+		return 0, err
+	}
+	im.st.Users[login] = id
+	return id, nil
+}
+
+// commentUser 评论者映射：注册用户对应其作者账号，访客创建禁用账号
+//
+// 访客不按邮箱匹配已有用户，WXR 中的邮箱可由任何人填写；
+// 访客账号使用占位邮箱，真实邮箱只记录在评论上，不占用本站的注册邮箱。
+func (im *importer) commentUser(tx *gorm.DB, wc *wxrComment) (uint, error) {
+	if wc.UserID != 0 {
+		for _, author := range im.channel.Authors {
+			if author.ID == wc.UserID {
+				return im.authorUser(tx, author.Login)
+			}
+		}
+	}
+
+	key := "email:" + strings.ToLower(wc.AuthorEmail)
+	if wc.AuthorEmail == "" {
+		key = "name:" + wc.Author
+	}
+	if id, ok := im.st.Users[key]; ok {
+		return id, nil
+	}
+
+	// 访客账号禁用登录，仅用于保留评论署名
+	id, err := im.createUser(tx, usernameFor(wc.Author, ""), "", wc.Author, 0)
+	if err != nil {
+		return 0, err
+	}
+	im.st.Users[key] = id
+	return id, nil
+}
+
+// findOrCreateUser 按邮箱匹配已有用户，否则新建
+func (im *importer) findOrCreateUser(tx *gorm.DB, login, email, nickname string, status int8) (uint, error) {
+	var user models.User
+	if email != "" {
+		if err := tx.Where("email = ?", email).First(&user).Error; err == nil {
+			return user.ID, nil
+		}
+	}
+	return im.createUser(tx, usernameFor(login, email), email, nickname, status)
+}
+
+// createUser 新建用户，用户名冲突时追加随机后缀，email 为空时使用占位邮箱
+func (im *importer) createUser(tx *gorm.DB, username, email, nickname string, status int8) (uint, error) {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		username = username + "-" + randomHex(3)
+	}
+	if email == "" {
+		email = username + "@wordpress.invalid"
+	}
+
+	// 随机密码，导入的作者需通过重置密码登录
+	user := models.User{
+		Username: username,
+		Password: randomHex(16),
+		Email:    email,
+		Nickname: truncateRunes(nickname, 50),
+		Status:   status,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, err
+	}
+	if status == 0 {
+		if err := tx.Model(&user).Update("status", 0).Error; err != nil {
+			return 0, err
+		}
+	}
+	im.st.Stats.Users++
+	return user.ID, nil
+}
+
+// usernameFor 生成符合注册规则（3-50 个字符）的用户名，只保留小写字母、数字、- 和 _
+func usernameFor(login, email string) string {
+	clean := func(s string) string {
+		var b strings.Builder
+		for _, r := range strings.ToLower(s) {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+
+	name := clean(login)
+	if len(name) < 3 {
+		if at := strings.IndexByte(email, '@'); at > 0 {
+			name = clean(email[:at])
+		}
+	}
+	if len(name) < 3 {
+		name = "wp-" + randomHex(4)
+	}
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,181 @@
+package wordpress
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"blog/models"
+	"blog/seo"
+
+	"gorm.io/gorm"
+)
+
+var linkAttrPattern = regexp.MustCompile(`(href|src)=(["'])([^"']+)(["'])`)
+
+// rewriteAll 改写所有已导入文章中的站内链接和附件地址
+func (im *importer) rewriteAll() error {
+	db := im.jc.DB()
+	itemCount := len(im.channel.Items)
+
+	wpIDs := make([]int, 0, len(im.st.Posts))
+	for wpID := range im.st.Posts {
+		wpIDs = append(wpIDs, wpID)
+	}
+	sort.Ints(wpIDs)
+
+	// 旧地址 -> 新路径
+	links, err := im.linkMap(db)
+	if err != nil {
+		return err
+	}
+
+	for im.st.Next < len(wpIDs) {
+		if err := im.jc.Err(); err != nil {
+			return err
+		}
+
+		postID := im.st.Posts[wpIDs[im.st.Next]]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var post models.Post
+			if err := tx.First(&post, postID).Error; err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			} else if err == nil {
+				content := im.rewriteContent(post.Content, links)
+				if content != post.Content {
+					if err := tx.Model(&post).UpdateColumn("content", content).Error; err != nil {
+						return err
+					}
+					im.st.Stats.Rewritten++
+				}
+			}
+			im.st.Next++
+			return im.jc.Checkpoint(tx, im.st, im.processed(itemCount))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkMap 建立旧站文章地址（固定链接、?p=ID）到新文章路径的映射
+func (im *importer) linkMap(db *gorm.DB) (map[string]string, error) {
+	ids := make([]uint, 0, len(im.st.Posts))
+	for _, id := range im.st.Posts {
+		ids = append(ids, id)
+	}
+
+	var posts []models.Post
+	if len(ids) > 0 {
+		if err := db.Select("id", "slug").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+	}
+	paths := make(map[uint]string, len(posts))
+	for i := range posts {
+		paths[posts[i].ID] = seo.PostPath(&posts[i])
+	}
+
+	links := make(map[string]string)
+	for _, item := range im.channel.Items {
+		postID, ok := im.st.Posts[item.PostID]
+		if !ok {
+			continue
+		}
+		path, ok := paths[postID]
+		if !ok {
+			continue
+		}
+		for _, old := range []string{item.Link, item.GUID} {
+			if key := normalizeLink(old); key != "" {
+				links[key] = path
+			}
+		}
+		links["?p="+strconv.Itoa(item.PostID)] = path
+	}
+	return links, nil
+}
+
+// rewriteContent 替换附件地址前缀，并将指向旧站文章的链接改为新路径
+func (im *importer) rewriteContent(content string, links map[string]string) string {
+	mediaURL := strings.TrimRight(im.payload.MediaURL, "/") + "/"
+	for _, base := range im.siteBases() {
+		content = strings.ReplaceAll(content, base+"/wp-content/uploads/", mediaURL)
+	}
+
+	return linkAttrPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := linkAttrPattern.FindStringSubmatch(match)
+		attr, open, link, close := parts[1], parts[2], parts[3], parts[4]
+
+		fragment := ""
+		if i := strings.IndexByte(link, '#'); i >= 0 {
+			link, fragment = link[:i], link[i:]
+		}
+		if !im.isInternal(link) {
+			return match
+		}
+		if bases := im.siteBases(); strings.HasPrefix(link, "/") && len(bases) > 0 {
+			link = bases[0] + link
+		}
+
+		if path, ok := links[normalizeLink(link)]; ok {
+			return attr + "=" + open + path + fragment + close
+		}
+		// ?p=123 形式的短链接
+		if u, err := url.Parse(link); err == nil && u.Query().Get("p") != "" {
+			if path, ok := links["?p="+u.Query().Get("p")]; ok {
+				return attr + "=" + open + path + fragment + close
+			}
+		}
+		return match
+	})
+}
+
+// siteBases 旧站地址的 http 与 https 形式
+func (im *importer) siteBases() []string {
+	var bases []string
+	seen := make(map[string]bool)
+	for _, base := range []string{im.channel.BaseSiteURL, im.channel.BaseBlogURL, im.channel.Link} {
+		base = strings.TrimRight(base, "/")
+		if base == "" {
+			continue
+		}
+		host := strings.TrimPrefix(strings.TrimPrefix(base, "https://"), "http://")
+		for _, scheme := range []string{"https://", "http://"} {
+			if !seen[scheme+host] {
+				seen[scheme+host] = true
+				bases = append(bases, scheme+host)
+			}
+		}
+	}
+	return bases
+}
+
+// isInternal 链接是否指向旧站
+func (im *importer) isInternal(link string) bool {
+	if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+		return true
+	}
+	for _, base := range im.siteBases() {
+		if link == base || strings.HasPrefix(link, base+"/") || strings.HasPrefix(link, base+"?") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLink 去掉协议、域名之外的差异（末尾斜杠、片段），用于匹配
+func normalizeLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || link == "" {
+		return ""
+	}
+	key := strings.TrimRight(u.Host+u.EscapedPath(), "/")
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,148 @@
+package wordpress
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// WXR（WordPress eXtended RSS）导出文件结构
+//
+// 字段只按本地名匹配，以兼容 WXR 1.0~1.2 不同的命名空间版本。
+type wxrFile struct {
+	Channel wxrChannel `xml:"channel"`
+}
+
+type wxrChannel struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	BaseSiteURL string        `xml:"base_site_url"`
+	BaseBlogURL string        `xml:"base_blog_url"`
+	Authors     []wxrAuthor   `xml:"author"`
+	Categories  []wxrCategory `xml:"category"`
+	Tags        []wxrTag      `xml:"tag"`
+	Items       []wxrItem     `xml:"item"`
+}
+
+type wxrAuthor struct {
+	ID          int    `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+	Nicename    string `xml:"category_nicename"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type wxrTag struct {
+	Slug string `xml:"tag_slug"`
+	Name string `xml:"tag_name"`
+}
+
+// nsText 带命名空间的文本元素（content:encoded 与 excerpt:encoded 本地名相同）
+type nsText struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrItemCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrItem struct {
+	Title         string            `xml:"title"`
+	Link          string            `xml:"link"`
+	GUID          string            `xml:"guid"`
+	Creator       string            `xml:"creator"`
+	Encoded       []nsText          `xml:"encoded"`
+	PostID        int               `xml:"post_id"`
+	PostDate      string            `xml:"post_date"`
+	PostDateGMT   string            `xml:"post_date_gmt"`
+	PostName      string            `xml:"post_name"`
+	Status        string            `xml:"status"`
+	PostType      string            `xml:"post_type"`
+	AttachmentURL string            `xml:"attachment_url"`
+	Categories    []wxrItemCategory `xml:"category"`
+	Comments      []wxrComment      `xml:"comment"`
+}
+
+type wxrComment struct {
+	ID          int    `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorIP    string `xml:"comment_author_IP"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      int    `xml:"comment_parent"`
+	UserID      int    `xml:"comment_user_id"`
+}
+
+// parseWXR 解析 WXR 文件
+func parseWXR(r io.Reader) (*wxrChannel, error) {
+	var file wxrFile
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+	return &file.Channel, nil
+}
+
+// content 文章正文（content:encoded）
+func (it *wxrItem) content() string {
+	return it.encoded("content")
+}
+
+// excerpt 文章摘要（excerpt:encoded）
+func (it *wxrItem) excerpt() string {
+	return it.encoded("excerpt")
+}
+
+func (it *wxrItem) encoded(kind string) string {
+	for _, e := range it.Encoded {
+		if strings.Contains(e.XMLName.Space, kind) {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// publishedAt 发布时间，优先使用 GMT 时间
+func (it *wxrItem) publishedAt() *time.Time {
+	if t, ok := parseWPTime(it.PostDateGMT, time.UTC); ok {
+		return &t
+	}
+	if t, ok := parseWPTime(it.PostDate, time.Local); ok {
+		return &t
+	}
+	return nil
+}
+
+func (c *wxrComment) createdAt() *time.Time {
+	if t, ok := parseWPTime(c.DateGMT, time.UTC); ok {
+		return &t
+	}
+	if t, ok := parseWPTime(c.Date, time.Local); ok {
+		return &t
+	}
+	return nil
+}
+
+// parseWPTime 解析 WordPress 日期，草稿的 0000-00-00 00:00:00 视为空
+func parseWPTime(value string, loc *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "0000-00-00") {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+	return t, err == nil
+}